@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -19,6 +20,7 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/lib/dns"
 	"github.com/operator-framework/operator-registry/pkg/lib/graceful"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
+	"github.com/operator-framework/operator-registry/pkg/lib/serverflags"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/server"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
@@ -47,6 +49,8 @@ func init() {
 	rootCmd.Flags().StringP("port", "p", "50051", "port number to serve on")
 	rootCmd.Flags().StringP("termination-log", "t", "/dev/termination-log", "path to a container termination log file")
 	rootCmd.Flags().Bool("permissive", false, "allow registry load errors")
+	serverflags.AddTLSFlags(rootCmd.Flags())
+	serverflags.AddMetricsFlags(rootCmd.Flags())
 	if err := rootCmd.Flags().MarkHidden("debug"); err != nil {
 		logrus.Panic(err.Error())
 	}
@@ -104,6 +108,7 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 		logger.Fatalf("error getting configmap: %s", err)
 	}
 
+	start := time.Now()
 	db, err := sqlite.Open(dbName)
 	if err != nil {
 		return err
@@ -134,6 +139,7 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 	if store == nil {
 		store = registry.NewEmptyQuerier()
 	}
+	loadDuration := time.Since(start)
 
 	// sanity check that the db is available
 	tables, err := store.ListTables(context.TODO())
@@ -144,11 +150,21 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 		logger.Warn("no tables found in db")
 	}
 
+	serverOpts, err := serverflags.TLSServerOptions(cmd.Flags(), logger)
+	if err != nil {
+		return err
+	}
+	metricsOpts, err := serverflags.ServeMetrics(context.TODO(), cmd.Flags(), store, logger, serverflags.SQLiteMetricsOptions(context.TODO(), db, loadDuration, logger)...)
+	if err != nil {
+		return err
+	}
+	serverOpts = append(serverOpts, metricsOpts...)
+
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
 		logger.Fatalf("failed to listen: %s", err)
 	}
-	s := grpc.NewServer()
+	s := grpc.NewServer(serverOpts...)
 
 	api.RegisterRegistryServer(s, server.NewRegistryServer(store))
 	health.RegisterHealthServer(s, server.NewHealthServer())
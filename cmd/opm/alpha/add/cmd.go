@@ -1,15 +1,14 @@
 package add
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/action"
-	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
-	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 )
 
 const (
@@ -22,8 +21,11 @@ type add struct {
 	configsDir string
 	debug      bool
 	caFile     string
+	authFile   string
+	policyFile string
 	pullTool   string
 	skipTLS    bool
+	pinDigests bool
 }
 
 func NewCmd() *cobra.Command {
@@ -35,8 +37,13 @@ func NewCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "add <configs_path> <bundle_image1> <bundle_image2>........<bundle_imageN>",
 		Short: "add operator bundle/s to a catalog of packages",
-		Long:  `add operator bundles to a directory of configs representing packages in the catalog`,
-		Args:  cobra.MinimumNArgs(2),
+		Long: `add operator bundles to a directory of configs representing packages in the catalog
+
+Bundle images can also be read from the local filesystem, with references of the
+form oci:DIR:NAME, oci-archive:FILE:NAME or docker-archive:FILE:NAME, where NAME
+is the image reference the bundle was saved as, such as quay.io/example/bundle:v1,
+which the catalog records as the bundle image.`,
+		Args: cobra.MinimumNArgs(2),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			a.configsDir = args[0]
 			if a.debug {
@@ -50,16 +57,22 @@ func NewCmd() *cobra.Command {
 
 	rootCmd.Flags().BoolVar(&a.debug, "debug", false, "enable debug logging")
 	rootCmd.Flags().StringVarP(&a.caFile, "ca-file", "", "", "the root Certificates to use with this command")
+	rootCmd.Flags().StringVar(&a.authFile, "registry-auth-file", "", "path to a registry credentials file, either a docker config.json or a containers auth.json; defaults to the docker config and its credential helpers")
+	rootCmd.Flags().StringVar(&a.policyFile, "verification-policy", "", "path to a verification policy that pulled images must comply with, requiring digest references and/or signatures by trusted public keys")
 	rootCmd.Flags().BoolVar(&a.skipTLS, "skip-tls", false, "disable TLS verification")
+	rootCmd.Flags().BoolVar(&a.pinDigests, "pin-digests", false, "pin bundle images and their related images to digests, recording the original references as olm.image.pinned properties")
 	return rootCmd
 }
 
 func (a *add) addFunc(cmd *cobra.Command, args []string) error {
-	rootCAs, err := certs.RootCAs(a.caFile)
-	if err != nil {
-		return fmt.Errorf("failed to get RootCAs: %v", err)
+	pull := registry.PullOptions{
+		CaFile:             a.caFile,
+		AuthFile:           a.authFile,
+		SkipTLS:            a.skipTLS,
+		VerificationPolicy: a.policyFile,
+		SharedCacheDir:     util.SharedCacheDir(cmd),
 	}
-	reg, err := containerdregistry.NewRegistry(containerdregistry.SkipTLS(a.skipTLS), containerdregistry.WithLog(a.logger), containerdregistry.WithRootCAs(rootCAs))
+	reg, err := pull.NewContainerdRegistry(a.logger)
 	if err != nil {
 		return err
 	}
@@ -76,6 +89,9 @@ func (a *add) addFunc(cmd *cobra.Command, args []string) error {
 		Bundles:    bundles,
 		ConfigsDir: a.configsDir,
 	}
+	if a.pinDigests {
+		request.DigestResolver = reg
+	}
 	adder := action.NewBundleAdder(a.logger)
 	return adder.AddToConfig(request)
 }
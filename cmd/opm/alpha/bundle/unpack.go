@@ -12,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
@@ -77,7 +78,7 @@ func unpackBundle(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	registryOpts = append(registryOpts, containerdregistry.SkipTLS(skipTLS))
+	registryOpts = append(registryOpts, containerdregistry.SkipTLS(skipTLS), containerdregistry.WithSharedCache(util.SharedCacheDir(cmd)))
 
 	var skipValidation bool
 	skipValidation, err = cmd.Flags().GetBool("skip-validation")
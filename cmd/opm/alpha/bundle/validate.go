@@ -9,6 +9,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
@@ -18,6 +19,7 @@ import (
 
 var (
 	optional string
+	authFile string
 )
 
 func newBundleValidateCmd() *cobra.Command {
@@ -26,6 +28,8 @@ func newBundleValidateCmd() *cobra.Command {
 		Short: "Validate bundle image",
 		Long: `The "opm alpha bundle validate" command will validate a bundle image
 from a remote source to determine if its format and content information are accurate. 
+The bundle image can also be read from the local filesystem, with a tag of the form oci:DIR[:NAME],
+oci-archive:FILE[:NAME] or docker-archive:FILE[:NAME].
 Required validators. These validators will run by default on every invocation of the command. 
  * CSV validator - validates the CSV name and replaces fields.
  * CRD validator - validates the CRDs OpenAPI V3 schema. 
@@ -36,8 +40,9 @@ Optional validators. These validators are disabled by default and can be enabled
  * Bundle objects validator - performs validation on resources like PodDisruptionBudgets and PriorityClasses. 
 
 See https://olm.operatorframework.io/docs/tasks/validate-package/#validation for more info.`,
-		Example: `$ opm alpha bundle validate --tag quay.io/test/test-operator:latest --image-builder docker`,
-		RunE:    validateFunc,
+		Example: `$ opm alpha bundle validate --tag quay.io/test/test-operator:latest --image-builder docker
+$ opm alpha bundle validate --tag oci-archive:test-operator.tar --image-builder none`,
+		RunE: validateFunc,
 	}
 
 	bundleValidateCmd.Flags().StringVarP(&tag, "tag", "t", "",
@@ -47,6 +52,7 @@ See https://olm.operatorframework.io/docs/tasks/validate-package/#validation for
 	}
 
 	bundleValidateCmd.Flags().StringVarP(&containerTool, "image-builder", "b", "docker", "Tool used to pull and unpack bundle images. One of: [none, docker, podman]")
+	bundleValidateCmd.Flags().StringVar(&authFile, "registry-auth-file", "", "path to a registry credentials file, either a docker config.json or a containers auth.json; defaults to the docker config and its credential helpers")
 	bundleValidateCmd.Flags().StringVarP(&optional, "optional-validators", "o", "", "Specifies optional validations to be run. One or more of: [operatorhub, bundle-objects]")
 
 	return bundleValidateCmd
@@ -63,10 +69,15 @@ func validateFunc(cmd *cobra.Command, args []string) error {
 
 	tool := containertools.NewContainerTool(containerTool, containertools.NoneTool)
 	switch tool {
-	case containertools.PodmanTool, containertools.DockerTool:
+	case containertools.DockerTool:
+		if authFile != "" {
+			return fmt.Errorf("--registry-auth-file cannot be set with --image-builder=docker, which uses the credentials of its own config")
+		}
 		registry, err = execregistry.NewRegistry(tool, logger)
+	case containertools.PodmanTool:
+		registry, err = execregistry.NewRegistry(tool, logger, containertools.WithAuthFile(authFile))
 	case containertools.NoneTool:
-		registry, err = containerdregistry.NewRegistry(containerdregistry.WithLog(logger))
+		registry, err = containerdregistry.NewRegistry(containerdregistry.WithLog(logger), containerdregistry.WithAuthFile(authFile), containerdregistry.WithSharedCache(util.SharedCacheDir(cmd)))
 	default:
 		err = fmt.Errorf("unrecognized container-tool option: %s", containerTool)
 	}
@@ -3,6 +3,11 @@ package alpha
 import (
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/add"
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/bundle"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/diff"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/graph"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/index"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/render"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/resolve"
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/serve"
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/validate"
 	"github.com/spf13/cobra"
@@ -15,6 +20,6 @@ func NewCmd() *cobra.Command {
 		Short:  "Run an alpha subcommand",
 	}
 
-	runCmd.AddCommand(bundle.NewCmd(), add.NewCmd(), serve.NewCmd(), validate.NewCmd())
+	runCmd.AddCommand(bundle.NewCmd(), add.NewCmd(), serve.NewCmd(), validate.NewCmd(), render.NewCmd(), diff.NewCmd(), graph.NewCmd(), resolve.NewCmd(), index.NewCmd())
 	return runCmd
 }
@@ -0,0 +1,140 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/action"
+)
+
+type diff struct {
+	logger  *logrus.Entry
+	output  string
+	debug   bool
+	caFile  string
+	skipTLS bool
+}
+
+func NewCmd() *cobra.Command {
+	logger := logrus.New()
+	d := diff{
+		logger: logrus.NewEntry(logger),
+	}
+	cmd := &cobra.Command{
+		Use:   "diff <old-ref> <new-ref>",
+		Short: "Compare two catalogs",
+		Long: `Compare two catalogs and report the packages, channels and bundles that were
+added or removed, the bundles that changed, and the channel heads that moved.
+
+Each ref may be an index image, a SQLite index database file, a declarative
+config directory or a package manifests directory.
+
+With --output=json or --output=yaml, a declarative config is written instead of
+the report. It contains only the bundles of the new catalog that were added or
+changed, along with the bundles they replace or skip and the head of their
+channels, and can be used to build a delta catalog.`,
+		Args: cobra.ExactArgs(2),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if d.debug {
+				logger.SetLevel(logrus.DebugLevel)
+			} else {
+				// The loaders used for package manifests directories log
+				// every file they read with the standard logger.
+				logrus.SetLevel(logrus.WarnLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return d.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&d.debug, "debug", false, "enable debug logging")
+	cmd.Flags().StringVarP(&d.output, "output", "o", "text", "output format (text|json|yaml)")
+	cmd.Flags().StringVarP(&d.caFile, "ca-file", "", "", "the root Certificates to use with this command")
+	cmd.Flags().BoolVar(&d.skipTLS, "skip-tls", false, "disable TLS verification")
+	return cmd
+}
+
+func (d *diff) run(cmd *cobra.Command, args []string) error {
+	var write func(action.DiffResult, io.Writer) error
+	switch d.output {
+	case "text":
+		write = func(res action.DiffResult, w io.Writer) error {
+			return writeReport(res.Diff, w)
+		}
+	case "json":
+		write = func(res action.DiffResult, w io.Writer) error {
+			return declcfg.WriteJSON(res.Delta, w)
+		}
+	case "yaml":
+		write = func(res action.DiffResult, w io.Writer) error {
+			return declcfg.WriteYAML(res.Delta, w)
+		}
+	default:
+		return fmt.Errorf("invalid output format %q", d.output)
+	}
+
+	reg, destroy, err := util.CreateCLIRegistry(d.logger, d.caFile, util.SharedCacheDir(cmd), d.skipTLS)
+	if err != nil {
+		return err
+	}
+	defer destroy()
+
+	diff := action.Diff{
+		OldRefs:  []string{args[0]},
+		NewRefs:  []string{args[1]},
+		Registry: reg,
+		Logger:   d.logger,
+	}
+	res, err := diff.Run(cmd.Context())
+	if err != nil {
+		return err
+	}
+	return write(*res, os.Stdout)
+}
+
+func writeReport(d model.Diff, w io.Writer) error {
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "No changes")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tPACKAGE\tCHANNEL\tBUNDLE\tDETAILS")
+	for _, p := range d.AddedPackages {
+		fmt.Fprintf(tw, "added package\t%s\t\t\t\n", p)
+	}
+	for _, p := range d.RemovedPackages {
+		fmt.Fprintf(tw, "removed package\t%s\t\t\t\n", p)
+	}
+	for _, c := range d.ChangedDefaultChannels {
+		fmt.Fprintf(tw, "changed default channel\t%s\t%s\t\tfrom %s\n", c.Package, c.To, c.From)
+	}
+	for _, c := range d.AddedChannels {
+		fmt.Fprintf(tw, "added channel\t%s\t%s\t\t\n", c.Package, c.Channel)
+	}
+	for _, c := range d.RemovedChannels {
+		fmt.Fprintf(tw, "removed channel\t%s\t%s\t\t\n", c.Package, c.Channel)
+	}
+	for _, h := range d.MovedHeads {
+		fmt.Fprintf(tw, "moved channel head\t%s\t%s\t%s\tfrom %s\n", h.Package, h.Channel, h.To, h.From)
+	}
+	for _, b := range d.AddedBundles {
+		fmt.Fprintf(tw, "added bundle\t%s\t%s\t%s\t\n", b.Package, b.Channel, b.Name)
+	}
+	for _, b := range d.RemovedBundles {
+		fmt.Fprintf(tw, "removed bundle\t%s\t%s\t%s\t\n", b.Package, b.Channel, b.Name)
+	}
+	for _, b := range d.ChangedBundles {
+		fmt.Fprintf(tw, "changed bundle\t%s\t%s\t%s\t\n", b.Package, b.Channel, b.Name)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/action"
+)
+
+type graph struct {
+	logger      *logrus.Entry
+	packageName string
+	channel     string
+	output      string
+	debug       bool
+	caFile      string
+	skipTLS     bool
+}
+
+func NewCmd() *cobra.Command {
+	logger := logrus.New()
+	g := graph{
+		logger: logrus.NewEntry(logger),
+	}
+	cmd := &cobra.Command{
+		Use:   "graph <index-image | sqlite-file | declcfg-dir | package-manifests-dir>",
+		Short: "Render the upgrade graph of a package",
+		Long: `Render the upgrade graph of each channel of a package as a Graphviz DOT or
+Mermaid diagram and write it to stdout.
+
+Replaces edges are drawn as solid lines, skips edges as dashed lines and
+skipRange edges as dotted (DOT) or thick (Mermaid) lines. Channel heads,
+the default channel and deprecated bundles are highlighted.
+
+For example, to render a package as an SVG image with Graphviz:
+
+  opm alpha graph ./index.db --package etcd | dot -Tsvg > etcd.svg`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if g.debug {
+				logger.SetLevel(logrus.DebugLevel)
+			} else {
+				// The loaders used for package manifests directories log
+				// every file they read with the standard logger.
+				logrus.SetLevel(logrus.WarnLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return g.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&g.packageName, "package", "p", "", "the package to render (required if the catalog contains more than one package)")
+	cmd.Flags().StringVarP(&g.channel, "channel", "c", "", "render only this channel")
+	cmd.Flags().StringVarP(&g.output, "output", "o", string(model.GraphFormatDOT), "output format (dot|mermaid)")
+	cmd.Flags().BoolVar(&g.debug, "debug", false, "enable debug logging")
+	cmd.Flags().StringVarP(&g.caFile, "ca-file", "", "", "the root Certificates to use with this command")
+	cmd.Flags().BoolVar(&g.skipTLS, "skip-tls", false, "disable TLS verification")
+	return cmd
+}
+
+func (g *graph) run(cmd *cobra.Command, args []string) error {
+	format := model.GraphFormat(g.output)
+	if format != model.GraphFormatDOT && format != model.GraphFormatMermaid {
+		return fmt.Errorf("invalid output format %q", g.output)
+	}
+
+	reg, destroy, err := util.CreateCLIRegistry(g.logger, g.caFile, util.SharedCacheDir(cmd), g.skipTLS)
+	if err != nil {
+		return err
+	}
+	defer destroy()
+
+	render := action.Render{
+		Refs:     args,
+		Registry: reg,
+		Logger:   g.logger,
+	}
+	m, err := render.RenderModel(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	pkg, err := g.selectPackage(m)
+	if err != nil {
+		return err
+	}
+	return model.WriteGraph(os.Stdout, pkg, format)
+}
+
+// selectPackage returns the requested package, limited to the requested
+// channel if one was set.
+func (g *graph) selectPackage(m model.Model) (*model.Package, error) {
+	var pkg *model.Package
+	switch {
+	case g.packageName != "":
+		pkg = m[g.packageName]
+		if pkg == nil {
+			return nil, fmt.Errorf("package %q not found", g.packageName)
+		}
+	case len(m) == 1:
+		for _, p := range m {
+			pkg = p
+		}
+	default:
+		var names []string
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("--package is required, choose one of: %s", strings.Join(names, ", "))
+	}
+
+	if g.channel == "" {
+		return pkg, nil
+	}
+	ch, ok := pkg.Channels[g.channel]
+	if !ok {
+		return nil, fmt.Errorf("channel %q not found in package %q", g.channel, pkg.Name)
+	}
+	filtered := *pkg
+	filtered.Channels = map[string]*model.Channel{ch.Name: ch}
+	return &filtered, nil
+}
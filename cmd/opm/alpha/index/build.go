@@ -0,0 +1,85 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+)
+
+var (
+	buildLong = templates.LongDesc(`
+		Build an index image that serves a directory of declarative configs.
+
+		The configs are validated, then added to the image at /configs. The image is labeled with the location of the configs so that "opm alpha serve" and "opm alpha render" can use it directly, and by default runs "opm alpha serve /configs".
+
+		With the build tool none, the image is built without a container tool by adding the configs as a layer on top of the binary image, and is pushed to --tag.
+	`)
+
+	buildExample = templates.Examples(`
+		# Build an index image with podman
+		%[1]s ./configs --tag quay.io/operator-framework/monitoring:1.0.0
+
+		# Build an index image without a container tool and push it
+		%[1]s ./configs --tag quay.io/operator-framework/monitoring:1.0.0 --build-tool none
+
+		# Generate a Dockerfile instead of an image
+		%[1]s ./configs --generate
+	`)
+)
+
+type build struct {
+	request   indexer.BuildConfigsIndexRequest
+	buildTool string
+	debug     bool
+	logger    *logrus.Entry
+}
+
+func newBuildCmd() *cobra.Command {
+	logger := logrus.New()
+	b := build{
+		logger: logrus.NewEntry(logger),
+	}
+	cmd := &cobra.Command{
+		Use:   "build <declcfg-dir>",
+		Short: "Build an index image of declarative configs",
+		Long:  buildLong,
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			b.request.ConfigsDir = args[0]
+			if b.debug {
+				logger.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			b.request.SharedCacheDir = util.SharedCacheDir(cmd)
+			return b.run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&b.debug, "debug", false, "enable debug logging")
+	cmd.Flags().BoolVar(&b.request.Generate, "generate", false, "if enabled, just creates the dockerfile and saves it to local disk")
+	cmd.Flags().StringVarP(&b.request.OutDockerfile, "out-dockerfile", "d", "", "if generating the dockerfile, this flag is used to (optionally) specify a dockerfile name")
+	cmd.Flags().StringVarP(&b.request.BinarySourceImage, "binary-image", "i", "", "container image for on-image `opm` command")
+	cmd.Flags().StringVarP(&b.buildTool, "build-tool", "u", "", "tool to build container images. One of: [none, docker, podman]. Defaults to podman. With none, the image is built without a container tool for the platform of this host only, and pushed to --tag.")
+	cmd.Flags().StringVarP(&b.request.Tag, "tag", "t", "", "custom tag for container image being built (required with build tool none, which pushes the image to it)")
+	cmd.Flags().StringVarP(&b.request.CaFile, "ca-file", "", "", "the root Certificates to use with build tool none")
+	cmd.Flags().BoolVar(&b.request.SkipTLS, "skip-tls", false, "disable TLS verification with build tool none")
+
+	cmd.Example = fmt.Sprintf(buildExample, "opm alpha index build")
+	return cmd
+}
+
+func (b *build) run() error {
+	b.logger = b.logger.WithFields(logrus.Fields{"configs": b.request.ConfigsDir})
+	b.logger.Info("building the index")
+
+	builder := indexer.NewIndexConfigsBuilder(containertools.NewContainerTool(b.buildTool, containertools.PodmanTool), b.logger)
+	return builder.BuildConfigsIndex(b.request)
+}
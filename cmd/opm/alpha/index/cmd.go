@@ -0,0 +1,15 @@
+package index
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Build index images of declarative configs",
+	}
+
+	cmd.AddCommand(newBuildCmd())
+	return cmd
+}
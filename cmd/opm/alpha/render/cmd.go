@@ -0,0 +1,85 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/action"
+)
+
+type render struct {
+	logger  *logrus.Entry
+	output  string
+	debug   bool
+	caFile  string
+	skipTLS bool
+}
+
+func NewCmd() *cobra.Command {
+	logger := logrus.New()
+	r := render{
+		logger: logrus.NewEntry(logger),
+	}
+	cmd := &cobra.Command{
+		Use:   "render [index-image | bundle-image | sqlite-file | declcfg-dir | package-manifests-dir]...",
+		Short: "Generate a declarative config blob from catalogs and bundles",
+		Long: `Generate a declarative config blob from the provided index images, bundle images,
+SQLite index database files, declarative config directories and package manifests
+directories, and write it to stdout`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if r.debug {
+				logger.SetLevel(logrus.DebugLevel)
+			} else {
+				// The loaders used for package manifests directories log
+				// every file they read with the standard logger.
+				logrus.SetLevel(logrus.WarnLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&r.debug, "debug", false, "enable debug logging")
+	cmd.Flags().StringVarP(&r.output, "output", "o", "json", "output format (json|yaml)")
+	cmd.Flags().StringVarP(&r.caFile, "ca-file", "", "", "the root Certificates to use with this command")
+	cmd.Flags().BoolVar(&r.skipTLS, "skip-tls", false, "disable TLS verification")
+	return cmd
+}
+
+func (r *render) run(cmd *cobra.Command, args []string) error {
+	var write func(declcfg.DeclarativeConfig, io.Writer) error
+	switch r.output {
+	case "json":
+		write = declcfg.WriteJSON
+	case "yaml":
+		write = declcfg.WriteYAML
+	default:
+		return fmt.Errorf("invalid output format %q", r.output)
+	}
+
+	reg, destroy, err := util.CreateCLIRegistry(r.logger, r.caFile, util.SharedCacheDir(cmd), r.skipTLS)
+	if err != nil {
+		return err
+	}
+	defer destroy()
+
+	render := action.Render{
+		Refs:     args,
+		Registry: reg,
+		Logger:   r.logger,
+	}
+	cfg, err := render.Run(cmd.Context())
+	if err != nil {
+		return err
+	}
+	return write(*cfg, os.Stdout)
+}
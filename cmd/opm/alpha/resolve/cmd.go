@@ -0,0 +1,145 @@
+package resolve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/internal/resolve"
+	"github.com/operator-framework/operator-registry/pkg/action"
+)
+
+type resolveCmd struct {
+	logger  *logrus.Entry
+	request resolve.Request
+	output  string
+	debug   bool
+	caFile  string
+	skipTLS bool
+}
+
+func NewCmd() *cobra.Command {
+	logger := logrus.New()
+	r := resolveCmd{
+		logger: logrus.NewEntry(logger),
+	}
+	cmd := &cobra.Command{
+		Use:   "resolve <index-image | sqlite-file | declcfg-dir | package-manifests-dir>...",
+		Short: "Resolve the dependencies of a bundle in a catalog",
+		Long: `Compute the bundles that would be installed along with a bundle of a catalog,
+and the reason each of them was chosen.
+
+Dependencies are followed through the olm.package.required, olm.gvk.required
+and olm.label.required properties of each chosen bundle. At most one bundle is
+chosen per package. Package dependencies choose the highest version in range
+that is not deprecated, preferring the default channel. API and label
+dependencies choose the head of a default channel that provides them, picking
+packages in lexicographical order.
+
+If any dependency cannot be satisfied, the constraint and an explanation are
+reported and the command fails.`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if r.debug {
+				logger.SetLevel(logrus.DebugLevel)
+			} else {
+				// The loaders used for package manifests directories log
+				// every file they read with the standard logger.
+				logrus.SetLevel(logrus.WarnLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&r.request.Package, "package", "p", "", "the package to install")
+	cmd.Flags().StringVarP(&r.request.Channel, "channel", "c", "", "the channel to install from (defaults to the default channel of the package)")
+	cmd.Flags().StringVarP(&r.request.Bundle, "bundle", "b", "", "the bundle to install (defaults to the head of the channel)")
+	cmd.Flags().StringVarP(&r.output, "output", "o", "text", "output format (text|json|yaml)")
+	cmd.Flags().BoolVar(&r.debug, "debug", false, "enable debug logging")
+	cmd.Flags().StringVarP(&r.caFile, "ca-file", "", "", "the root Certificates to use with this command")
+	cmd.Flags().BoolVar(&r.skipTLS, "skip-tls", false, "disable TLS verification")
+	if err := cmd.MarkFlagRequired("package"); err != nil {
+		logger.Panic(err)
+	}
+	return cmd
+}
+
+func (r *resolveCmd) run(cmd *cobra.Command, args []string) error {
+	var write func(resolve.Resolution, io.Writer) error
+	switch r.output {
+	case "text":
+		write = writeReport
+	case "json":
+		write = writeJSON
+	case "yaml":
+		write = writeYAML
+	default:
+		return fmt.Errorf("invalid output format %q", r.output)
+	}
+
+	reg, destroy, err := util.CreateCLIRegistry(r.logger, r.caFile, util.SharedCacheDir(cmd), r.skipTLS)
+	if err != nil {
+		return err
+	}
+	defer destroy()
+
+	res, err := action.Resolve{
+		Refs:     args,
+		Request:  r.request,
+		Registry: reg,
+		Logger:   r.logger,
+	}.Run(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if err := write(*res, os.Stdout); err != nil {
+		return err
+	}
+	if !res.Satisfied() {
+		return fmt.Errorf("%d unsatisfiable constraint(s)", len(res.Unsatisfied))
+	}
+	return nil
+}
+
+func writeReport(res resolve.Resolution, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tCHANNEL\tBUNDLE\tVERSION\tREASON")
+	for _, s := range res.Bundles {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Package, s.Channel, s.Bundle, s.Version, strings.Join(s.Reasons, "; "))
+	}
+	if len(res.Unsatisfied) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "UNSATISFIED\tBUNDLE\tCONSTRAINT\tREASON")
+		for _, u := range res.Unsatisfied {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\n", u.Bundle, u.Constraint, u.Message)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeJSON(res resolve.Resolution, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(res)
+}
+
+func writeYAML(res resolve.Resolution, w io.Writer) error {
+	out, err := yaml.Marshal(res)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -3,25 +3,39 @@ package serve
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
-	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/api"
 	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
 	"github.com/operator-framework/operator-registry/pkg/lib/dns"
 	"github.com/operator-framework/operator-registry/pkg/lib/graceful"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
-	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
+	"github.com/operator-framework/operator-registry/pkg/lib/serverflags"
 	"github.com/operator-framework/operator-registry/pkg/server"
 )
 
 type serve struct {
-	configDir string
+	configDir      string
+	reloadInterval time.Duration
+	caFile         string
+	authFile       string
+	skipTLS        bool
+	sharedCacheDir string
 
 	port           string
 	terminationLog string
@@ -36,10 +50,19 @@ func NewCmd() *cobra.Command {
 		logger: logrus.NewEntry(logger),
 	}
 	cmd := &cobra.Command{
-		Use:   "serve <source_path>",
+		Use:   "serve <source_path | index-image>",
 		Short: "serve declarative configs",
-		Long:  `serve declarative configs via grpc`,
-		Args:  cobra.ExactArgs(1),
+		Long: `serve declarative configs via grpc
+
+The config directory is polled for changes. When its contents change, the
+configs are reloaded and validated in the background, and the new configs are
+only served once they are valid. Requests in flight during a reload are
+answered from the previous configs.
+
+If the source path does not exist, it is pulled as an index image built by
+"opm alpha index build", and the configs it contains are served. Images are
+not polled for changes.`,
+		Args: cobra.ExactArgs(1),
 		PreRunE: func(_ *cobra.Command, args []string) error {
 			s.configDir = args[0]
 			if s.debug {
@@ -48,17 +71,28 @@ func NewCmd() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return s.run(cmd.Context())
+			s.sharedCacheDir = util.SharedCacheDir(cmd)
+			return s.run(cmd.Context(), cmd.Flags())
 		},
 	}
 
 	cmd.Flags().BoolVar(&s.debug, "debug", false, "enable debug logging")
 	cmd.Flags().StringVarP(&s.port, "port", "p", "50051", "port number to serve on")
+	cmd.Flags().DurationVar(&s.reloadInterval, "reload-interval", 10*time.Second, "interval at which to poll the config directory for changes, or 0 to disable reloading")
 	cmd.Flags().StringVarP(&s.terminationLog, "termination-log", "t", "/dev/termination-log", "path to a container termination log file")
+	cmd.Flags().StringVarP(&s.caFile, "ca-file", "", "", "the root Certificates to use when pulling an index image")
+	cmd.Flags().StringVar(&s.authFile, "registry-auth-file", "", "path to a registry credentials file to use when pulling an index image, either a docker config.json or a containers auth.json; defaults to the docker config and its credential helpers")
+	cmd.Flags().BoolVar(&s.skipTLS, "skip-tls", false, "disable TLS verification when pulling an index image")
+	serverflags.AddTLSFlags(cmd.Flags())
+	serverflags.AddMetricsFlags(cmd.Flags())
 	return cmd
 }
 
-func (s *serve) run(ctx context.Context) error {
+func (s *serve) run(ctx context.Context, flags *pflag.FlagSet) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	// Immediately set up termination log
 	err := log.AddDefaultWriterHooks(s.terminationLog)
 	if err != nil {
@@ -72,30 +106,101 @@ func (s *serve) run(ctx context.Context) error {
 
 	s.logger = s.logger.WithFields(logrus.Fields{"configs": s.configDir, "port": s.port})
 
-	cfg, err := declcfg.LoadDir(s.configDir)
+	if _, err := os.Stat(s.configDir); os.IsNotExist(err) {
+		tmpDir, err := ioutil.TempDir("", "serve-configs-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		if s.configDir, err = s.unpackImage(ctx, s.configDir, tmpDir); err != nil {
+			return err
+		}
+		s.reloadInterval = 0
+	}
+
+	reloader, err := server.NewConfigReloader(s.configDir, s.logger)
 	if err != nil {
-		return fmt.Errorf("load declarative config directory: %v", err)
+		return err
 	}
 
-	m, err := declcfg.ConvertToModel(*cfg)
+	serverOpts, err := serverflags.TLSServerOptions(flags, s.logger)
 	if err != nil {
-		return fmt.Errorf("could not build index model from declarative config: %v", err)
+		return err
 	}
-	store := registry.NewQuerier(m)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	metricsOpts, err := serverflags.ServeMetrics(ctx, flags, reloader.Querier(), s.logger, server.WithMetricsReloadStatus(reloader))
+	if err != nil {
+		return err
+	}
+	serverOpts = append(serverOpts, metricsOpts...)
 
 	lis, err := net.Listen("tcp", ":"+s.port)
 	if err != nil {
 		s.logger.Fatalf("failed to listen: %s", err)
 	}
 
-	grpcServer := grpc.NewServer()
-	api.RegisterRegistryServer(grpcServer, server.NewRegistryServer(store))
-	health.RegisterHealthServer(grpcServer, server.NewHealthServer())
+	grpcServer := grpc.NewServer(serverOpts...)
+	watcher := server.NewCatalogWatcher(reloader.Querier(), s.logger)
+	api.RegisterRegistryServer(grpcServer, server.NewRegistryServer(reloader.Querier(), server.WithCatalogWatcher(watcher)))
+	health.RegisterHealthServer(grpcServer, server.NewHealthServer(server.WithReloadStatus(reloader)))
 	reflection.Register(grpcServer)
+
+	if s.reloadInterval > 0 {
+		go reloader.Watch(ctx, s.reloadInterval)
+		go watcher.Run(ctx, s.reloadInterval)
+	}
+
 	s.logger.Info("serving registry")
 	return graceful.Shutdown(s.logger, func() error {
 		return grpcServer.Serve(lis)
 	}, func() {
+		cancel()
 		grpcServer.GracefulStop()
 	})
 }
+
+// unpackImage pulls an index image of declarative configs and unpacks it into
+// dir, returning the directory of the configs.
+func (s *serve) unpackImage(ctx context.Context, ref, dir string) (string, error) {
+	pull := registry.PullOptions{
+		CaFile:         s.caFile,
+		AuthFile:       s.authFile,
+		SkipTLS:        s.skipTLS,
+		SharedCacheDir: s.sharedCacheDir,
+	}
+	reg, err := pull.NewContainerdRegistry(s.logger, containerdregistry.WithCacheDir(filepath.Join(dir, "cache")))
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := reg.Destroy(); err != nil {
+			s.logger.Errorf("error destroying local cache: %v", err)
+		}
+	}()
+
+	imageRef := image.SimpleReference(ref)
+	s.logger.Infof("pulling image %q", ref)
+	if err := reg.Pull(ctx, imageRef); err != nil {
+		return "", fmt.Errorf("pull image %q: %v", ref, err)
+	}
+	labels, err := reg.Labels(ctx, imageRef)
+	if err != nil {
+		return "", fmt.Errorf("get labels of image %q: %v", ref, err)
+	}
+	location, ok := labels[containertools.ConfigsLocationLabel]
+	if !ok {
+		return "", fmt.Errorf("image %q is not an index image of declarative configs (missing label %q)", ref, containertools.ConfigsLocationLabel)
+	}
+	root := filepath.Join(dir, "root")
+	if err := reg.Unpack(ctx, imageRef, root); err != nil {
+		return "", fmt.Errorf("unpack image %q: %v", ref, err)
+	}
+	configsDir, err := containertools.JoinLocation(root, location)
+	if err != nil {
+		return "", fmt.Errorf("image %q has an invalid label %q: %v", ref, containertools.ConfigsLocationLabel, err)
+	}
+	return configsDir, nil
+}
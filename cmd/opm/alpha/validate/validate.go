@@ -1,12 +1,16 @@
 package validate
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"text/tabwriter"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/internal/lint"
 	"github.com/operator-framework/operator-registry/pkg/lib/config"
 )
 
@@ -14,12 +18,25 @@ func NewCmd() *cobra.Command {
 	validate := &cobra.Command{
 		Use:   "validate <directory>",
 		Short: "Validate the declarative index config",
-		Long:  "Validate the declarative config JSON file(s) in a given directory",
-		Args:  cobra.ExactArgs(1),
-		RunE:  configValidate,
+		Long: `Validate the declarative config JSON file(s) in a given directory.
+
+After the configs are validated structurally, the upgrade graph of each
+package is linted. Each finding has a severity of error or warning; only
+findings with error severity cause validation to fail. Use --list-rules
+to see the available rules, and --disable-rule to turn individual rules off.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if listRules, _ := cmd.Flags().GetBool("list-rules"); listRules {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: configValidate,
 	}
 
 	validate.Flags().BoolP("debug", "d", false, "enable debug log output")
+	validate.Flags().StringP("output", "o", "text", "output format for findings (text|json)")
+	validate.Flags().StringSlice("disable-rule", nil, "disable the named lint rule (can be specified multiple times)")
+	validate.Flags().Bool("list-rules", false, "list the available lint rules and exit")
 	return validate
 }
 
@@ -28,6 +45,29 @@ func configValidate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid output format %q", output)
+	}
+	disabled, err := cmd.Flags().GetStringSlice("disable-rule")
+	if err != nil {
+		return err
+	}
+	listRules, err := cmd.Flags().GetBool("list-rules")
+	if err != nil {
+		return err
+	}
+
+	linter, err := lint.NewLinter(lint.WithDisabledRules(disabled...))
+	if err != nil {
+		return err
+	}
+	if listRules {
+		return writeRules(os.Stdout, linter)
+	}
 
 	directory := args[0]
 	if _, err := os.Stat(directory); os.IsNotExist(err) {
@@ -39,11 +79,54 @@ func configValidate(cmd *cobra.Command, args []string) error {
 		logger.Logger.SetLevel(logrus.DebugLevel)
 	}
 
-	err = config.ValidateConfig(directory)
+	findings, err := config.LintConfig(directory, linter)
 	if err != nil {
 		logger.Error(err.Error())
 		return fmt.Errorf("failed to validate config: %s", err)
 	}
 
+	switch output {
+	case "json":
+		err = writeJSON(os.Stdout, findings)
+	default:
+		err = writeText(os.Stdout, findings)
+	}
+	if err != nil {
+		return err
+	}
+
+	if lint.HasErrors(findings) {
+		return fmt.Errorf("failed to validate config: found lint errors")
+	}
+	return nil
+}
+
+func writeText(w io.Writer, findings []lint.Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintln(w, f); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func writeJSON(w io.Writer, findings []lint.Finding) error {
+	if findings == nil {
+		findings = []lint.Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(struct {
+		Findings []lint.Finding `json:"findings"`
+	}{findings})
+}
+
+func writeRules(w io.Writer, linter *lint.Linter) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSEVERITY\tENABLED\tDESCRIPTION")
+	for _, r := range linter.Rules() {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\n", r.Name, r.Severity, linter.Enabled(r.Name), r.Description)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+)
+
+// NewCmd returns the command that manages the image cache shared by opm
+// commands run with --cache-dir.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the shared image cache",
+		Long:  `manage the content cache shared by opm commands run with --cache-dir`,
+	}
+	cmd.AddCommand(newGCCmd())
+	return cmd
+}
+
+func newGCCmd() *cobra.Command {
+	var (
+		maxAge  time.Duration
+		maxSize string
+	)
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "remove unused content from the shared image cache",
+		Long: `remove unused content from the shared image cache given by --cache-dir
+
+Content that hasn't been used by a pull for longer than --max-age is removed,
+then the least recently used content until the cache is no larger than
+--max-size. gc fails if other commands are using the cache, and commands
+started while it runs wait for it to finish.`,
+		Example: `  opm cache gc --cache-dir /var/cache/opm --max-age 168h --max-size 20Gi`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := cmd.Flags().GetString("cache-dir")
+			if err != nil {
+				return err
+			}
+			if dir == "" {
+				return errors.New("--cache-dir must be set")
+			}
+			var size int64
+			if maxSize != "" {
+				q, err := resource.ParseQuantity(maxSize)
+				if err != nil {
+					return fmt.Errorf("invalid --max-size %q: %v", maxSize, err)
+				}
+				size = q.Value()
+			}
+			if maxAge == 0 && size == 0 {
+				return errors.New("at least one of --max-age and --max-size must be set")
+			}
+
+			removed, kept, err := containerdregistry.GarbageCollectCache(dir, maxAge, size)
+			if err != nil {
+				return fmt.Errorf("error collecting garbage in %s: %v", dir, err)
+			}
+			logrus.WithFields(logrus.Fields{
+				"removed-blobs": removed.Blobs,
+				"removed-bytes": removed.Size,
+				"kept-blobs":    kept.Blobs,
+				"kept-bytes":    kept.Size,
+			}).Info("collected garbage in the shared image cache")
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&maxAge, "max-age", 0, "remove content that hasn't been used for longer than this")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "remove the least recently used content until the cache is no larger than this, e.g. 20Gi")
+	return cmd
+}
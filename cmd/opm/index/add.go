@@ -7,8 +7,10 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/kubectl/pkg/util/templates"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	libregistry "github.com/operator-framework/operator-registry/pkg/lib/registry"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
 
@@ -19,6 +21,8 @@ var (
 		This command will add the given set of bundle images (specified by the --bundles option) to an index image (provided by the --from-index option).
 
 		If multiple bundles are given with '--mode=replaces' (the default), bundles are added to the index by order of ascending (semver) version unless the update graph specified by replaces requires a different input order; e.g. 1.0.0 replaces 1.0.1 would result in [1.0.1, 1.0.0] instead of the [1.0.0, 1.0.1] normally expected of semver. However, for most cases (e.g. 1.0.1 replaces 1.0.0) the bundle with the highest version is used to set the default channel of the related package.
+
+		Bundle images and the index image can also be read from the local filesystem, with references of the form oci:DIR[:NAME] for an OCI image layout, oci-archive:FILE[:NAME] for an archive of one, and docker-archive:FILE[:NAME] for an archive written by docker save. NAME selects an image by the name it was saved as, and must be given for bundle images as a full image reference, such as quay.io/example/bundle:v1, which the index records as the bundle image.
 	`)
 
 	addExample = templates.Examples(`
@@ -28,6 +32,9 @@ var (
 		# Add a single bundle image to an index image
 		%[1]s --bundles quay.io/operator-framework/operator-bundle-prometheus:0.15.0 --from-index quay.io/operator-framework/monitoring:1.0.0 --tag quay.io/operator-framework/monitoring:1.0.1
 
+		# Create an index from a bundle archived by docker save and generate a Dockerfile instead of an image
+		%[1]s --bundles docker-archive:bundle.tar --generate
+
 		# Add multiple bundles to an index and generate a Dockerfile instead of an image
 		%[1]s --bundles quay.io/operator-framework/operator-bundle-prometheus:0.15.0,quay.io/operator-framework/operator-bundle-prometheus:0.22.2 --generate
 	`)
@@ -57,12 +64,16 @@ func addIndexAddCmd(parent *cobra.Command) {
 		logrus.Panic("Failed to set required `bundles` flag for `index add`")
 	}
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
-	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
-	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman]. Defaults to podman. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [none, docker, podman]. Defaults to podman. With none, the image is built without a container tool for the platform of this host only, and pushed to --tag. Overrides part of container-tool.")
 	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman]. Defaults to none. Overrides part of container-tool.")
-	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
+	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built (required with build tool none, which pushes the image to it)")
 	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
 	indexCmd.Flags().StringP("mode", "", "replaces", "graph update mode that defines how channel graphs are updated. One of: [replaces, semver, semver-skippatch]")
+	indexCmd.Flags().String("registry-auth-file", "", "path to a registry credentials file, either a docker config.json or a containers auth.json; defaults to the docker config and its credential helpers")
+	indexCmd.Flags().String("verification-policy", "", "path to a verification policy that pulled images must comply with, requiring digest references and/or signatures by trusted public keys")
+	indexCmd.Flags().Bool("pin-digests", false, "pin bundle images and their related images to digests, recording the original references as olm.image.pinned properties")
+	indexCmd.Flags().Int("pull-workers", libregistry.DefaultPullWorkers, "number of bundle images to pull and unpack concurrently")
 
 	indexCmd.Flags().Bool("overwrite-latest", false, "overwrite the latest bundles (channel heads) with those of the same csv name given by --bundles")
 	if err := indexCmd.Flags().MarkHidden("overwrite-latest"); err != nil {
@@ -138,6 +149,26 @@ func runIndexAddCmdFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	pullWorkers, err := cmd.Flags().GetInt("pull-workers")
+	if err != nil {
+		return err
+	}
+
+	authFile, err := cmd.Flags().GetString("registry-auth-file")
+	if err != nil {
+		return err
+	}
+
+	policyFile, err := cmd.Flags().GetString("verification-policy")
+	if err != nil {
+		return err
+	}
+
+	pinDigests, err := cmd.Flags().GetBool("pin-digests")
+	if err != nil {
+		return err
+	}
+
 	modeEnum, err := registry.GetModeFromString(mode)
 	if err != nil {
 		return err
@@ -147,6 +178,15 @@ func runIndexAddCmdFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if authFile != "" && containertools.NewContainerTool(pullTool, containertools.NoneTool) == containertools.DockerTool {
+		return fmt.Errorf("--registry-auth-file cannot be set with pull tool docker, which uses the credentials of its own config")
+	}
+	if policyFile != "" && containertools.NewContainerTool(pullTool, containertools.NoneTool) != containertools.NoneTool {
+		return fmt.Errorf("--verification-policy can only be set with pull tool none")
+	}
+	if pinDigests && containertools.NewContainerTool(pullTool, containertools.NoneTool) != containertools.NoneTool {
+		return fmt.Errorf("--pin-digests can only be set with pull tool none")
+	}
 
 	logger := logrus.WithFields(logrus.Fields{"bundles": bundles})
 
@@ -166,9 +206,16 @@ func runIndexAddCmdFunc(cmd *cobra.Command, args []string) error {
 		Bundles:           bundles,
 		Permissive:        permissive,
 		Mode:              modeEnum,
-		SkipTLS:           skipTLS,
-		Overwrite:         overwrite,
-		EnableAlpha:       enableAlpha,
+		PullOptions: libregistry.PullOptions{
+			SkipTLS:            skipTLS,
+			AuthFile:           authFile,
+			VerificationPolicy: policyFile,
+			SharedCacheDir:     util.SharedCacheDir(cmd),
+		},
+		Overwrite:   overwrite,
+		EnableAlpha: enableAlpha,
+		PullWorkers: pullWorkers,
+		PinDigests:  pinDigests,
 	}
 
 	err = indexAdder.AddToIndex(request)
@@ -187,10 +234,6 @@ func getContainerTools(cmd *cobra.Command) (string, string, error) {
 		return "", "", err
 	}
 
-	if buildTool == "none" {
-		return "", "", fmt.Errorf("none is not a valid container-tool for index add")
-	}
-
 	pullTool, err := cmd.Flags().GetString("pull-tool")
 	if err != nil {
 		return "", "", err
@@ -18,10 +18,17 @@ func AddCommand(parent *cobra.Command) {
 			}
 			return nil
 		},
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// cobra only runs the closest persistent pre-run, so run the parent's first.
+			if parent.PersistentPreRunE != nil {
+				if err := parent.PersistentPreRunE(cmd, args); err != nil {
+					return err
+				}
+			}
 			if skipTLS, err := cmd.Flags().GetBool("skip-tls"); err == nil && skipTLS {
 				logrus.Warn("--skip-tls flag is set: this mode is insecure and meant for development purposes only.")
 			}
+			return nil
 		},
 	}
 
@@ -4,8 +4,10 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 )
 
 func newIndexDeleteCmd() *cobra.Command {
@@ -37,9 +39,9 @@ func newIndexDeleteCmd() *cobra.Command {
 	}
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
 	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
-	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman]. Defaults to podman. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [none, docker, podman]. Defaults to podman. With none, the image is built without a container tool for the platform of this host only, and pushed to --tag. Overrides part of container-tool.")
 	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman]. Defaults to none. Overrides part of container-tool.")
-	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
+	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built (required with build tool none, which pushes the image to it)")
 	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
 
 	if err := indexCmd.Flags().MarkHidden("debug"); err != nil {
@@ -113,7 +115,10 @@ func runIndexDeleteCmdFunc(cmd *cobra.Command, args []string) error {
 		Operators:         operators,
 		Tag:               tag,
 		Permissive:        permissive,
-		SkipTLS:           skipTLS,
+		PullOptions: registry.PullOptions{
+			SkipTLS:        skipTLS,
+			SharedCacheDir: util.SharedCacheDir(cmd),
+		},
 	}
 
 	err = indexDeleter.DeleteFromIndex(request)
@@ -7,8 +7,10 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/kubectl/pkg/util/templates"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 )
 
 var exportLong = templates.LongDesc(`
@@ -17,6 +19,9 @@ var exportLong = templates.LongDesc(`
 	This command will take an index image (specified by the --index option), parse it for the given operator(s) (set by 
 	the --package option) and export the operator metadata into an appregistry compliant format (a package.yaml file). 
 
+	The index and bundle images can also be read from the local filesystem, with references of the form oci:DIR[:NAME], 
+	oci-archive:FILE[:NAME] or docker-archive:FILE[:NAME]. 
+
 	Note: the appregistry format is being deprecated in favor of the new index image and image bundle format. 
 	`)
 
@@ -114,7 +119,10 @@ func runIndexExportCmdFunc(cmd *cobra.Command, args []string) error {
 		Packages:      packages,
 		DownloadPath:  downloadPath,
 		ContainerTool: containertools.NewContainerTool(containerTool, containertools.NoneTool),
-		SkipTLS:       skipTLS,
+		PullOptions: registry.PullOptions{
+			SkipTLS:        skipTLS,
+			SharedCacheDir: util.SharedCacheDir(cmd),
+		},
 	}
 
 	err = indexExporter.ExportFromIndex(request)
@@ -1,13 +1,13 @@
 package index
 
 import (
-	"fmt"
-
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 )
 
 func newIndexPruneCmd() *cobra.Command {
@@ -38,8 +38,8 @@ func newIndexPruneCmd() *cobra.Command {
 		logrus.Panic("Failed to set required `packages` flag for `index prune`")
 	}
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
-	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
-	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
+	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built (required with container tool none, which pushes the image to it)")
 	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
 
 	if err := indexCmd.Flags().MarkHidden("debug"); err != nil {
@@ -81,10 +81,6 @@ func runIndexPruneCmdFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if containerTool == "none" {
-		return fmt.Errorf("none is not a valid container-tool for index prune")
-	}
-
 	tag, err := cmd.Flags().GetString("tag")
 	if err != nil {
 		return err
@@ -114,7 +110,10 @@ func runIndexPruneCmdFunc(cmd *cobra.Command, args []string) error {
 		Packages:          packages,
 		Tag:               tag,
 		Permissive:        permissive,
-		SkipTLS:           skipTLS,
+		PullOptions: registry.PullOptions{
+			SkipTLS:        skipTLS,
+			SharedCacheDir: util.SharedCacheDir(cmd),
+		},
 	}
 
 	err = indexPruner.PruneFromIndex(request)
@@ -1,13 +1,13 @@
 package index
 
 import (
-	"fmt"
-
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 )
 
 func newIndexPruneStrandedCmd() *cobra.Command {
@@ -34,8 +34,8 @@ func newIndexPruneStrandedCmd() *cobra.Command {
 		logrus.Panic("Failed to set required `from-index` flag for `index prune-stranded`")
 	}
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
-	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
-	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
+	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built (required with container tool none, which pushes the image to it)")
 
 	if err := indexCmd.Flags().MarkHidden("debug"); err != nil {
 		logrus.Panic(err.Error())
@@ -71,10 +71,6 @@ func runIndexPruneStrandedCmdFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if containerTool == "none" {
-		return fmt.Errorf("none is not a valid container-tool for index prune")
-	}
-
 	tag, err := cmd.Flags().GetString("tag")
 	if err != nil {
 		return err
@@ -97,7 +93,10 @@ func runIndexPruneStrandedCmdFunc(cmd *cobra.Command, args []string) error {
 		BinarySourceImage: binaryImage,
 		OutDockerfile:     outDockerfile,
 		Tag:               tag,
-		SkipTLS:           skipTLS,
+		PullOptions: registry.PullOptions{
+			SkipTLS:        skipTLS,
+			SharedCacheDir: util.SharedCacheDir(cmd),
+		},
 	}
 
 	err = indexPruner.PruneStrandedFromIndex(request)
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"io/ioutil"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
+)
+
+// SharedCacheDir returns the image cache shared by opm runs that is set by
+// the --cache-dir flag of the root command, if any.
+func SharedCacheDir(cmd *cobra.Command) string {
+	// The flag is missing if the command isn't run as part of opm, in which
+	// case there is no shared cache.
+	dir, _ := cmd.Flags().GetString("cache-dir")
+	return dir
+}
+
+// CreateCLIRegistry returns a registry to pull images with, whose content is
+// cached in a temporary directory, or in sharedCacheDir if it is set. The
+// returned function destroys the registry and its temporary cache.
+func CreateCLIRegistry(logger *logrus.Entry, caFile, sharedCacheDir string, skipTLS bool) (*containerdregistry.Registry, func(), error) {
+	cacheDir, err := ioutil.TempDir("", "opm-registry-")
+	if err != nil {
+		return nil, nil, err
+	}
+	pull := registry.PullOptions{CaFile: caFile, SkipTLS: skipTLS, SharedCacheDir: sharedCacheDir}
+	reg, err := pull.NewContainerdRegistry(logger, containerdregistry.WithCacheDir(cacheDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	return reg, func() {
+		if err := reg.Destroy(); err != nil {
+			logger.Errorf("error destroying local cache: %v", err)
+		}
+	}, nil
+}
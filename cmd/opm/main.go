@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"os"
 
+	"github.com/sirupsen/logrus"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/operator-framework/operator-registry/cmd/opm/root"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 	registrylib "github.com/operator-framework/operator-registry/pkg/registry"
 )
 
 func main() {
 	cmd := root.NewCmd()
-	if err := cmd.Execute(); err != nil {
+	err := cmd.Execute()
+	if err := tracing.Shutdown(context.Background(), err); err != nil {
+		logrus.WithError(err).Warn("unable to export trace")
+	}
+	if err != nil {
 		agg, ok := err.(utilerrors.Aggregate)
 		if !ok {
 			os.Exit(1)
@@ -7,6 +7,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 	reg "github.com/operator-framework/operator-registry/pkg/registry"
@@ -36,6 +37,10 @@ func newRegistryAddCmd() *cobra.Command {
 	rootCmd.Flags().String("ca-file", "", "the root certificates to use when --container-tool=none; see docker/podman docs for certificate loading instructions")
 	rootCmd.Flags().StringP("mode", "", "replaces", "graph update mode that defines how channel graphs are updated. One of: [replaces, semver, semver-skippatch]")
 	rootCmd.Flags().StringP("container-tool", "c", "none", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+	rootCmd.Flags().String("registry-auth-file", "", "path to a registry credentials file, either a docker config.json or a containers auth.json; defaults to the docker config and its credential helpers")
+	rootCmd.Flags().String("verification-policy", "", "path to a verification policy that pulled images must comply with, requiring digest references and/or signatures by trusted public keys")
+	rootCmd.Flags().Bool("pin-digests", false, "pin bundle images and their related images to digests, recording the original references as olm.image.pinned properties")
+	rootCmd.Flags().Int("pull-workers", registry.DefaultPullWorkers, "number of bundle images to pull and unpack concurrently")
 
 	return rootCmd
 }
@@ -74,6 +79,22 @@ func addFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	pullWorkers, err := cmd.Flags().GetInt("pull-workers")
+	if err != nil {
+		return err
+	}
+	authFile, err := cmd.Flags().GetString("registry-auth-file")
+	if err != nil {
+		return err
+	}
+	policyFile, err := cmd.Flags().GetString("verification-policy")
+	if err != nil {
+		return err
+	}
+	pinDigests, err := cmd.Flags().GetBool("pin-digests")
+	if err != nil {
+		return err
+	}
 
 	if caFile != "" {
 		if skipTLS {
@@ -84,16 +105,32 @@ func addFunc(cmd *cobra.Command, args []string) error {
 				"certificates must be configured specifically for %[1]s", containerTool)
 		}
 	}
+	if authFile != "" && containerTool == containertools.DockerTool {
+		return fmt.Errorf("--registry-auth-file cannot be set with --container-tool=docker, which uses the credentials of its own config")
+	}
+	if policyFile != "" && containerTool != containertools.NoneTool {
+		return fmt.Errorf("--verification-policy can only be set with --container-tool=none")
+	}
+	if pinDigests && containerTool != containertools.NoneTool {
+		return fmt.Errorf("--pin-digests can only be set with --container-tool=none")
+	}
 
 	request := registry.AddToRegistryRequest{
-		Permissive:    permissive,
-		SkipTLS:       skipTLS,
-		CaFile:        caFile,
+		Permissive: permissive,
+		PullOptions: registry.PullOptions{
+			SkipTLS:            skipTLS,
+			CaFile:             caFile,
+			AuthFile:           authFile,
+			VerificationPolicy: policyFile,
+			SharedCacheDir:     util.SharedCacheDir(cmd),
+		},
 		InputDatabase: fromFilename,
 		Bundles:       bundleImages,
 		Mode:          modeEnum,
 		ContainerTool: containerTool,
 		Overwrite:     false,
+		PullWorkers:   pullWorkers,
+		PinDigests:    pinDigests,
 	}
 
 	logger := logrus.WithFields(logrus.Fields{"bundles": bundleImages})
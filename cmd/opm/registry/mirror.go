@@ -1,19 +1,46 @@
 package registry
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 	"github.com/operator-framework/operator-registry/pkg/mirror"
 )
 
 func MirrorCmd() *cobra.Command {
 	o := mirror.DefaultImageIndexMirrorerOptions()
+	var (
+		authFile, caFile, layoutDir string
+		skipTLS                     bool
+		copyWorkers                 int
+	)
 	cmd := &cobra.Command{
 		Hidden: true,
-		Use:    "mirror [src image] [dest image]",
+		Use:    "mirror [src image or configs dir] [dest registry]",
 		Short:  "mirror an operator-registry catalog",
-		Long:   `mirror an operator-registry catalog image from one registry to another`,
+		Long: `mirror an operator-registry catalog image from one registry to another
+
+The catalog is either the database or the declarative configs of an index image,
+or a local directory of declarative configs. With --to-manifests, a mapping file
+for oc image mirror and an ImageContentSourcePolicy for the mirrored images are
+written to the given directory.
+
+Images are copied with all their platforms. Blobs and images the destination
+already has are skipped, so running the command again after an interruption
+only copies what's missing. Blobs are fetched into a temporary cache that is
+removed when the command exits; with --cache-dir they are kept there instead,
+so that blobs fetched but not yet copied aren't fetched again either. With
+--to-oci-layout, images are written to a local OCI image layout instead, each
+named after its destination, for transfer to a disconnected registry.`,
 
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if debug, _ := cmd.Flags().GetBool("debug"); debug {
@@ -26,7 +53,43 @@ func MirrorCmd() *cobra.Command {
 			src := args[0]
 			dest := args[1]
 
-			mirrorer, err := mirror.NewIndexImageMirror(o.ToOption(), mirror.WithSource(src), mirror.WithDest(dest))
+			workingDir, err := ioutil.TempDir("", "mirror-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(workingDir)
+			extractor := indexer.ImageIndexer{PullTool: containertools.NoneTool, Logger: logrus.NewEntry(logrus.StandardLogger())}
+			pull := registry.PullOptions{
+				CaFile:         caFile,
+				AuthFile:       authFile,
+				SkipTLS:        skipTLS,
+				SharedCacheDir: util.SharedCacheDir(cmd),
+			}
+			extract := mirror.DatabaseExtractorFunc(func(from string) (string, error) {
+				return extractor.ExtractCatalog(indexer.ExtractCatalogRequest{
+					WorkingDir:  workingDir,
+					Index:       from,
+					PullOptions: pull,
+				})
+			})
+
+			reg, err := pull.NewContainerdRegistry(logrus.NewEntry(logrus.StandardLogger()), containerdregistry.WithCacheDir(filepath.Join(workingDir, "cache")))
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			copier := &mirror.ContainerdMirrorer{
+				Registry: reg,
+				Workers:  copyWorkers,
+				Logger:   logrus.NewEntry(logrus.StandardLogger()),
+			}
+			if layoutDir != "" {
+				if copier.Layout, err = containerdregistry.NewOCILayout(layoutDir); err != nil {
+					return err
+				}
+			}
+
+			mirrorer, err := mirror.NewIndexImageMirror(o.ToOption(), mirror.WithExtractor(extract), mirror.WithMirrorer(copier), mirror.WithSource(src), mirror.WithDest(dest))
 			if err != nil {
 				return err
 			}
@@ -40,7 +103,16 @@ func MirrorCmd() *cobra.Command {
 	flags := cmd.Flags()
 
 	cmd.Flags().Bool("debug", false, "Enable debug logging.")
-	flags.StringVar(&o.ManifestDir, "--to-manifests", "manifests", "Local path to store manifests.")
+	flags.StringVar(&o.ManifestDir, "to-manifests", "", "Write a mapping file and an ImageContentSourcePolicy for the mirrored images to this directory; nothing is written if empty.")
+	flags.StringSliceVar(&o.Packages, "packages", nil, "Only mirror the bundles of these packages.")
+	flags.StringSliceVar(&o.Channels, "channels", nil, "Only mirror the bundles of these channels.")
+	flags.BoolVar(&o.Rewrite, "rewrite-catalog", false, "Write the catalog, rewritten to reference the mirrored images, to the --to-manifests directory as declarative configs.")
+	flags.BoolVar(&o.ManifestsOnly, "manifests-only", false, "Only write the manifests to the --to-manifests directory, without mirroring images.")
+	flags.StringVar(&authFile, "registry-auth-file", "", "Path to a registry credentials file, either a docker config.json or a containers auth.json; defaults to the docker config and its credential helpers.")
+	flags.StringVar(&caFile, "ca-file", "", "the root Certificates to use with this command")
+	flags.BoolVar(&skipTLS, "skip-tls", false, "disable TLS verification")
+	flags.StringVar(&layoutDir, "to-oci-layout", "", "Write the images to an OCI image layout in this directory instead of pushing them to the destination registry.")
+	flags.IntVar(&copyWorkers, "copy-workers", mirror.DefaultCopyWorkers, "Number of images to copy concurrently.")
 
 	return cmd
 }
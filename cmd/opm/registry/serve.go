@@ -19,7 +19,9 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/lib/dns"
 	"github.com/operator-framework/operator-registry/pkg/lib/graceful"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
+	"github.com/operator-framework/operator-registry/pkg/lib/serverflags"
 	"github.com/operator-framework/operator-registry/pkg/lib/tmp"
+	reg "github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/server"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -45,6 +47,9 @@ func newRegistryServeCmd() *cobra.Command {
 	rootCmd.Flags().StringP("port", "p", "50051", "port number to serve on")
 	rootCmd.Flags().StringP("termination-log", "t", "/dev/termination-log", "path to a container termination log file")
 	rootCmd.Flags().Bool("skip-migrate", false, "do  not attempt to migrate to the latest db revision when starting")
+	rootCmd.Flags().Bool("in-memory", false, "load the database into memory when starting and serve queries from memory")
+	serverflags.AddTLSFlags(rootCmd.Flags())
+	serverflags.AddMetricsFlags(rootCmd.Flags())
 	rootCmd.Flags().String("timeout-seconds", "infinite", "Timeout in seconds. This flag will be removed later.")
 
 	return rootCmd
@@ -78,6 +83,8 @@ func serveFunc(cmd *cobra.Command, args []string) error {
 
 	logger := logrus.WithFields(logrus.Fields{"database": dbName, "port": port})
 
+	start := time.Now()
+
 	// make a writable copy of the db for migrations
 	tmpdb, err := tmp.CopyTmpDB(dbName)
 	if err != nil {
@@ -106,6 +113,30 @@ func serveFunc(cmd *cobra.Command, args []string) error {
 		logger.Warn("no tables found in db")
 	}
 
+	var querier reg.GRPCQuery = store
+	inMemory, err := cmd.Flags().GetBool("in-memory")
+	if err != nil {
+		return err
+	}
+	if inMemory {
+		querier, err = sqlite.NewInMemoryQuerier(context.TODO(), store)
+		if err != nil {
+			return fmt.Errorf("failed to load database into memory: %v", err)
+		}
+		logger.Info("loaded database into memory")
+	}
+	loadDuration := time.Since(start)
+
+	serverOpts, err := serverflags.TLSServerOptions(cmd.Flags(), logger)
+	if err != nil {
+		return err
+	}
+	metricsOpts, err := serverflags.ServeMetrics(context.TODO(), cmd.Flags(), querier, logger, serverflags.SQLiteMetricsOptions(context.TODO(), db, loadDuration, logger)...)
+	if err != nil {
+		return err
+	}
+	serverOpts = append(serverOpts, metricsOpts...)
+
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
 		logger.Fatalf("failed to listen: %s", err)
@@ -116,7 +147,7 @@ func serveFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(serverOpts...)
 	logger.Printf("Keeping server open for %s seconds", timeout)
 	if timeout != "infinite" {
 		timeoutSeconds, err := strconv.ParseUint(timeout, 10, 16)
@@ -132,7 +163,7 @@ func serveFunc(cmd *cobra.Command, args []string) error {
 		defer timer.Stop()
 	}
 
-	api.RegisterRegistryServer(s, server.NewRegistryServer(store))
+	api.RegisterRegistryServer(s, server.NewRegistryServer(querier))
 	health.RegisterHealthServer(s, server.NewHealthServer())
 	reflection.Register(s)
 	logger.Info("serving registry")
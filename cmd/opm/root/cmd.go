@@ -5,9 +5,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha"
+	"github.com/operator-framework/operator-registry/cmd/opm/cache"
 	"github.com/operator-framework/operator-registry/cmd/opm/index"
 	"github.com/operator-framework/operator-registry/cmd/opm/registry"
 	"github.com/operator-framework/operator-registry/cmd/opm/version"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 func NewCmd() *cobra.Command {
@@ -21,9 +23,12 @@ func NewCmd() *cobra.Command {
 			}
 			return nil
 		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return initTracing(cmd)
+		},
 	}
 
-	cmd.AddCommand(registry.NewOpmRegistryCmd(), alpha.NewCmd())
+	cmd.AddCommand(registry.NewOpmRegistryCmd(), alpha.NewCmd(), cache.NewCmd())
 	index.AddCommand(cmd)
 	version.AddCommand(cmd)
 
@@ -32,5 +37,37 @@ func NewCmd() *cobra.Command {
 		logrus.Panic(err.Error())
 	}
 
+	cmd.PersistentFlags().String("cache-dir", "", "keep the content of pulled images in this directory, shared by all opm runs that set it, instead of a temporary cache; layers already in it aren't fetched again")
+	cmd.PersistentFlags().String("trace-file", "", "write a trace of the command to this file as OTLP JSON")
+	cmd.PersistentFlags().String("trace-endpoint", "", "send a trace of the command to the OTLP/HTTP collector at this address; if set without a value, "+tracing.DefaultOTLPEndpoint+" is used")
+	cmd.PersistentFlags().Lookup("trace-endpoint").NoOptDefVal = tracing.DefaultOTLPEndpoint
+
 	return cmd
 }
+
+// initTracing enables tracing of the command if a trace file or collector
+// endpoint is set. The trace is exported when tracing.Shutdown is called
+// once the command has run.
+func initTracing(cmd *cobra.Command) error {
+	traceFile, err := cmd.Flags().GetString("trace-file")
+	if err != nil {
+		return err
+	}
+	traceEndpoint, err := cmd.Flags().GetString("trace-endpoint")
+	if err != nil {
+		return err
+	}
+
+	var exporters []tracing.Exporter
+	if traceFile != "" {
+		exporters = append(exporters, tracing.NewFileExporter(traceFile))
+	}
+	if traceEndpoint != "" {
+		exporters = append(exporters, tracing.NewOTLPExporter(traceEndpoint))
+	}
+	if len(exporters) == 0 {
+		return nil
+	}
+	tracing.Init(cmd.CommandPath(), logrus.StandardLogger(), exporters...)
+	return nil
+}
@@ -8,11 +8,13 @@ import (
 
 const (
 	schemaPackage = "olm.package"
+	schemaChannel = "olm.channel"
 	schemaBundle  = "olm.bundle"
 )
 
 type DeclarativeConfig struct {
 	Packages []Package
+	Channels []Channel
 	Bundles  []Bundle
 	Others   []Meta
 }
@@ -30,6 +32,24 @@ type Icon struct {
 	MediaType string `json:"mediatype"`
 }
 
+// Channel defines the bundles in a channel of a package and the upgrade
+// edges between them. Channels may instead be defined by the legacy
+// olm.channel, olm.skips and olm.skipRange bundle properties, but a channel
+// cannot be defined both ways.
+type Channel struct {
+	Schema  string         `json:"schema"`
+	Name    string         `json:"name"`
+	Package string         `json:"package"`
+	Entries []ChannelEntry `json:"entries"`
+}
+
+type ChannelEntry struct {
+	Name      string   `json:"name"`
+	Replaces  string   `json:"replaces,omitempty"`
+	Skips     []string `json:"skips,omitempty"`
+	SkipRange string   `json:"skipRange,omitempty"`
+}
+
 type Bundle struct {
 	Schema        string              `json:"schema"`
 	Name          string              `json:"name"`
@@ -26,8 +26,51 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 		mpkgs[p.Name] = mpkg
 	}
 
+	// entries maps package name to bundle name to the channel entries
+	// that reference the bundle.
+	entries := map[string]map[string][]channelEntry{}
+	blobChannels := map[string]map[string]struct{}{}
+	for _, c := range cfg.Channels {
+		mpkg, ok := mpkgs[c.Package]
+		if !ok {
+			return nil, fmt.Errorf("unknown package %q for channel %q", c.Package, c.Name)
+		}
+		if c.Name == "" {
+			return nil, fmt.Errorf("channel name must be set for channel in package %q", c.Package)
+		}
+		if _, ok := mpkg.Channels[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate channel %q in package %q", c.Name, c.Package)
+		}
+		mch := &model.Channel{
+			Package: mpkg,
+			Name:    c.Name,
+			Bundles: map[string]*model.Bundle{},
+		}
+		if c.Name == defaultChannels[c.Package] {
+			mpkg.DefaultChannel = mch
+		}
+		mpkg.Channels[c.Name] = mch
+
+		if entries[c.Package] == nil {
+			entries[c.Package] = map[string][]channelEntry{}
+			blobChannels[c.Package] = map[string]struct{}{}
+		}
+		blobChannels[c.Package][c.Name] = struct{}{}
+		seen := map[string]struct{}{}
+		for _, e := range c.Entries {
+			if e.Name == "" {
+				return nil, fmt.Errorf("entry name must be set for channel %q in package %q", c.Name, c.Package)
+			}
+			if _, ok := seen[e.Name]; ok {
+				return nil, fmt.Errorf("duplicate entry %q in channel %q of package %q", e.Name, c.Name, c.Package)
+			}
+			seen[e.Name] = struct{}{}
+			entries[c.Package][e.Name] = append(entries[c.Package][e.Name], channelEntry{channel: c.Name, ChannelEntry: e})
+		}
+	}
+
+	bundleNames := map[string]map[string]struct{}{}
 	for _, b := range cfg.Bundles {
-		defaultChannelName := defaultChannels[b.Package]
 		if b.Package == "" {
 			return nil, fmt.Errorf("package name must be set for bundle %q", b.Name)
 		}
@@ -35,6 +78,10 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 		if !ok {
 			return nil, fmt.Errorf("unknown package %q for bundle %q", b.Package, b.Name)
 		}
+		if bundleNames[b.Package] == nil {
+			bundleNames[b.Package] = map[string]struct{}{}
+		}
+		bundleNames[b.Package][b.Name] = struct{}{}
 
 		props, err := parseProperties(b.Properties)
 		if err != nil {
@@ -49,31 +96,41 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 			return nil, fmt.Errorf("package %q does not match %q property %q", b.Package, property.TypePackage, props.Packages[0].PackageName)
 		}
 
-		if len(props.Channels) == 0 {
+		bundleEntries, err := legacyChannelEntries(b, props, blobChannels[b.Package])
+		if err != nil {
+			return nil, err
+		}
+		bundleEntries = append(bundleEntries, entries[b.Package][b.Name]...)
+		if len(bundleEntries) == 0 {
 			return nil, fmt.Errorf("bundle %q is missing channel information", b.Name)
 		}
 
-		for _, bundleChannel := range props.Channels {
-			pkgChannel, ok := mpkg.Channels[bundleChannel.Name]
+		for _, e := range bundleEntries {
+			pkgChannel, ok := mpkg.Channels[e.channel]
 			if !ok {
 				pkgChannel = &model.Channel{
 					Package: mpkg,
-					Name:    bundleChannel.Name,
+					Name:    e.channel,
 					Bundles: map[string]*model.Bundle{},
 				}
-				if bundleChannel.Name == defaultChannelName {
+				if e.channel == defaultChannels[b.Package] {
 					mpkg.DefaultChannel = pkgChannel
 				}
-				mpkg.Channels[bundleChannel.Name] = pkgChannel
+				mpkg.Channels[e.channel] = pkgChannel
+			}
+			bundleProps := b.Properties
+			if len(entries[b.Package][b.Name]) > 0 {
+				bundleProps = channelProperties(b.Properties, bundleEntries, e)
 			}
 			pkgChannel.Bundles[b.Name] = &model.Bundle{
 				Package:       mpkg,
 				Channel:       pkgChannel,
 				Name:          b.Name,
 				Image:         b.Image,
-				Replaces:      bundleChannel.Replaces,
-				Skips:         skipsToStrings(props.Skips),
-				Properties:    b.Properties,
+				Replaces:      e.Replaces,
+				Skips:         e.Skips,
+				SkipRange:     e.SkipRange,
+				Properties:    bundleProps,
 				RelatedImages: relatedImagesToModelRelatedImages(b.RelatedImages),
 				CsvJSON:       b.CsvJSON,
 				Objects:       b.Objects,
@@ -81,6 +138,14 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 		}
 	}
 
+	for pkgName, pkgEntries := range entries {
+		for bundleName, bundleEntries := range pkgEntries {
+			if _, ok := bundleNames[pkgName][bundleName]; !ok {
+				return nil, fmt.Errorf("unknown bundle %q in channel %q of package %q", bundleName, bundleEntries[0].channel, pkgName)
+			}
+		}
+	}
+
 	for _, mpkg := range mpkgs {
 		defaultChannelName := defaultChannels[mpkg.Name]
 		if defaultChannelName != "" && mpkg.DefaultChannel == nil {
@@ -101,6 +166,56 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 	return mpkgs, nil
 }
 
+// channelEntry is an entry of the named channel.
+type channelEntry struct {
+	channel string
+	ChannelEntry
+}
+
+// legacyChannelEntries returns the channel entries defined by a bundle's
+// olm.channel, olm.skips and olm.skipRange properties. The skips and
+// skipRange apply to every channel of the bundle.
+func legacyChannelEntries(b Bundle, props *property.Properties, blobChannels map[string]struct{}) ([]channelEntry, error) {
+	skipRange := ""
+	if len(props.SkipRanges) > 0 {
+		skipRange = string(props.SkipRanges[0])
+	}
+	var out []channelEntry
+	for _, ch := range props.Channels {
+		if _, ok := blobChannels[ch.Name]; ok {
+			return nil, fmt.Errorf("bundle %q has a %q property for channel %q, which is defined by a %q blob", b.Name, property.TypeChannel, ch.Name, schemaChannel)
+		}
+		out = append(out, channelEntry{
+			channel: ch.Name,
+			ChannelEntry: ChannelEntry{
+				Name:      b.Name,
+				Replaces:  ch.Replaces,
+				Skips:     skipsToStrings(props.Skips),
+				SkipRange: skipRange,
+			},
+		})
+	}
+	return out, nil
+}
+
+// channelProperties returns the properties of a bundle that is a member of a
+// channel defined by an olm.channel blob. Its upgrade edges are represented
+// as legacy properties so that they continue to be served to clients that
+// read them from bundle properties.
+func channelProperties(props []property.Property, bundleEntries []channelEntry, e channelEntry) []property.Property {
+	out := withoutChannelProperties(props)
+	for _, be := range bundleEntries {
+		out = append(out, property.MustBuildChannel(be.channel, be.Replaces))
+	}
+	for _, skip := range e.Skips {
+		out = append(out, property.MustBuildSkips(skip))
+	}
+	if e.SkipRange != "" {
+		out = append(out, property.MustBuildSkipRange(e.SkipRange))
+	}
+	return out
+}
+
 func skipsToStrings(in []property.Skips) []string {
 	var out []string
 	for _, s := range in {
@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/property"
 )
 
 func TestConvertToModel(t *testing.T) {
@@ -79,6 +81,69 @@ func TestConvertToModel(t *testing.T) {
 				Bundles:  []Bundle{newTestBundle("foo", "0.1.0", withChannel("alpha", ""), withNoBundleImage())},
 			},
 		},
+		{
+			name:      "Error/ChannelUnknownPackage",
+			assertion: require.Error,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("bar", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")})},
+				Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+		},
+		{
+			name:      "Error/ChannelUnknownBundle",
+			assertion: require.Error,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "alpha",
+					ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+					ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0")},
+				)},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+		},
+		{
+			name:      "Error/ChannelDuplicateEntry",
+			assertion: require.Error,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "alpha",
+					ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+					ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+				)},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+		},
+		{
+			name:      "Error/ChannelDuplicate",
+			assertion: require.Error,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{
+					newTestChannel("foo", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")}),
+					newTestChannel("foo", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")}),
+				},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+		},
+		{
+			name:      "Error/ChannelDefinedByBlobAndProperty",
+			assertion: require.Error,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")})},
+				Bundles:  []Bundle{newTestBundle("foo", "0.1.0", withChannel("alpha", ""))},
+			},
+		},
+		{
+			name:      "Success/ChannelBlobAndProperty",
+			assertion: require.NoError,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")})},
+				Bundles:  []Bundle{newTestBundle("foo", "0.1.0", withChannel("beta", ""))},
+			},
+		},
 		{
 			name:      "Success/ValidModel",
 			assertion: require.NoError,
@@ -111,3 +176,90 @@ func TestConvertToModelRoundtrip(t *testing.T) {
 	assert.Equal(t, expected.Bundles, actual.Bundles)
 	assert.Len(t, actual.Others, 0, "expected unrecognized schemas not to make the roundtrip")
 }
+
+func TestConvertToModelChannelSchemaRoundtrip(t *testing.T) {
+	expected := buildChannelSchemaDeclarativeConfig(true)
+
+	m, err := ConvertToModel(expected)
+	require.NoError(t, err)
+	actual := ConvertFromModel(m, WithChannelSchema())
+
+	removeJSONWhitespace(&expected)
+	removeJSONWhitespace(&actual)
+
+	assert.Equal(t, expected.Packages, actual.Packages)
+	assert.Equal(t, expected.Channels, actual.Channels)
+	assert.Equal(t, expected.Bundles, actual.Bundles)
+	assert.Len(t, actual.Others, 0, "expected unrecognized schemas not to make the roundtrip")
+}
+
+func TestConvertChannelSchema(t *testing.T) {
+	// Channels defined either way are written as bundle properties by
+	// default, and as olm.channel blobs with WithChannelSchema.
+	for name, cfg := range map[string]DeclarativeConfig{
+		"Properties":    buildValidDeclarativeConfig(false),
+		"ChannelSchema": buildChannelSchemaDeclarativeConfig(false),
+	} {
+		t.Run(name, func(t *testing.T) {
+			m, err := ConvertToModel(cfg)
+			require.NoError(t, err)
+			equalsDeclarativeConfig(t, buildValidDeclarativeConfig(false), ConvertFromModel(m))
+			equalsDeclarativeConfig(t, buildChannelSchemaDeclarativeConfig(false), ConvertFromModel(m, WithChannelSchema()))
+		})
+	}
+}
+
+func TestConvertToModelChannelEntries(t *testing.T) {
+	// Comparisons modify the configs they compare, so each gets a new one.
+	newConfig := func() DeclarativeConfig {
+		return DeclarativeConfig{
+			Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+			Channels: []Channel{
+				newTestChannel("foo", "stable",
+					ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+					ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0"), SkipRange: "<0.2.0"},
+				),
+				newTestChannel("foo", "fast",
+					ChannelEntry{Name: testBundleName("foo", "0.2.0"), Skips: []string{testBundleName("foo", "0.1.0")}},
+				),
+			},
+			Bundles: []Bundle{
+				newTestBundle("foo", "0.1.0"),
+				newTestBundle("foo", "0.2.0"),
+			},
+		}
+	}
+	cfg := newConfig()
+
+	m, err := ConvertToModel(cfg)
+	require.NoError(t, err)
+
+	stable := m["foo"].Channels["stable"].Bundles[testBundleName("foo", "0.2.0")]
+	assert.Equal(t, testBundleName("foo", "0.1.0"), stable.Replaces)
+	assert.Empty(t, stable.Skips)
+	assert.Equal(t, "<0.2.0", stable.SkipRange)
+	assert.Contains(t, stable.Properties, property.MustBuildSkipRange("<0.2.0"))
+	assert.Contains(t, stable.Properties, property.MustBuildChannel("fast", ""))
+	assert.NotContains(t, stable.Properties, property.MustBuildSkips(testBundleName("foo", "0.1.0")))
+
+	fast := m["foo"].Channels["fast"].Bundles[testBundleName("foo", "0.2.0")]
+	assert.Empty(t, fast.Replaces)
+	assert.Equal(t, []string{testBundleName("foo", "0.1.0")}, fast.Skips)
+	assert.Empty(t, fast.SkipRange)
+	assert.Contains(t, fast.Properties, property.MustBuildSkips(testBundleName("foo", "0.1.0")))
+
+	equalsDeclarativeConfig(t, newConfig(), ConvertFromModel(m, WithChannelSchema()))
+
+	// The skips of 0.2.0 differ between channels, which only olm.channel
+	// blobs can express, so they are written without the option too and
+	// survive another round trip.
+	equalsDeclarativeConfig(t, newConfig(), ConvertFromModel(m))
+	m, err = ConvertToModel(ConvertFromModel(m))
+	require.NoError(t, err)
+	stable = m["foo"].Channels["stable"].Bundles[testBundleName("foo", "0.2.0")]
+	assert.Empty(t, stable.Skips)
+	assert.Equal(t, "<0.2.0", stable.SkipRange)
+	fast = m["foo"].Channels["fast"].Bundles[testBundleName("foo", "0.2.0")]
+	assert.Equal(t, []string{testBundleName("foo", "0.1.0")}, fast.Skips)
+	assert.Empty(t, fast.SkipRange)
+}
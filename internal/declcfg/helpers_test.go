@@ -65,6 +65,45 @@ func buildValidDeclarativeConfig(includeUnrecognized bool) DeclarativeConfig {
 	}
 }
 
+// buildChannelSchemaDeclarativeConfig builds the same config as
+// buildValidDeclarativeConfig, with its channels defined by olm.channel
+// blobs instead of bundle properties.
+func buildChannelSchemaDeclarativeConfig(includeUnrecognized bool) DeclarativeConfig {
+	cfg := buildValidDeclarativeConfig(includeUnrecognized)
+	cfg.Channels = []Channel{
+		newTestChannel("anakin", "dark",
+			ChannelEntry{Name: testBundleName("anakin", "0.0.1")},
+			ChannelEntry{Name: testBundleName("anakin", "0.1.0"), Replaces: testBundleName("anakin", "0.0.1")},
+			ChannelEntry{Name: testBundleName("anakin", "0.1.1"), Replaces: testBundleName("anakin", "0.0.1"), Skips: []string{testBundleName("anakin", "0.1.0")}},
+		),
+		newTestChannel("anakin", "light",
+			ChannelEntry{Name: testBundleName("anakin", "0.0.1")},
+			ChannelEntry{Name: testBundleName("anakin", "0.1.0"), Replaces: testBundleName("anakin", "0.0.1")},
+		),
+		newTestChannel("boba-fett", "mando",
+			ChannelEntry{Name: testBundleName("boba-fett", "1.0.0")},
+			ChannelEntry{Name: testBundleName("boba-fett", "2.0.0"), Replaces: testBundleName("boba-fett", "1.0.0")},
+		),
+	}
+	cfg.Bundles = []Bundle{
+		newTestBundle("anakin", "0.0.1"),
+		newTestBundle("anakin", "0.1.0"),
+		newTestBundle("anakin", "0.1.1"),
+		newTestBundle("boba-fett", "1.0.0"),
+		newTestBundle("boba-fett", "2.0.0"),
+	}
+	return cfg
+}
+
+func newTestChannel(packageName, name string, entries ...ChannelEntry) Channel {
+	return Channel{
+		Schema:  schemaChannel,
+		Name:    name,
+		Package: packageName,
+		Entries: entries,
+	}
+}
+
 type bundleOpt func(*Bundle)
 
 func withChannel(name, replaces string) func(*Bundle) {
@@ -304,6 +343,7 @@ func equalsDeclarativeConfig(t *testing.T, expected, actual DeclarativeConfig) {
 	removeJSONWhitespace(&actual)
 
 	assert.ElementsMatch(t, expected.Packages, actual.Packages)
+	assert.ElementsMatch(t, expected.Channels, actual.Channels)
 	assert.ElementsMatch(t, expected.Others, actual.Others)
 
 	// When comparing bundles, the order of properties doesn't matter.
@@ -327,6 +367,7 @@ func equalsDeclarativeConfig(t *testing.T, expected, actual DeclarativeConfig) {
 	// In case new fields are added to the DeclarativeConfig struct in the future,
 	// test that the rest is Equal.
 	expected.Packages, actual.Packages = nil, nil
+	expected.Channels, actual.Channels = nil, nil
 	expected.Bundles, actual.Bundles = nil, nil
 	expected.Others, actual.Others = nil, nil
 	assert.Equal(t, expected, actual)
@@ -42,6 +42,11 @@ func TestReadYAMLOrJSON(t *testing.T) {
 			file:      "testdata/invalid/invalid-package-json.json",
 			assertion: require.Error,
 		},
+		{
+			name:      "Error/InvalidChannelJSON",
+			file:      "testdata/invalid/invalid-channel-json.json",
+			assertion: require.Error,
+		},
 		{
 			name:      "Error/InvalidBundleJSON",
 			file:      "testdata/invalid/invalid-bundle-json.json",
@@ -7,10 +7,35 @@ import (
 	"github.com/operator-framework/operator-registry/internal/property"
 )
 
-func ConvertFromModel(mpkgs model.Model) DeclarativeConfig {
+// ConvertOption configures ConvertFromModel.
+type ConvertOption func(*convertOptions)
+
+type convertOptions struct {
+	channelSchema bool
+}
+
+// WithChannelSchema writes the channels of the model as olm.channel blobs,
+// instead of as the olm.channel, olm.skips and olm.skipRange properties of
+// their bundles. Versions of opm that predate olm.channel blobs can't read
+// the result. Packages whose bundles skip differently in different channels
+// are written as olm.channel blobs regardless, since bundle properties can't
+// tell the channels apart.
+func WithChannelSchema() ConvertOption {
+	return func(o *convertOptions) {
+		o.channelSchema = true
+	}
+}
+
+func ConvertFromModel(mpkgs model.Model, opts ...ConvertOption) DeclarativeConfig {
+	var o convertOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	cfg := DeclarativeConfig{}
 	for _, mpkg := range mpkgs {
-		bundles := traverseModelChannels(*mpkg)
+		channelSchema := o.channelSchema || skipsDifferBetweenChannels(*mpkg)
+		bundles := traverseModelChannels(*mpkg, channelSchema)
 
 		var i *Icon
 		if mpkg.Icon != nil {
@@ -30,12 +55,21 @@ func ConvertFromModel(mpkgs model.Model) DeclarativeConfig {
 			Icon:           i,
 			Description:    mpkg.Description,
 		})
+		if channelSchema {
+			cfg.Channels = append(cfg.Channels, modelChannelsToChannels(*mpkg)...)
+		}
 		cfg.Bundles = append(cfg.Bundles, bundles...)
 	}
 
 	sort.Slice(cfg.Packages, func(i, j int) bool {
 		return cfg.Packages[i].Name < cfg.Packages[j].Name
 	})
+	sort.Slice(cfg.Channels, func(i, j int) bool {
+		if cfg.Channels[i].Package != cfg.Channels[j].Package {
+			return cfg.Channels[i].Package < cfg.Channels[j].Package
+		}
+		return cfg.Channels[i].Name < cfg.Channels[j].Name
+	})
 	sort.Slice(cfg.Bundles, func(i, j int) bool {
 		return cfg.Bundles[i].Name < cfg.Bundles[j].Name
 	})
@@ -43,7 +77,7 @@ func ConvertFromModel(mpkgs model.Model) DeclarativeConfig {
 	return cfg
 }
 
-func traverseModelChannels(mpkg model.Package) []Bundle {
+func traverseModelChannels(mpkg model.Package, channelSchema bool) []Bundle {
 	bundles := map[string]*Bundle{}
 
 	for _, ch := range mpkg.Channels {
@@ -61,7 +95,11 @@ func traverseModelChannels(mpkg model.Package) []Bundle {
 				}
 				bundles[b.Name] = b
 			}
-			b.Properties = append(b.Properties, chb.Properties...)
+			if channelSchema {
+				b.Properties = append(b.Properties, withoutChannelProperties(chb.Properties)...)
+			} else {
+				b.Properties = append(b.Properties, chb.Properties...)
+			}
 		}
 	}
 
@@ -73,6 +111,84 @@ func traverseModelChannels(mpkg model.Package) []Bundle {
 	return out
 }
 
+// skipsDifferBetweenChannels returns true if a bundle of mpkg has different
+// skips or skip ranges in different channels.
+func skipsDifferBetweenChannels(mpkg model.Package) bool {
+	seen := map[string]*model.Bundle{}
+	for _, ch := range mpkg.Channels {
+		for _, b := range ch.Bundles {
+			prev, ok := seen[b.Name]
+			if !ok {
+				seen[b.Name] = b
+				continue
+			}
+			if prev.SkipRange != b.SkipRange || !sameStrings(prev.Skips, b.Skips) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameStrings returns true if a and b hold the same strings, in any order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		if counts[s] == 0 {
+			return false
+		}
+		counts[s]--
+	}
+	return true
+}
+
+func modelChannelsToChannels(mpkg model.Package) []Channel {
+	var out []Channel
+	for _, ch := range mpkg.Channels {
+		c := Channel{
+			Schema:  schemaChannel,
+			Name:    ch.Name,
+			Package: mpkg.Name,
+		}
+		for _, b := range ch.Bundles {
+			e := ChannelEntry{
+				Name:      b.Name,
+				Replaces:  b.Replaces,
+				SkipRange: b.SkipRange,
+			}
+			if len(b.Skips) > 0 {
+				e.Skips = b.Skips
+			}
+			c.Entries = append(c.Entries, e)
+		}
+		sort.Slice(c.Entries, func(i, j int) bool {
+			return c.Entries[i].Name < c.Entries[j].Name
+		})
+		out = append(out, c)
+	}
+	return out
+}
+
+// withoutChannelProperties removes the legacy properties that encode
+// upgrade edges, which are instead written as channel entries.
+func withoutChannelProperties(props []property.Property) []property.Property {
+	var out []property.Property
+	for _, p := range props {
+		switch p.Type {
+		case property.TypeChannel, property.TypeSkips, property.TypeSkipRange:
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
 func modelRelatedImagesToRelatedImages(relatedImages []model.RelatedImage) []RelatedImage {
 	var out []RelatedImage
 	for _, ri := range relatedImages {
@@ -50,6 +50,10 @@ func (w diskWriter) WriteFile(path string, data []byte, mode os.FileMode) error
 }
 
 func writeToFS(cfg DeclarativeConfig, w fsWriter, rootDir string) error {
+	channelsByPackage := map[string][]Channel{}
+	for _, c := range cfg.Channels {
+		channelsByPackage[c.Package] = append(channelsByPackage[c.Package], c)
+	}
 	bundlesByPackage := map[string][]Bundle{}
 	for _, b := range cfg.Bundles {
 		bundlesByPackage[b.Package] = append(bundlesByPackage[b.Package], b)
@@ -70,6 +74,7 @@ func writeToFS(cfg DeclarativeConfig, w fsWriter, rootDir string) error {
 	for _, p := range cfg.Packages {
 		fcfg := DeclarativeConfig{
 			Packages: []Package{p},
+			Channels: channelsByPackage[p.Name],
 			Bundles:  bundlesByPackage[p.Name],
 			Others:   othersByPackage[p.Name],
 		}
@@ -190,6 +195,12 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 		pkgNames.Insert(pkgName)
 		packagesByName[pkgName] = append(packagesByName[pkgName], p)
 	}
+	channelsByPackage := map[string][]Channel{}
+	for _, c := range cfg.Channels {
+		pkgName := c.Package
+		pkgNames.Insert(pkgName)
+		channelsByPackage[pkgName] = append(channelsByPackage[pkgName], c)
+	}
 	bundlesByPackage := map[string][]Bundle{}
 	for _, b := range cfg.Bundles {
 		pkgName := b.Package
@@ -217,6 +228,16 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 			}
 		}
 
+		channels := channelsByPackage[pName]
+		sort.Slice(channels, func(i, j int) bool {
+			return channels[i].Name < channels[j].Name
+		})
+		for _, c := range channels {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+
 		bundles := bundlesByPackage[pName]
 		sort.Slice(bundles, func(i, j int) bool {
 			return bundles[i].Name < bundles[j].Name
@@ -0,0 +1,147 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem reported by a rule. Package, Channel and Bundle
+// locate the problem, and are empty if they don't apply.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Package  string   `json:"package,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
+	Bundle   string   `json:"bundle,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	var loc []string
+	for _, s := range []string{f.Package, f.Channel, f.Bundle} {
+		if s != "" {
+			loc = append(loc, s)
+		}
+	}
+	if len(loc) == 0 {
+		return fmt.Sprintf("%s [%s]: %s", f.Severity, f.Rule, f.Message)
+	}
+	return fmt.Sprintf("%s [%s] %s: %s", f.Severity, f.Rule, strings.Join(loc, "/"), f.Message)
+}
+
+// Rule is a named check over a model. Rules only need to set the location
+// and message of the findings they return; the rule name and severity are
+// filled in by the linter.
+type Rule struct {
+	Name        string
+	Description string
+	Severity    Severity
+	Check       func(m model.Model) []Finding
+}
+
+// Linter runs a set of rules over a model.
+type Linter struct {
+	rules    []Rule
+	disabled map[string]struct{}
+}
+
+type Option func(*Linter)
+
+// WithRules replaces the rules that are run, which default to DefaultRules.
+func WithRules(rules ...Rule) Option {
+	return func(l *Linter) {
+		l.rules = rules
+	}
+}
+
+// WithDisabledRules disables the named rules.
+func WithDisabledRules(names ...string) Option {
+	return func(l *Linter) {
+		for _, name := range names {
+			l.disabled[name] = struct{}{}
+		}
+	}
+}
+
+func NewLinter(opts ...Option) (*Linter, error) {
+	l := &Linter{
+		rules:    DefaultRules(),
+		disabled: map[string]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	known := map[string]struct{}{}
+	for _, r := range l.rules {
+		known[r.Name] = struct{}{}
+	}
+	for name := range l.disabled {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	return l, nil
+}
+
+// Rules returns the rules of the linter, including disabled rules.
+func (l *Linter) Rules() []Rule {
+	return l.rules
+}
+
+// Enabled reports whether the named rule is enabled.
+func (l *Linter) Enabled(name string) bool {
+	_, disabled := l.disabled[name]
+	return !disabled
+}
+
+// Lint runs the enabled rules over m and returns their findings, sorted by
+// location and rule.
+func (l *Linter) Lint(m model.Model) []Finding {
+	var findings []Finding
+	for _, r := range l.rules {
+		if !l.Enabled(r.Name) {
+			continue
+		}
+		for _, f := range r.Check(m) {
+			f.Rule = r.Name
+			f.Severity = r.Severity
+			findings = append(findings, f)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.Channel != b.Channel {
+			return a.Channel < b.Channel
+		}
+		if a.Bundle != b.Bundle {
+			return a.Bundle < b.Bundle
+		}
+		return a.Rule < b.Rule
+	})
+	return findings
+}
+
+// HasErrors reports whether any of the findings has error severity.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+type testBundle struct {
+	name      string
+	version   string
+	replaces  string
+	skips     []string
+	skipRange string
+	props     []property.Property
+}
+
+// addChannel adds a channel with the given bundles to pkg, creating the
+// package in m if needed.
+func addChannel(m model.Model, pkgName, chName string, bundles ...testBundle) *model.Channel {
+	pkg, ok := m[pkgName]
+	if !ok {
+		pkg = &model.Package{Name: pkgName, Channels: map[string]*model.Channel{}}
+		m[pkgName] = pkg
+	}
+	ch := &model.Channel{Package: pkg, Name: chName, Bundles: map[string]*model.Bundle{}}
+	for _, tb := range bundles {
+		props := append([]property.Property{
+			property.MustBuildPackage(pkgName, tb.version),
+			property.MustBuildChannel(chName, tb.replaces),
+		}, tb.props...)
+		ch.Bundles[tb.name] = &model.Bundle{
+			Package:    pkg,
+			Channel:    ch,
+			Name:       tb.name,
+			Image:      pkgName + ":" + tb.version,
+			Replaces:   tb.replaces,
+			Skips:      tb.skips,
+			SkipRange:  tb.skipRange,
+			Properties: props,
+		}
+	}
+	pkg.Channels[chName] = ch
+	if pkg.DefaultChannel == nil {
+		pkg.DefaultChannel = ch
+	}
+	return ch
+}
+
+func TestLint(t *testing.T) {
+	m := model.Model{}
+	addChannel(m, "foo", "stable",
+		testBundle{name: "foo.v1", version: "1.0.0"},
+		testBundle{name: "foo.v2", version: "2.0.0", replaces: "foo.v1"},
+		// Replaces a bundle with a higher version.
+		testBundle{name: "foo.v3", version: "1.5.0", replaces: "foo.v2", skipRange: ">=6.0.0"},
+		testBundle{name: "foo.v4", version: "1.6.0", replaces: "foo.v3", skipRange: ">=1.0.0 <1.5.0"},
+		// A cycle that is not connected to the channel head.
+		testBundle{name: "foo.v0", version: "0.1.0", skips: []string{"foo.v9"}},
+		testBundle{name: "foo.v9", version: "0.9.0", replaces: "foo.v0"},
+	)
+	addChannel(m, "foo", "fast",
+		testBundle{name: "foo.v5", version: "5.0.0", skipRange: "not-a-range"},
+	)
+	addChannel(m, "bar", "stable",
+		testBundle{name: "bar.v1", version: "1.0.0", props: []property.Property{
+			property.MustBuildPackageRequired("foo", ">=1.0.0"),
+			property.MustBuildPackageRequired("baz", ">=1.0.0"),
+			property.MustBuildGVK("bar.io", "v1", "Bar"),
+			property.MustBuildGVKRequired("bar.io", "v1", "Bar"),
+			property.MustBuildGVKRequired("baz.io", "v1", "Baz"),
+		}},
+	)
+
+	l, err := NewLinter()
+	require.NoError(t, err)
+	findings := l.Lint(m)
+
+	var got []Finding
+	for _, f := range findings {
+		// Messages are checked separately below.
+		got = append(got, Finding{Rule: f.Rule, Severity: f.Severity, Package: f.Package, Channel: f.Channel, Bundle: f.Bundle})
+	}
+	assert.Equal(t, []Finding{
+		{Rule: "gvk-required-unprovided", Severity: SeverityWarning, Package: "bar", Bundle: "bar.v1"},
+		{Rule: "package-required-unsatisfied", Severity: SeverityWarning, Package: "bar", Bundle: "bar.v1"},
+		{Rule: "skiprange-invalid", Severity: SeverityError, Package: "foo", Channel: "fast", Bundle: "foo.v5"},
+		{Rule: "default-channel-head-older", Severity: SeverityWarning, Package: "foo", Channel: "stable"},
+		{Rule: "unreachable-bundle", Severity: SeverityWarning, Package: "foo", Channel: "stable", Bundle: "foo.v0"},
+		{Rule: "replaces-version-decrease", Severity: SeverityError, Package: "foo", Channel: "stable", Bundle: "foo.v3"},
+		{Rule: "skiprange-no-match", Severity: SeverityWarning, Package: "foo", Channel: "stable", Bundle: "foo.v3"},
+		{Rule: "unreachable-bundle", Severity: SeverityWarning, Package: "foo", Channel: "stable", Bundle: "foo.v9"},
+	}, got)
+	assert.True(t, HasErrors(findings))
+
+	assert.Contains(t, findings[0].Message, "baz.io/v1, Kind=Baz")
+	assert.Contains(t, findings[1].Message, `"baz"`)
+	assert.Contains(t, findings[2].Message, "invalid skipRange")
+	assert.Contains(t, findings[3].Message, `channel "fast"`)
+}
+
+func TestLintDisabledRules(t *testing.T) {
+	m := model.Model{}
+	addChannel(m, "foo", "stable",
+		testBundle{name: "foo.v2", version: "2.0.0"},
+		testBundle{name: "foo.v1", version: "1.0.0", replaces: "foo.v2"},
+	)
+
+	l, err := NewLinter()
+	require.NoError(t, err)
+	assert.NotEmpty(t, l.Lint(m))
+
+	l, err = NewLinter(WithDisabledRules("replaces-version-decrease"))
+	require.NoError(t, err)
+	assert.False(t, l.Enabled("replaces-version-decrease"))
+	assert.Empty(t, l.Lint(m))
+
+	_, err = NewLinter(WithDisabledRules("no-such-rule"))
+	assert.EqualError(t, err, `unknown rule "no-such-rule"`)
+}
+
+func TestLintCustomRules(t *testing.T) {
+	m := model.Model{}
+	addChannel(m, "foo", "stable", testBundle{name: "foo.v1", version: "1.0.0"})
+
+	l, err := NewLinter(WithRules(Rule{
+		Name:     "always",
+		Severity: SeverityError,
+		Check: func(m model.Model) []Finding {
+			return []Finding{{Package: "foo", Message: "hello"}}
+		},
+	}))
+	require.NoError(t, err)
+	findings := l.Lint(m)
+	assert.Equal(t, []Finding{{Rule: "always", Severity: SeverityError, Package: "foo", Message: "hello"}}, findings)
+	assert.True(t, HasErrors(findings))
+	assert.Equal(t, "error [always] foo: hello", findings[0].String())
+}
@@ -0,0 +1,399 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+// DefaultRules returns the rules that are run by a linter by default.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name:        "unreachable-bundle",
+			Description: "bundles that cannot be upgraded to their channel head through replaces, skips or skipRange edges",
+			Severity:    SeverityWarning,
+			Check:       checkUnreachableBundles,
+		},
+		{
+			Name:        "skiprange-invalid",
+			Description: "skipRange expressions that can't be parsed",
+			Severity:    SeverityError,
+			Check:       checkInvalidSkipRanges,
+		},
+		{
+			Name:        "skiprange-no-match",
+			Description: "skipRange expressions that match no other bundle in the package",
+			Severity:    SeverityWarning,
+			Check:       checkSkipRanges,
+		},
+		{
+			Name:        "replaces-version-decrease",
+			Description: "bundles whose version is not greater than the version of the bundle they replace",
+			Severity:    SeverityError,
+			Check:       checkReplacesVersions,
+		},
+		{
+			Name:        "default-channel-head-older",
+			Description: "default channels whose head is older than the head of another channel",
+			Severity:    SeverityWarning,
+			Check:       checkDefaultChannelHeads,
+		},
+		{
+			Name:        "package-required-unsatisfied",
+			Description: "olm.package.required properties that no bundle in the catalog satisfies",
+			Severity:    SeverityWarning,
+			Check:       checkRequiredPackages,
+		},
+		{
+			Name:        "gvk-required-unprovided",
+			Description: "olm.gvk.required properties that no bundle in the catalog provides",
+			Severity:    SeverityWarning,
+			Check:       checkRequiredGVKs,
+		},
+	}
+}
+
+func checkUnreachableBundles(m model.Model) []Finding {
+	var findings []Finding
+	versions := versionCache{}
+	forEachChannel(m, func(ch *model.Channel) {
+		head, err := ch.Head()
+		if err != nil {
+			// Channels without a single head are reported by model validation.
+			return
+		}
+		visited := map[string]struct{}{}
+		queue := []*model.Bundle{head}
+		for len(queue) > 0 {
+			b := queue[0]
+			queue = queue[1:]
+			if _, ok := visited[b.Name]; ok {
+				continue
+			}
+			visited[b.Name] = struct{}{}
+			for _, pred := range predecessors(ch, b, versions.of(ch.Package)) {
+				queue = append(queue, pred)
+			}
+		}
+		for _, name := range sortedBundleNames(ch) {
+			if _, ok := visited[name]; !ok {
+				findings = append(findings, Finding{
+					Package: ch.Package.Name,
+					Channel: ch.Name,
+					Bundle:  name,
+					Message: fmt.Sprintf("bundle cannot be upgraded to channel head %q", head.Name),
+				})
+			}
+		}
+	})
+	return findings
+}
+
+// predecessors returns the bundles in ch that can be upgraded to b, given the
+// versions of the bundles in its package.
+func predecessors(ch *model.Channel, b *model.Bundle, versions map[string]semver.Version) []*model.Bundle {
+	var out []*model.Bundle
+	if pred, ok := ch.Bundles[b.Replaces]; ok {
+		out = append(out, pred)
+	}
+	for _, skip := range b.Skips {
+		if pred, ok := ch.Bundles[skip]; ok {
+			out = append(out, pred)
+		}
+	}
+	if b.SkipRange == "" {
+		return out
+	}
+	r, err := semver.ParseRange(b.SkipRange)
+	if err != nil {
+		return out
+	}
+	for _, name := range sortedBundleNames(ch) {
+		if v, ok := versions[name]; ok && name != b.Name && r(v) {
+			out = append(out, ch.Bundles[name])
+		}
+	}
+	return out
+}
+
+func checkInvalidSkipRanges(m model.Model) []Finding {
+	var findings []Finding
+	forEachChannel(m, func(ch *model.Channel) {
+		for _, name := range sortedBundleNames(ch) {
+			b := ch.Bundles[name]
+			if b.SkipRange == "" {
+				continue
+			}
+			if _, err := semver.ParseRange(b.SkipRange); err != nil {
+				findings = append(findings, Finding{
+					Package: ch.Package.Name,
+					Channel: ch.Name,
+					Bundle:  b.Name,
+					Message: fmt.Sprintf("invalid skipRange %q: %v", b.SkipRange, err),
+				})
+			}
+		}
+	})
+	return findings
+}
+
+func checkSkipRanges(m model.Model) []Finding {
+	var findings []Finding
+	versions := versionCache{}
+	forEachChannel(m, func(ch *model.Channel) {
+		for _, name := range sortedBundleNames(ch) {
+			b := ch.Bundles[name]
+			if b.SkipRange == "" {
+				continue
+			}
+			finding := Finding{Package: ch.Package.Name, Channel: ch.Name, Bundle: b.Name}
+			r, err := semver.ParseRange(b.SkipRange)
+			if err != nil {
+				// Reported by skiprange-invalid.
+				continue
+			}
+			matched := false
+			for other, v := range versions.of(ch.Package) {
+				if other != b.Name && r(v) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				finding.Message = fmt.Sprintf("skipRange %q does not match any other bundle in the package", b.SkipRange)
+				findings = append(findings, finding)
+			}
+		}
+	})
+	return findings
+}
+
+func checkReplacesVersions(m model.Model) []Finding {
+	var findings []Finding
+	versions := versionCache{}
+	forEachChannel(m, func(ch *model.Channel) {
+		pkgVersions := versions.of(ch.Package)
+		for _, name := range sortedBundleNames(ch) {
+			b := ch.Bundles[name]
+			replaced, ok := ch.Bundles[b.Replaces]
+			if !ok {
+				continue
+			}
+			v, ok := pkgVersions[b.Name]
+			if !ok {
+				continue
+			}
+			rv, ok := pkgVersions[replaced.Name]
+			if !ok {
+				continue
+			}
+			if v.LTE(rv) {
+				findings = append(findings, Finding{
+					Package: ch.Package.Name,
+					Channel: ch.Name,
+					Bundle:  b.Name,
+					Message: fmt.Sprintf("version %s is not greater than version %s of replaced bundle %q", v, rv, replaced.Name),
+				})
+			}
+		}
+	})
+	return findings
+}
+
+func checkDefaultChannelHeads(m model.Model) []Finding {
+	var findings []Finding
+	for _, pkgName := range sortedPackageNames(m) {
+		pkg := m[pkgName]
+		if pkg.DefaultChannel == nil {
+			continue
+		}
+		defaultHead, err := pkg.DefaultChannel.Head()
+		if err != nil {
+			continue
+		}
+		defaultVersion, err := defaultHead.Version()
+		if err != nil {
+			continue
+		}
+		for _, chName := range sortedChannelNames(pkg) {
+			ch := pkg.Channels[chName]
+			if ch == pkg.DefaultChannel {
+				continue
+			}
+			head, err := ch.Head()
+			if err != nil {
+				continue
+			}
+			if v, err := head.Version(); err == nil && v.GT(defaultVersion) {
+				findings = append(findings, Finding{
+					Package: pkg.Name,
+					Channel: pkg.DefaultChannel.Name,
+					Message: fmt.Sprintf("default channel head %q (%s) is older than head %q (%s) of channel %q", defaultHead.Name, defaultVersion, head.Name, v, ch.Name),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func checkRequiredPackages(m model.Model) []Finding {
+	var findings []Finding
+	versions := versionCache{}
+	forEachBundle(m, func(pkg *model.Package, b *model.Bundle) {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			return
+		}
+		for _, req := range props.PackagesRequired {
+			r, err := semver.ParseRange(req.VersionRange)
+			if err != nil {
+				findings = append(findings, Finding{
+					Package: pkg.Name,
+					Bundle:  b.Name,
+					Message: fmt.Sprintf("invalid version range %q for required package %q: %v", req.VersionRange, req.PackageName, err),
+				})
+				continue
+			}
+			satisfied := false
+			if reqPkg, ok := m[req.PackageName]; ok {
+				for _, v := range versions.of(reqPkg) {
+					if r(v) {
+						satisfied = true
+						break
+					}
+				}
+			}
+			if !satisfied {
+				findings = append(findings, Finding{
+					Package: pkg.Name,
+					Bundle:  b.Name,
+					Message: fmt.Sprintf("no bundle satisfies required package %q with version range %q", req.PackageName, req.VersionRange),
+				})
+			}
+		}
+	})
+	return findings
+}
+
+func checkRequiredGVKs(m model.Model) []Finding {
+	provided := map[property.GVK]struct{}{}
+	forEachBundle(m, func(_ *model.Package, b *model.Bundle) {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			return
+		}
+		for _, gvk := range props.GVKs {
+			provided[gvk] = struct{}{}
+		}
+	})
+
+	var findings []Finding
+	forEachBundle(m, func(pkg *model.Package, b *model.Bundle) {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			return
+		}
+		for _, req := range props.GVKsRequired {
+			if _, ok := provided[property.GVK(req)]; !ok {
+				findings = append(findings, Finding{
+					Package: pkg.Name,
+					Bundle:  b.Name,
+					Message: fmt.Sprintf("no bundle provides required API %s/%s, Kind=%s", req.Group, req.Version, req.Kind),
+				})
+			}
+		}
+	})
+	return findings
+}
+
+func forEachChannel(m model.Model, f func(*model.Channel)) {
+	for _, pkgName := range sortedPackageNames(m) {
+		pkg := m[pkgName]
+		for _, chName := range sortedChannelNames(pkg) {
+			f(pkg.Channels[chName])
+		}
+	}
+}
+
+// forEachBundle calls f once for each bundle in m. Bundles that are in
+// several channels are only visited in the first of them.
+func forEachBundle(m model.Model, f func(*model.Package, *model.Bundle)) {
+	for _, pkgName := range sortedPackageNames(m) {
+		pkg := m[pkgName]
+		for _, b := range packageBundles(pkg) {
+			f(pkg, b)
+		}
+	}
+}
+
+// packageBundles returns one copy of each bundle in pkg, sorted by name.
+func packageBundles(pkg *model.Package) []*model.Bundle {
+	seen := map[string]struct{}{}
+	var out []*model.Bundle
+	for _, chName := range sortedChannelNames(pkg) {
+		ch := pkg.Channels[chName]
+		for _, name := range sortedBundleNames(ch) {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			out = append(out, ch.Bundles[name])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// versionCache holds the parsed versions of the bundles of each package, so
+// that rules comparing every pair of bundles parse each version only once.
+type versionCache map[*model.Package]map[string]semver.Version
+
+// of returns the versions of the bundles in pkg, keyed by bundle name.
+// Bundles with invalid versions are left out.
+func (c versionCache) of(pkg *model.Package) map[string]semver.Version {
+	if versions, ok := c[pkg]; ok {
+		return versions
+	}
+	versions := map[string]semver.Version{}
+	for _, b := range packageBundles(pkg) {
+		if v, err := b.Version(); err == nil {
+			versions[b.Name] = v
+		}
+	}
+	c[pkg] = versions
+	return versions
+}
+
+func sortedPackageNames(m model.Model) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedChannelNames(pkg *model.Package) []string {
+	var names []string
+	for name := range pkg.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedBundleNames(ch *model.Channel) []string {
+	var names []string
+	for name := range ch.Bundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
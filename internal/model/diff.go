@@ -0,0 +1,317 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/blang/semver"
+
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+// Diff describes the differences between two models. Additions and removals
+// are reported at the highest level at which they occur: the channels and
+// bundles of an added package are not listed separately, nor are the
+// bundles of an added channel.
+type Diff struct {
+	AddedPackages          []string               `json:"addedPackages,omitempty"`
+	RemovedPackages        []string               `json:"removedPackages,omitempty"`
+	ChangedDefaultChannels []DefaultChannelChange `json:"changedDefaultChannels,omitempty"`
+	AddedChannels          []ChannelRef           `json:"addedChannels,omitempty"`
+	RemovedChannels        []ChannelRef           `json:"removedChannels,omitempty"`
+	MovedHeads             []HeadChange           `json:"movedHeads,omitempty"`
+	AddedBundles           []BundleRef            `json:"addedBundles,omitempty"`
+	RemovedBundles         []BundleRef            `json:"removedBundles,omitempty"`
+	ChangedBundles         []BundleRef            `json:"changedBundles,omitempty"`
+}
+
+type ChannelRef struct {
+	Package string `json:"package"`
+	Channel string `json:"channel"`
+}
+
+type BundleRef struct {
+	Package string `json:"package"`
+	Channel string `json:"channel"`
+	Name    string `json:"name"`
+}
+
+type DefaultChannelChange struct {
+	Package string `json:"package"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+type HeadChange struct {
+	Package string `json:"package"`
+	Channel string `json:"channel"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// Empty reports whether the diff contains no changes.
+func (d Diff) Empty() bool {
+	return reflect.DeepEqual(d, Diff{})
+}
+
+// Compare returns the differences between the old and new models. Bundle
+// property values are compared byte for byte, so both models should be
+// normalized first.
+func Compare(oldModel, newModel Model) Diff {
+	var d Diff
+	for _, pkgName := range sortedKeys(newModel) {
+		newPkg := newModel[pkgName]
+		oldPkg, ok := oldModel[pkgName]
+		if !ok {
+			d.AddedPackages = append(d.AddedPackages, pkgName)
+			continue
+		}
+		if from, to := channelName(oldPkg.DefaultChannel), channelName(newPkg.DefaultChannel); from != to {
+			d.ChangedDefaultChannels = append(d.ChangedDefaultChannels, DefaultChannelChange{Package: pkgName, From: from, To: to})
+		}
+		d.compareChannels(oldPkg, newPkg)
+	}
+	for _, pkgName := range sortedKeys(oldModel) {
+		if _, ok := newModel[pkgName]; !ok {
+			d.RemovedPackages = append(d.RemovedPackages, pkgName)
+		}
+	}
+	return d
+}
+
+func (d *Diff) compareChannels(oldPkg, newPkg *Package) {
+	for _, chName := range sortedKeys(newPkg.Channels) {
+		newCh := newPkg.Channels[chName]
+		oldCh, ok := oldPkg.Channels[chName]
+		if !ok {
+			d.AddedChannels = append(d.AddedChannels, ChannelRef{Package: newPkg.Name, Channel: chName})
+			continue
+		}
+		if from, to := headName(oldCh), headName(newCh); from != to {
+			d.MovedHeads = append(d.MovedHeads, HeadChange{Package: newPkg.Name, Channel: chName, From: from, To: to})
+		}
+		d.compareBundles(oldCh, newCh)
+	}
+	for _, chName := range sortedKeys(oldPkg.Channels) {
+		if _, ok := newPkg.Channels[chName]; !ok {
+			d.RemovedChannels = append(d.RemovedChannels, ChannelRef{Package: oldPkg.Name, Channel: chName})
+		}
+	}
+}
+
+func (d *Diff) compareBundles(oldCh, newCh *Channel) {
+	for _, name := range sortedKeys(newCh.Bundles) {
+		ref := BundleRef{Package: newCh.Package.Name, Channel: newCh.Name, Name: name}
+		oldB, ok := oldCh.Bundles[name]
+		switch {
+		case !ok:
+			d.AddedBundles = append(d.AddedBundles, ref)
+		case bundleChanged(oldB, newCh.Bundles[name]):
+			d.ChangedBundles = append(d.ChangedBundles, ref)
+		}
+	}
+	for _, name := range sortedKeys(oldCh.Bundles) {
+		if _, ok := newCh.Bundles[name]; !ok {
+			d.RemovedBundles = append(d.RemovedBundles, BundleRef{Package: oldCh.Package.Name, Channel: oldCh.Name, Name: name})
+		}
+	}
+}
+
+// Delta returns a model containing only the bundles of newModel that are
+// absent from or changed since oldModel, along with the bundles they
+// transitively replace, skip or cover with their skip range. The head of each
+// channel of the delta is included too, so that its upgrade graph is complete
+// and its head is the same as in newModel.
+//
+// If a package's default channel has no new or changed bundles, its head and
+// its ancestors are included so that the delta is a valid model.
+func Delta(oldModel, newModel Model) (Model, error) {
+	delta := Model{}
+	for _, pkgName := range sortedKeys(newModel) {
+		newPkg := newModel[pkgName]
+		oldPkg := oldModel[pkgName]
+
+		var changed []*Bundle
+		for _, chName := range sortedKeys(newPkg.Channels) {
+			newCh := newPkg.Channels[chName]
+			var oldCh *Channel
+			if oldPkg != nil {
+				oldCh = oldPkg.Channels[chName]
+			}
+			for _, name := range sortedKeys(newCh.Bundles) {
+				b := newCh.Bundles[name]
+				if oldCh == nil || oldCh.Bundles[name] == nil || bundleChanged(oldCh.Bundles[name], b) {
+					changed = append(changed, b)
+				}
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		pkg := &Package{
+			Name:        newPkg.Name,
+			Description: newPkg.Description,
+			Icon:        newPkg.Icon,
+			Channels:    map[string]*Channel{},
+		}
+		delta[pkgName] = pkg
+		for _, b := range changed {
+			if err := addWithAncestors(pkg, b); err != nil {
+				return nil, err
+			}
+		}
+		chNames := sortedKeys(pkg.Channels)
+		if newPkg.DefaultChannel != nil {
+			if _, ok := pkg.Channels[newPkg.DefaultChannel.Name]; !ok {
+				chNames = append(chNames, newPkg.DefaultChannel.Name)
+			}
+		}
+		for _, chName := range chNames {
+			head, err := newPkg.Channels[chName].Head()
+			if err != nil {
+				return nil, fmt.Errorf("package %q channel %q: %v", pkgName, chName, err)
+			}
+			if err := addWithAncestors(pkg, head); err != nil {
+				return nil, err
+			}
+		}
+		if newPkg.DefaultChannel != nil {
+			pkg.DefaultChannel = pkg.Channels[newPkg.DefaultChannel.Name]
+		}
+	}
+	return delta, nil
+}
+
+// addWithAncestors copies b and the bundles it transitively replaces, skips
+// or covers with its skip range into the matching channel of pkg.
+func addWithAncestors(pkg *Package, b *Bundle) error {
+	ch, ok := pkg.Channels[b.Channel.Name]
+	if !ok {
+		ch = &Channel{Package: pkg, Name: b.Channel.Name, Bundles: map[string]*Bundle{}}
+		pkg.Channels[ch.Name] = ch
+	}
+	queue := []*Bundle{b}
+	for len(queue) > 0 {
+		b, queue = queue[0], queue[1:]
+		if _, ok := ch.Bundles[b.Name]; ok {
+			continue
+		}
+		cp := *b
+		cp.Package = pkg
+		cp.Channel = ch
+		ch.Bundles[cp.Name] = &cp
+
+		ancestors, err := ancestors(b)
+		if err != nil {
+			return fmt.Errorf("package %q channel %q bundle %q: %v", pkg.Name, ch.Name, b.Name, err)
+		}
+		queue = append(queue, ancestors...)
+	}
+	return nil
+}
+
+// ancestors returns the bundles of b's channel that b replaces, skips or
+// covers with its skip range.
+func ancestors(b *Bundle) ([]*Bundle, error) {
+	var out []*Bundle
+	for _, name := range append([]string{b.Replaces}, b.Skips...) {
+		if a, ok := b.Channel.Bundles[name]; ok {
+			out = append(out, a)
+		}
+	}
+	if b.SkipRange == "" {
+		return out, nil
+	}
+	skipRange, err := semver.ParseRange(b.SkipRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid skip range %q: %v", b.SkipRange, err)
+	}
+	for _, name := range sortedKeys(b.Channel.Bundles) {
+		a := b.Channel.Bundles[name]
+		if a == b {
+			continue
+		}
+		// Bundles without a valid version can't be in any range.
+		if v, err := a.Version(); err == nil && skipRange(v) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// bundleChanged reports whether the content of a bundle differs between two
+// models. Upgrade edges are compared using the bundle fields, and channel
+// membership is reported separately, so the olm.channel, olm.skips and
+// olm.skipRange properties are ignored.
+func bundleChanged(a, b *Bundle) bool {
+	if a.Image != b.Image || a.Replaces != b.Replaces || a.SkipRange != b.SkipRange || a.CsvJSON != b.CsvJSON {
+		return true
+	}
+	return !equalStringSets(a.Skips, b.Skips) ||
+		!equalStringSets(a.Objects, b.Objects) ||
+		!equalStringSets(propertyKeys(a.Properties), propertyKeys(b.Properties)) ||
+		!equalStringSets(relatedImageKeys(a.RelatedImages), relatedImageKeys(b.RelatedImages))
+}
+
+func propertyKeys(props []property.Property) []string {
+	var keys []string
+	for _, p := range props {
+		switch p.Type {
+		case property.TypeChannel, property.TypeSkips, property.TypeSkipRange:
+			continue
+		}
+		keys = append(keys, fmt.Sprintf("%s:%s", p.Type, p.Value))
+	}
+	return keys
+}
+
+func relatedImageKeys(images []RelatedImage) []string {
+	var keys []string
+	for _, i := range images {
+		keys = append(keys, fmt.Sprintf("%s:%s", i.Name, i.Image))
+	}
+	return keys
+}
+
+func equalStringSets(a, b []string) bool {
+	count := map[string]int{}
+	for _, s := range a {
+		count[s]++
+	}
+	for _, s := range b {
+		count[s]--
+	}
+	for _, c := range count {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func channelName(ch *Channel) string {
+	if ch == nil {
+		return ""
+	}
+	return ch.Name
+}
+
+func headName(ch *Channel) string {
+	head, err := ch.Head()
+	if err != nil {
+		return ""
+	}
+	return head.Name
+}
+
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
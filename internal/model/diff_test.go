@@ -0,0 +1,190 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+// buildModel builds a model with a package "foo" and the given default
+// channel. Each channel is a replaces chain of the given bundles,
+// oldest first.
+func buildModel(channels map[string][]string, defaultChannel string) Model {
+	pkg := &Package{Name: "foo", Channels: map[string]*Channel{}}
+	for chName, bundles := range channels {
+		ch := &Channel{Package: pkg, Name: chName, Bundles: map[string]*Bundle{}}
+		replaces := ""
+		for _, name := range bundles {
+			ch.Bundles[name] = &Bundle{
+				Package:  pkg,
+				Channel:  ch,
+				Name:     name,
+				Image:    "foo:" + name,
+				Replaces: replaces,
+				Properties: []property.Property{
+					property.MustBuildPackage("foo", "0.0.0"),
+					property.MustBuildChannel(chName, replaces),
+				},
+			}
+			replaces = name
+		}
+		pkg.Channels[chName] = ch
+	}
+	pkg.DefaultChannel = pkg.Channels[defaultChannel]
+	return Model{"foo": pkg}
+}
+
+func TestCompare(t *testing.T) {
+	old := buildModel(map[string][]string{
+		"stable": {"foo.v1", "foo.v2"},
+		"beta":   {"foo.v1", "foo.v2"},
+		"alpha":  {"foo.v1"},
+	}, "stable")
+	old["bar"] = &Package{Name: "bar"}
+	new := buildModel(map[string][]string{
+		"stable": {"foo.v1", "foo.v2", "foo.v3"},
+		"beta":   {"foo.v1"},
+		"fast":   {"foo.v3"},
+	}, "fast")
+	new["baz"] = &Package{Name: "baz"}
+	new["foo"].Channels["stable"].Bundles["foo.v1"].Image = "foo:changed"
+
+	assert.True(t, Compare(old, old).Empty())
+	assert.Equal(t, Diff{
+		AddedPackages:          []string{"baz"},
+		RemovedPackages:        []string{"bar"},
+		ChangedDefaultChannels: []DefaultChannelChange{{Package: "foo", From: "stable", To: "fast"}},
+		AddedChannels:          []ChannelRef{{Package: "foo", Channel: "fast"}},
+		RemovedChannels:        []ChannelRef{{Package: "foo", Channel: "alpha"}},
+		MovedHeads: []HeadChange{
+			{Package: "foo", Channel: "beta", From: "foo.v2", To: "foo.v1"},
+			{Package: "foo", Channel: "stable", From: "foo.v2", To: "foo.v3"},
+		},
+		AddedBundles:   []BundleRef{{Package: "foo", Channel: "stable", Name: "foo.v3"}},
+		RemovedBundles: []BundleRef{{Package: "foo", Channel: "beta", Name: "foo.v2"}},
+		ChangedBundles: []BundleRef{{Package: "foo", Channel: "stable", Name: "foo.v1"}},
+	}, Compare(old, new))
+}
+
+func TestDelta(t *testing.T) {
+	old := buildModel(map[string][]string{
+		"stable": {"foo.v1", "foo.v2", "foo.v3"},
+		"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+	}, "beta")
+
+	t.Run("NoChanges", func(t *testing.T) {
+		delta, err := Delta(old, old)
+		require.NoError(t, err)
+		assert.Empty(t, delta)
+	})
+
+	t.Run("IncludesAncestors", func(t *testing.T) {
+		new := buildModel(map[string][]string{
+			"stable": {"foo.v1", "foo.v2", "foo.v3", "foo.v4"},
+			"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+		}, "stable")
+		delta, err := Delta(old, new)
+		require.NoError(t, err)
+		require.NoError(t, delta.Validate())
+		require.Len(t, delta["foo"].Channels, 1)
+		assert.Equal(t, "stable", delta["foo"].DefaultChannel.Name)
+		assert.ElementsMatch(t, []string{"foo.v1", "foo.v2", "foo.v3", "foo.v4"}, bundleNames(delta["foo"].Channels["stable"]))
+	})
+
+	t.Run("IncludesDefaultChannelHead", func(t *testing.T) {
+		new := buildModel(map[string][]string{
+			"stable": {"foo.v1", "foo.v2", "foo.v3"},
+			"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+			"fast":   {"foo.v4"},
+		}, "beta")
+		delta, err := Delta(old, new)
+		require.NoError(t, err)
+		require.NoError(t, delta.Validate())
+		assert.Equal(t, "beta", delta["foo"].DefaultChannel.Name)
+		assert.ElementsMatch(t, []string{"foo.v4"}, bundleNames(delta["foo"].Channels["fast"]))
+		assert.ElementsMatch(t, []string{"foo.v1", "foo.v2", "foo.v3"}, bundleNames(delta["foo"].Channels["beta"]))
+		assert.NotContains(t, delta["foo"].Channels, "stable")
+	})
+
+	t.Run("IncludesSkippedBundlesAndHead", func(t *testing.T) {
+		// foo.v4 replaces foo.v2 and skips foo.v3, so it is the head of
+		// stable only through its skips. Changing foo.v3 must not make it
+		// the head of the delta.
+		new := buildModel(map[string][]string{
+			"stable": {"foo.v1", "foo.v2", "foo.v3", "foo.v4"},
+			"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+		}, "beta")
+		stable := new["foo"].Channels["stable"]
+		stable.Bundles["foo.v4"].Replaces = "foo.v2"
+		stable.Bundles["foo.v4"].Skips = []string{"foo.v3"}
+		oldStable := buildModel(map[string][]string{
+			"stable": {"foo.v1", "foo.v2", "foo.v3", "foo.v4"},
+			"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+		}, "beta")
+		oldStable["foo"].Channels["stable"].Bundles["foo.v4"].Replaces = "foo.v2"
+		oldStable["foo"].Channels["stable"].Bundles["foo.v4"].Skips = []string{"foo.v3"}
+		stable.Bundles["foo.v3"].Image = "foo:changed"
+
+		delta, err := Delta(oldStable, new)
+		require.NoError(t, err)
+		require.NoError(t, delta.Validate())
+		head, err := delta["foo"].Channels["stable"].Head()
+		require.NoError(t, err)
+		assert.Equal(t, "foo.v4", head.Name)
+		assert.ElementsMatch(t, []string{"foo.v1", "foo.v2", "foo.v3", "foo.v4"}, bundleNames(delta["foo"].Channels["stable"]))
+	})
+
+	t.Run("IncludesSkipRange", func(t *testing.T) {
+		new := buildModel(map[string][]string{
+			"stable": {"foo.v1", "foo.v2", "foo.v3", "foo.v4"},
+			"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+		}, "beta")
+		for i, name := range []string{"foo.v1", "foo.v2", "foo.v3", "foo.v4"} {
+			b := new["foo"].Channels["stable"].Bundles[name]
+			b.Properties[0] = property.MustBuildPackage("foo", fmt.Sprintf("%d.0.0", i+1))
+		}
+		new["foo"].Channels["stable"].Bundles["foo.v4"].SkipRange = "<4.0.0"
+
+		delta, err := Delta(old, new)
+		require.NoError(t, err)
+		require.NoError(t, delta.Validate())
+		assert.ElementsMatch(t, []string{"foo.v1", "foo.v2", "foo.v3", "foo.v4"}, bundleNames(delta["foo"].Channels["stable"]))
+
+		new["foo"].Channels["stable"].Bundles["foo.v4"].SkipRange = "invalid"
+		_, err = Delta(old, new)
+		require.Error(t, err)
+	})
+
+	t.Run("InvalidHead", func(t *testing.T) {
+		new := buildModel(map[string][]string{
+			"stable": {"foo.v1", "foo.v2", "foo.v3", "foo.v4"},
+			"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+		}, "beta")
+		new["foo"].Channels["stable"].Bundles["foo.v4"].Replaces = "foo.v2"
+		_, err := Delta(old, new)
+		require.Error(t, err)
+	})
+
+	t.Run("DoesNotModifyInputs", func(t *testing.T) {
+		new := buildModel(map[string][]string{
+			"stable": {"foo.v1", "foo.v2", "foo.v3", "foo.v4"},
+			"beta":   {"foo.v1", "foo.v2", "foo.v3"},
+		}, "beta")
+		_, err := Delta(old, new)
+		require.NoError(t, err)
+		require.NoError(t, new.Validate())
+		assert.Len(t, new["foo"].Channels, 2)
+	})
+}
+
+func bundleNames(ch *Channel) []string {
+	var names []string
+	for name := range ch.Bundles {
+		names = append(names, name)
+	}
+	return names
+}
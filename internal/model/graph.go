@@ -0,0 +1,231 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// GraphFormat is an output format for upgrade graphs.
+type GraphFormat string
+
+const (
+	GraphFormatDOT     GraphFormat = "dot"
+	GraphFormatMermaid GraphFormat = "mermaid"
+)
+
+type edgeKind string
+
+const (
+	edgeReplaces  edgeKind = "replaces"
+	edgeSkips     edgeKind = "skips"
+	edgeSkipRange edgeKind = "skipRange"
+)
+
+// WriteGraph writes the upgrade graph of each channel of pkg in the given
+// format. Edges point from a bundle to the bundles it can be upgraded to,
+// and are styled by the kind of edge: replaces, skips or skipRange. Channel
+// heads, the default channel and deprecated bundles are highlighted.
+//
+// Bundles that are replaced or skipped but are not in the channel are drawn
+// with a dashed outline.
+func WriteGraph(w io.Writer, pkg *Package, format GraphFormat) error {
+	channels := buildGraph(pkg)
+	switch format {
+	case GraphFormatDOT:
+		return writeDOT(w, pkg.Name, channels)
+	case GraphFormatMermaid:
+		return writeMermaid(w, channels)
+	}
+	return fmt.Errorf("unknown graph format %q", format)
+}
+
+type graphNode struct {
+	name       string
+	head       bool
+	deprecated bool
+	missing    bool
+}
+
+type graphEdge struct {
+	from, to string
+	kind     edgeKind
+}
+
+type graphChannel struct {
+	name      string
+	isDefault bool
+	nodes     []graphNode
+	edges     []graphEdge
+}
+
+func buildGraph(pkg *Package) []graphChannel {
+	var out []graphChannel
+	for _, chName := range sortedKeys(pkg.Channels) {
+		ch := pkg.Channels[chName]
+		gc := graphChannel{
+			name:      ch.Name,
+			isDefault: ch == pkg.DefaultChannel,
+		}
+
+		headName := ""
+		if head, err := ch.Head(); err == nil {
+			headName = head.Name
+		}
+
+		versions := map[string]semver.Version{}
+		for name, b := range ch.Bundles {
+			if v, err := b.Version(); err == nil {
+				versions[name] = v
+			}
+		}
+
+		missing := map[string]struct{}{}
+		for _, name := range sortedKeys(ch.Bundles) {
+			b := ch.Bundles[name]
+			gc.nodes = append(gc.nodes, graphNode{
+				name:       name,
+				head:       name == headName,
+				deprecated: b.Deprecated(),
+			})
+			if b.Replaces != "" {
+				gc.edges = append(gc.edges, graphEdge{from: b.Replaces, to: name, kind: edgeReplaces})
+				if _, ok := ch.Bundles[b.Replaces]; !ok {
+					missing[b.Replaces] = struct{}{}
+				}
+			}
+			for _, skip := range b.Skips {
+				gc.edges = append(gc.edges, graphEdge{from: skip, to: name, kind: edgeSkips})
+				if _, ok := ch.Bundles[skip]; !ok {
+					missing[skip] = struct{}{}
+				}
+			}
+			if b.SkipRange == "" {
+				continue
+			}
+			r, err := semver.ParseRange(b.SkipRange)
+			if err != nil {
+				continue
+			}
+			for _, other := range sortedKeys(ch.Bundles) {
+				if v, ok := versions[other]; ok && other != name && r(v) {
+					gc.edges = append(gc.edges, graphEdge{from: other, to: name, kind: edgeSkipRange})
+				}
+			}
+		}
+		for _, name := range sortedKeys(missing) {
+			gc.nodes = append(gc.nodes, graphNode{name: name, missing: true})
+		}
+		out = append(out, gc)
+	}
+	return out
+}
+
+func writeDOT(w io.Writer, pkgName string, channels []graphChannel) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %q {\n", pkgName)
+	fmt.Fprintf(&sb, "  label=%q;\n", "package "+pkgName)
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=rounded];\n")
+	for _, ch := range channels {
+		id := func(name string) string {
+			return fmt.Sprintf("%q", ch.name+"/"+name)
+		}
+		fmt.Fprintf(&sb, "  subgraph %q {\n", "cluster_"+ch.name)
+		if ch.isDefault {
+			fmt.Fprintf(&sb, "    label=%q;\n", ch.name+" (default)")
+			sb.WriteString("    style=bold;\n")
+		} else {
+			fmt.Fprintf(&sb, "    label=%q;\n", ch.name)
+		}
+		for _, n := range ch.nodes {
+			var attrs []string
+			attrs = append(attrs, fmt.Sprintf("label=%q", n.name))
+			switch {
+			case n.missing:
+				attrs = append(attrs, `style="rounded,dashed"`, "color=gray")
+			case n.deprecated && n.head:
+				attrs = append(attrs, `style="rounded,filled,bold"`, "fillcolor=lightcoral")
+			case n.deprecated:
+				attrs = append(attrs, `style="rounded,filled"`, "fillcolor=lightcoral")
+			case n.head:
+				attrs = append(attrs, `style="rounded,filled,bold"`, "fillcolor=palegreen")
+			}
+			fmt.Fprintf(&sb, "    %s [%s];\n", id(n.name), strings.Join(attrs, ", "))
+		}
+		for _, e := range ch.edges {
+			var attrs string
+			switch e.kind {
+			case edgeReplaces:
+				attrs = "style=solid"
+			case edgeSkips:
+				attrs = "style=dashed, color=blue"
+			case edgeSkipRange:
+				attrs = "style=dotted, color=darkorange"
+			}
+			fmt.Fprintf(&sb, "    %s -> %s [label=%q, %s];\n", id(e.from), id(e.to), e.kind, attrs)
+		}
+		sb.WriteString("  }\n")
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMermaid(w io.Writer, channels []graphChannel) error {
+	var sb strings.Builder
+	sb.WriteString("graph LR\n")
+	sb.WriteString("  classDef head fill:#98fb98,stroke-width:3px;\n")
+	sb.WriteString("  classDef deprecated fill:#f08080;\n")
+	sb.WriteString("  classDef missing stroke-dasharray:5 5,color:#808080;\n")
+	for ci, ch := range channels {
+		// Mermaid IDs must be simple identifiers, so nodes are numbered.
+		ids := map[string]string{}
+		for ni, n := range ch.nodes {
+			ids[n.name] = fmt.Sprintf("c%dn%d", ci, ni)
+		}
+		label := ch.name
+		if ch.isDefault {
+			label += " (default)"
+		}
+		fmt.Fprintf(&sb, "  subgraph c%d[%q]\n", ci, label)
+		for _, n := range ch.nodes {
+			fmt.Fprintf(&sb, "    %s[%q]\n", ids[n.name], n.name)
+		}
+		for _, e := range ch.edges {
+			arrow := "-->"
+			switch e.kind {
+			case edgeSkips:
+				arrow = "-.->"
+			case edgeSkipRange:
+				arrow = "==>"
+			}
+			fmt.Fprintf(&sb, "    %s %s|%s| %s\n", ids[e.from], arrow, e.kind, ids[e.to])
+		}
+		sb.WriteString("  end\n")
+		if ch.isDefault {
+			fmt.Fprintf(&sb, "  style c%d stroke-width:3px\n", ci)
+		}
+		for _, n := range ch.nodes {
+			// A deprecated head gets both classes, the fill of deprecated
+			// taking precedence over the one of head as it is defined later.
+			var classes []string
+			if n.missing {
+				classes = append(classes, "missing")
+			}
+			if n.head {
+				classes = append(classes, "head")
+			}
+			if n.deprecated {
+				classes = append(classes, "deprecated")
+			}
+			for _, class := range classes {
+				fmt.Fprintf(&sb, "  class %s %s\n", ids[n.name], class)
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
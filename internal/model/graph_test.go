@@ -0,0 +1,139 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+func buildGraphTestPackage() *Package {
+	pkg := &Package{Name: "foo", Channels: map[string]*Channel{}}
+	stable := &Channel{Package: pkg, Name: "stable", Bundles: map[string]*Bundle{}}
+	fast := &Channel{Package: pkg, Name: "fast", Bundles: map[string]*Bundle{}}
+	pkg.Channels["stable"] = stable
+	pkg.Channels["fast"] = fast
+	pkg.DefaultChannel = stable
+
+	add := func(ch *Channel, version, replaces, skipRange string, skips []string, props ...property.Property) {
+		name := "foo.v" + version
+		ch.Bundles[name] = &Bundle{
+			Package:    pkg,
+			Channel:    ch,
+			Name:       name,
+			Image:      "foo:" + version,
+			Replaces:   replaces,
+			Skips:      skips,
+			SkipRange:  skipRange,
+			Properties: append([]property.Property{property.MustBuildPackage("foo", version)}, props...),
+		}
+	}
+	add(stable, "0.1.0", "", "", nil, property.MustBuildDeprecated())
+	add(stable, "0.2.0", "foo.v0.1.0", "", []string{"foo.v0.1.1"})
+	add(stable, "0.3.0", "foo.v0.2.0", "<0.3.0", nil)
+	add(fast, "0.3.0", "", "", nil)
+	return pkg
+}
+
+func TestWriteGraph(t *testing.T) {
+	type spec struct {
+		name      string
+		format    GraphFormat
+		assertion require.ErrorAssertionFunc
+		expected  string
+	}
+	specs := []spec{
+		{
+			name:      "DOT",
+			format:    GraphFormatDOT,
+			assertion: require.NoError,
+			expected: `digraph "foo" {
+  label="package foo";
+  rankdir=LR;
+  node [shape=box, style=rounded];
+  subgraph "cluster_fast" {
+    label="fast";
+    "fast/foo.v0.3.0" [label="foo.v0.3.0", style="rounded,filled,bold", fillcolor=palegreen];
+  }
+  subgraph "cluster_stable" {
+    label="stable (default)";
+    style=bold;
+    "stable/foo.v0.1.0" [label="foo.v0.1.0", style="rounded,filled", fillcolor=lightcoral];
+    "stable/foo.v0.2.0" [label="foo.v0.2.0"];
+    "stable/foo.v0.3.0" [label="foo.v0.3.0", style="rounded,filled,bold", fillcolor=palegreen];
+    "stable/foo.v0.1.1" [label="foo.v0.1.1", style="rounded,dashed", color=gray];
+    "stable/foo.v0.1.0" -> "stable/foo.v0.2.0" [label="replaces", style=solid];
+    "stable/foo.v0.1.1" -> "stable/foo.v0.2.0" [label="skips", style=dashed, color=blue];
+    "stable/foo.v0.2.0" -> "stable/foo.v0.3.0" [label="replaces", style=solid];
+    "stable/foo.v0.1.0" -> "stable/foo.v0.3.0" [label="skipRange", style=dotted, color=darkorange];
+    "stable/foo.v0.2.0" -> "stable/foo.v0.3.0" [label="skipRange", style=dotted, color=darkorange];
+  }
+}
+`,
+		},
+		{
+			name:      "Mermaid",
+			format:    GraphFormatMermaid,
+			assertion: require.NoError,
+			expected: `graph LR
+  classDef head fill:#98fb98,stroke-width:3px;
+  classDef deprecated fill:#f08080;
+  classDef missing stroke-dasharray:5 5,color:#808080;
+  subgraph c0["fast"]
+    c0n0["foo.v0.3.0"]
+  end
+  class c0n0 head
+  subgraph c1["stable (default)"]
+    c1n0["foo.v0.1.0"]
+    c1n1["foo.v0.2.0"]
+    c1n2["foo.v0.3.0"]
+    c1n3["foo.v0.1.1"]
+    c1n0 -->|replaces| c1n1
+    c1n3 -.->|skips| c1n1
+    c1n1 -->|replaces| c1n2
+    c1n0 ==>|skipRange| c1n2
+    c1n1 ==>|skipRange| c1n2
+  end
+  style c1 stroke-width:3px
+  class c1n0 deprecated
+  class c1n2 head
+  class c1n3 missing
+`,
+		},
+		{
+			name:      "UnknownFormat",
+			format:    "svg",
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteGraph(&buf, buildGraphTestPackage(), s.format)
+			s.assertion(t, err)
+			if err == nil {
+				assert.Equal(t, s.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteGraphDeprecatedHead(t *testing.T) {
+	pkg := buildGraphTestPackage()
+	pkg.Channels["fast"].Bundles["foo.v0.3.0"].Properties = append(pkg.Channels["fast"].Bundles["foo.v0.3.0"].Properties, property.MustBuildDeprecated())
+
+	var dot bytes.Buffer
+	require.NoError(t, WriteGraph(&dot, pkg, GraphFormatDOT))
+	assert.Contains(t, dot.String(), `"fast/foo.v0.3.0" [label="foo.v0.3.0", style="rounded,filled,bold", fillcolor=lightcoral];`)
+
+	var mermaid bytes.Buffer
+	require.NoError(t, WriteGraph(&mermaid, pkg, GraphFormatMermaid))
+	assert.Contains(t, mermaid.String(), `  end
+  class c0n0 head
+  class c0n0 deprecated
+  subgraph c1["stable (default)"]
+`)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/blang/semver"
 	"github.com/h2non/filetype"
 	"github.com/h2non/filetype/matchers"
 	"github.com/h2non/filetype/types"
@@ -206,6 +207,7 @@ type Bundle struct {
 	Image         string
 	Replaces      string
 	Skips         []string
+	SkipRange     string
 	Properties    []property.Property
 	RelatedImages []RelatedImage
 
@@ -267,6 +269,28 @@ func (b *Bundle) Validate() error {
 	return result.ErrorOrNil()
 }
 
+// Version returns the version from the bundle's olm.package property.
+func (b *Bundle) Version() (semver.Version, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	if len(props.Packages) != 1 {
+		return semver.Version{}, fmt.Errorf("must be exactly one property with type %q", property.TypePackage)
+	}
+	return semver.Parse(props.Packages[0].Version)
+}
+
+// Deprecated reports whether the bundle has an olm.deprecated property.
+func (b *Bundle) Deprecated() bool {
+	for _, p := range b.Properties {
+		if p.Type == property.TypeDeprecated {
+			return true
+		}
+	}
+	return false
+}
+
 type RelatedImage struct {
 	Name  string
 	Image string
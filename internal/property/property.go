@@ -58,9 +58,19 @@ type GVKRequired struct {
 	Version string `json:"version"`
 }
 
+type Label struct {
+	Label string `json:"label"`
+}
+
+type LabelRequired struct {
+	Label string `json:"label"`
+}
+
 type Skips string
 type SkipRange string
 
+type Deprecated struct{}
+
 type BundleObject struct {
 	File `json:",inline"`
 }
@@ -130,9 +140,12 @@ type Properties struct {
 	Channels         []Channel
 	GVKs             []GVK
 	GVKsRequired     []GVKRequired
+	Labels           []Label
+	LabelsRequired   []LabelRequired
 	Skips            []Skips
 	SkipRanges       []SkipRange
 	BundleObjects    []BundleObject
+	Deprecations     []Deprecated
 
 	Others []Property
 }
@@ -143,9 +156,12 @@ const (
 	TypeChannel         = "olm.channel"
 	TypeGVK             = "olm.gvk"
 	TypeGVKRequired     = "olm.gvk.required"
+	TypeLabel           = "olm.label"
+	TypeLabelRequired   = "olm.label.required"
 	TypeSkips           = "olm.skips"
 	TypeSkipRange       = "olm.skipRange"
 	TypeBundleObject    = "olm.bundle.object"
+	TypeDeprecated      = "olm.deprecated"
 )
 
 func Parse(in []Property) (*Properties, error) {
@@ -182,6 +198,18 @@ func Parse(in []Property) (*Properties, error) {
 				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
 			}
 			out.GVKsRequired = append(out.GVKsRequired, p)
+		case TypeLabel:
+			var p Label
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.Labels = append(out.Labels, p)
+		case TypeLabelRequired:
+			var p LabelRequired
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.LabelsRequired = append(out.LabelsRequired, p)
 		case TypeSkips:
 			var p Skips
 			if err := json.Unmarshal(prop.Value, &p); err != nil {
@@ -200,6 +228,12 @@ func Parse(in []Property) (*Properties, error) {
 				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
 			}
 			out.BundleObjects = append(out.BundleObjects, p)
+		case TypeDeprecated:
+			var p Deprecated
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.Deprecations = append(out.Deprecations, p)
 		default:
 			var p json.RawMessage
 			if err := json.Unmarshal(prop.Value, &p); err != nil {
@@ -299,6 +333,12 @@ func MustBuildGVK(group, version, kind string) Property {
 func MustBuildGVKRequired(group, version, kind string) Property {
 	return MustBuild(&GVKRequired{group, kind, version})
 }
+func MustBuildLabel(label string) Property {
+	return MustBuild(&Label{label})
+}
+func MustBuildLabelRequired(label string) Property {
+	return MustBuild(&LabelRequired{label})
+}
 func MustBuildSkips(skips string) Property {
 	s := Skips(skips)
 	return MustBuild(&s)
@@ -313,3 +353,7 @@ func MustBuildBundleObjectRef(ref string) Property {
 func MustBuildBundleObjectData(data []byte) Property {
 	return MustBuild(&BundleObject{File: File{data: data}})
 }
+
+func MustBuildDeprecated() Property {
+	return MustBuild(&Deprecated{})
+}
@@ -267,6 +267,20 @@ func TestParse(t *testing.T) {
 			},
 			assertion: assert.Error,
 		},
+		{
+			name: "Error/InvalidLabel",
+			input: []Property{
+				{Type: TypeLabel, Value: json.RawMessage(`{`)},
+			},
+			assertion: assert.Error,
+		},
+		{
+			name: "Error/InvalidLabelRequired",
+			input: []Property{
+				{Type: TypeLabelRequired, Value: json.RawMessage(`{`)},
+			},
+			assertion: assert.Error,
+		},
 		{
 			name: "Error/InvalidSkips",
 			input: []Property{
@@ -308,12 +322,15 @@ func TestParse(t *testing.T) {
 				MustBuildGVK("group", "v1", "Kind2"),
 				MustBuildGVKRequired("other", "v2", "Kind3"),
 				MustBuildGVKRequired("other", "v2", "Kind4"),
+				MustBuildLabel("label1"),
+				MustBuildLabelRequired("label2"),
 				MustBuildSkips("package1.v0.0.1"),
 				MustBuildSkips("package2.v0.1.1"),
 				MustBuildSkipRange("<0.1.0-0"),
 				MustBuildSkipRange("<0.2.0-0"),
 				MustBuildBundleObjectRef("testref1"),
 				MustBuildBundleObjectData([]byte("testdata2")),
+				MustBuildDeprecated(),
 				{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 				{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
 			},
@@ -338,6 +355,8 @@ func TestParse(t *testing.T) {
 					{"other", "Kind3", "v2"},
 					{"other", "Kind4", "v2"},
 				},
+				Labels:         []Label{{"label1"}},
+				LabelsRequired: []LabelRequired{{"label2"}},
 				Skips: []Skips{
 					"package1.v0.0.1",
 					"package2.v0.1.1",
@@ -350,6 +369,7 @@ func TestParse(t *testing.T) {
 					{File: File{ref: "testref1"}},
 					{File: File{data: []byte("testdata2")}},
 				},
+				Deprecations: []Deprecated{{}},
 				Others: []Property{
 					{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 					{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
@@ -474,6 +494,18 @@ func TestBuild(t *testing.T) {
 			assertion:        require.NoError,
 			expectedProperty: propPtr(MustBuildGVKRequired("group", "v1", "Kind")),
 		},
+		{
+			name:             "Success/Label",
+			input:            &Label{"test"},
+			assertion:        require.NoError,
+			expectedProperty: &Property{Type: TypeLabel, Value: json.RawMessage(`{"label":"test"}`)},
+		},
+		{
+			name:             "Success/LabelRequired",
+			input:            &LabelRequired{"test"},
+			assertion:        require.NoError,
+			expectedProperty: &Property{Type: TypeLabelRequired, Value: json.RawMessage(`{"label":"test"}`)},
+		},
 		{
 			name:             "Success/Skips",
 			input:            skipsPtr("test"),
@@ -492,6 +524,12 @@ func TestBuild(t *testing.T) {
 			assertion:        require.NoError,
 			expectedProperty: propPtr(MustBuildBundleObjectRef("test")),
 		},
+		{
+			name:             "Success/Deprecated",
+			input:            &Deprecated{},
+			assertion:        require.NoError,
+			expectedProperty: &Property{Type: TypeDeprecated, Value: json.RawMessage(`{}`)},
+		},
 		{
 			name:             "Success/Property",
 			input:            &Property{Type: "foo", Value: json.RawMessage(`"bar"`)},
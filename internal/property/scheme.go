@@ -15,9 +15,12 @@ func init() {
 		reflect.TypeOf(&Channel{}):         TypeChannel,
 		reflect.TypeOf(&GVK{}):             TypeGVK,
 		reflect.TypeOf(&GVKRequired{}):     TypeGVKRequired,
+		reflect.TypeOf(&Label{}):           TypeLabel,
+		reflect.TypeOf(&LabelRequired{}):   TypeLabelRequired,
 		reflect.TypeOf(&skips):             TypeSkips,
 		reflect.TypeOf(&skipRange):         TypeSkipRange,
 		reflect.TypeOf(&BundleObject{}):    TypeBundleObject,
+		reflect.TypeOf(&Deprecated{}):      TypeDeprecated,
 	}
 }
 
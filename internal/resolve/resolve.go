@@ -0,0 +1,334 @@
+package resolve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+// Request identifies the bundle to install.
+type Request struct {
+	Package string
+
+	// Channel defaults to the default channel of the package.
+	Channel string
+
+	// Bundle defaults to the head of the channel.
+	Bundle string
+}
+
+// Selection is a bundle chosen by the resolver, along with the reasons it
+// was chosen.
+type Selection struct {
+	Package string   `json:"package"`
+	Channel string   `json:"channel"`
+	Bundle  string   `json:"bundle"`
+	Version string   `json:"version"`
+	Image   string   `json:"image"`
+	Reasons []string `json:"reasons"`
+}
+
+// Unsatisfied is a dependency of a selected bundle that could not be
+// satisfied.
+type Unsatisfied struct {
+	Package    string `json:"package"`
+	Bundle     string `json:"bundle"`
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// Resolution is the closure of bundles needed to install a requested bundle.
+type Resolution struct {
+	Bundles     []Selection   `json:"bundles"`
+	Unsatisfied []Unsatisfied `json:"unsatisfied,omitempty"`
+}
+
+// Satisfied reports whether all dependencies were satisfied.
+func (r Resolution) Satisfied() bool {
+	return len(r.Unsatisfied) == 0
+}
+
+// Resolve computes the bundles that must be installed along with the
+// requested bundle by following olm.package.required, olm.gvk.required and
+// olm.label.required dependencies. At most one bundle is selected per
+// package, and dependencies are satisfied by already selected bundles
+// whenever possible.
+//
+// Package dependencies are satisfied by the highest version of the package
+// that is in the range and not deprecated, preferring the default channel.
+// API and label dependencies are satisfied the same way the registry's
+// GetDefaultBundleThatProvides is: by the head of a default channel, choosing
+// the first package in lexicographical order if several qualify.
+//
+// An error is returned only if the request itself cannot be found;
+// unsatisfiable dependencies are reported in the resolution.
+func Resolve(m model.Model, req Request) (*Resolution, error) {
+	pkg, ok := m[req.Package]
+	if !ok {
+		return nil, fmt.Errorf("package %q not found", req.Package)
+	}
+	ch := pkg.DefaultChannel
+	if req.Channel != "" {
+		ch, ok = pkg.Channels[req.Channel]
+		if !ok {
+			return nil, fmt.Errorf("channel %q not found in package %q", req.Channel, req.Package)
+		}
+	}
+	if ch == nil {
+		return nil, fmt.Errorf("package %q has no default channel", req.Package)
+	}
+	var b *model.Bundle
+	if req.Bundle != "" {
+		b, ok = ch.Bundles[req.Bundle]
+		if !ok {
+			return nil, fmt.Errorf("bundle %q not found in channel %q of package %q", req.Bundle, ch.Name, req.Package)
+		}
+	} else {
+		var err error
+		b, err = ch.Head()
+		if err != nil {
+			return nil, fmt.Errorf("package %q, channel %q has invalid head: %v", req.Package, ch.Name, err)
+		}
+	}
+
+	r := resolver{model: m, selected: map[string]*selection{}}
+	r.selectBundle(b, "requested")
+	for i := 0; i < len(r.order); i++ {
+		if err := r.resolveDependencies(r.order[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var res Resolution
+	for _, s := range r.order {
+		res.Bundles = append(res.Bundles, Selection{
+			Package: s.bundle.Package.Name,
+			Channel: s.bundle.Channel.Name,
+			Bundle:  s.bundle.Name,
+			Version: s.version.String(),
+			Image:   s.bundle.Image,
+			Reasons: s.reasons,
+		})
+	}
+	res.Unsatisfied = r.unsatisfied
+	return &res, nil
+}
+
+type selection struct {
+	bundle  *model.Bundle
+	props   *property.Properties
+	version semver.Version
+	reasons []string
+}
+
+type resolver struct {
+	model       model.Model
+	selected    map[string]*selection
+	order       []*selection
+	unsatisfied []Unsatisfied
+}
+
+func (r *resolver) selectBundle(b *model.Bundle, reason string) {
+	if s, ok := r.selected[b.Package.Name]; ok {
+		s.reasons = append(s.reasons, reason)
+		return
+	}
+	// Bundles in the model have valid properties and versions, so errors
+	// are surfaced when their dependencies are resolved.
+	props, _ := property.Parse(b.Properties)
+	v, _ := b.Version()
+	s := &selection{bundle: b, props: props, version: v, reasons: []string{reason}}
+	r.selected[b.Package.Name] = s
+	r.order = append(r.order, s)
+}
+
+func (r *resolver) resolveDependencies(s *selection) error {
+	if s.props == nil {
+		props, err := property.Parse(s.bundle.Properties)
+		if err != nil {
+			return fmt.Errorf("parse properties of bundle %q: %v", s.bundle.Name, err)
+		}
+		s.props = props
+	}
+
+	pkgReqs := append([]property.PackageRequired{}, s.props.PackagesRequired...)
+	sort.Slice(pkgReqs, func(i, j int) bool {
+		if pkgReqs[i].PackageName != pkgReqs[j].PackageName {
+			return pkgReqs[i].PackageName < pkgReqs[j].PackageName
+		}
+		return pkgReqs[i].VersionRange < pkgReqs[j].VersionRange
+	})
+	for _, req := range pkgReqs {
+		r.resolvePackage(s, req)
+	}
+
+	gvkReqs := append([]property.GVKRequired{}, s.props.GVKsRequired...)
+	sort.Slice(gvkReqs, func(i, j int) bool {
+		return gvkString(gvkReqs[i]) < gvkString(gvkReqs[j])
+	})
+	for _, req := range gvkReqs {
+		req := req
+		r.resolveProvider(s, fmt.Sprintf("API %s", gvkString(req)), func(p *property.Properties) bool {
+			for _, gvk := range p.GVKs {
+				if property.GVKRequired(gvk) == req {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	labelReqs := append([]property.LabelRequired{}, s.props.LabelsRequired...)
+	sort.Slice(labelReqs, func(i, j int) bool {
+		return labelReqs[i].Label < labelReqs[j].Label
+	})
+	for _, req := range labelReqs {
+		req := req
+		r.resolveProvider(s, fmt.Sprintf("label %q", req.Label), func(p *property.Properties) bool {
+			for _, l := range p.Labels {
+				if l.Label == req.Label {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	return nil
+}
+
+func (r *resolver) resolvePackage(s *selection, req property.PackageRequired) {
+	constraint := fmt.Sprintf("package %q with version range %q", req.PackageName, req.VersionRange)
+	reason := fmt.Sprintf("required by %q: %s", s.bundle.Name, constraint)
+	unsatisfied := func(format string, args ...interface{}) {
+		r.unsatisfied = append(r.unsatisfied, Unsatisfied{
+			Package:    s.bundle.Package.Name,
+			Bundle:     s.bundle.Name,
+			Constraint: constraint,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	inRange, err := semver.ParseRange(req.VersionRange)
+	if err != nil {
+		unsatisfied("invalid version range: %v", err)
+		return
+	}
+	if sel, ok := r.selected[req.PackageName]; ok {
+		if !inRange(sel.version) {
+			unsatisfied("package is already resolved to bundle %q with version %s, which is not in range", sel.bundle.Name, sel.version)
+			return
+		}
+		sel.reasons = append(sel.reasons, reason)
+		return
+	}
+	pkg, ok := r.model[req.PackageName]
+	if !ok {
+		unsatisfied("package not found")
+		return
+	}
+
+	for _, ch := range channelsByPreference(pkg) {
+		var best *model.Bundle
+		var bestVersion semver.Version
+		for _, b := range ch.Bundles {
+			v, err := b.Version()
+			if err != nil || !inRange(v) || b.Deprecated() {
+				continue
+			}
+			if best == nil || v.GT(bestVersion) || (v.EQ(bestVersion) && b.Name < best.Name) {
+				best, bestVersion = b, v
+			}
+		}
+		if best != nil {
+			r.selectBundle(best, reason)
+			return
+		}
+	}
+	unsatisfied("no bundle that is not deprecated is in range")
+}
+
+// resolveProvider satisfies a dependency on an API or label that is matched
+// by provides.
+func (r *resolver) resolveProvider(s *selection, constraint string, provides func(*property.Properties) bool) {
+	if provides(s.props) {
+		return
+	}
+	reason := fmt.Sprintf("required by %q: %s", s.bundle.Name, constraint)
+	for _, sel := range r.order {
+		if provides(sel.props) {
+			sel.reasons = append(sel.reasons, reason)
+			return
+		}
+	}
+
+	var conflicts []string
+	for _, pkgName := range sortedPackageNames(r.model) {
+		pkg := r.model[pkgName]
+		if pkg.DefaultChannel == nil {
+			continue
+		}
+		head, err := pkg.DefaultChannel.Head()
+		if err != nil {
+			continue
+		}
+		props, err := property.Parse(head.Properties)
+		if err != nil || !provides(props) {
+			continue
+		}
+		if sel, ok := r.selected[pkgName]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%q (package is already resolved to bundle %q)", head.Name, sel.bundle.Name))
+			continue
+		}
+		r.selectBundle(head, reason)
+		return
+	}
+
+	message := "no default channel head provides it"
+	if len(conflicts) > 0 {
+		message = fmt.Sprintf("only provided by default channel heads that conflict with selected bundles: %s", strings.Join(conflicts, ", "))
+	}
+	r.unsatisfied = append(r.unsatisfied, Unsatisfied{
+		Package:    s.bundle.Package.Name,
+		Bundle:     s.bundle.Name,
+		Constraint: constraint,
+		Message:    message,
+	})
+}
+
+// channelsByPreference returns the default channel of pkg followed by its
+// other channels, sorted by name.
+func channelsByPreference(pkg *model.Package) []*model.Channel {
+	var out []*model.Channel
+	if pkg.DefaultChannel != nil {
+		out = append(out, pkg.DefaultChannel)
+	}
+	var names []string
+	for name, ch := range pkg.Channels {
+		if ch != pkg.DefaultChannel {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out = append(out, pkg.Channels[name])
+	}
+	return out
+}
+
+func sortedPackageNames(m model.Model) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func gvkString(gvk property.GVKRequired) string {
+	return fmt.Sprintf("%s/%s, Kind=%s", gvk.Group, gvk.Version, gvk.Kind)
+}
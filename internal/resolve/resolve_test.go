@@ -0,0 +1,194 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+type testBundle struct {
+	version  string
+	replaces string
+	props    []property.Property
+}
+
+// addChannel adds a channel to the package pkgName of m. Bundles are named
+// "<pkgName>.v<version>" and the first channel added is the default channel.
+func addChannel(m model.Model, pkgName, chName string, bundles ...testBundle) {
+	pkg, ok := m[pkgName]
+	if !ok {
+		pkg = &model.Package{Name: pkgName, Channels: map[string]*model.Channel{}}
+		m[pkgName] = pkg
+	}
+	ch := &model.Channel{Package: pkg, Name: chName, Bundles: map[string]*model.Bundle{}}
+	for _, tb := range bundles {
+		name := pkgName + ".v" + tb.version
+		replaces := ""
+		if tb.replaces != "" {
+			replaces = pkgName + ".v" + tb.replaces
+		}
+		ch.Bundles[name] = &model.Bundle{
+			Package:  pkg,
+			Channel:  ch,
+			Name:     name,
+			Image:    pkgName + ":v" + tb.version,
+			Replaces: replaces,
+			Properties: append([]property.Property{
+				property.MustBuildPackage(pkgName, tb.version),
+				property.MustBuildChannel(chName, replaces),
+			}, tb.props...),
+		}
+	}
+	pkg.Channels[chName] = ch
+	if pkg.DefaultChannel == nil {
+		pkg.DefaultChannel = ch
+	}
+}
+
+func buildModel() model.Model {
+	m := model.Model{}
+	addChannel(m, "app", "stable",
+		testBundle{version: "1.0.0"},
+		testBundle{version: "2.0.0", replaces: "1.0.0", props: []property.Property{
+			property.MustBuildPackageRequired("db", ">=1.0.0 <2.0.0"),
+			property.MustBuildGVKRequired("cache.io", "v1", "Cache"),
+			property.MustBuildLabelRequired("monitoring"),
+		}},
+	)
+	addChannel(m, "app", "broken",
+		testBundle{version: "3.0.0", props: []property.Property{
+			property.MustBuildPackageRequired("db", ">=5.0.0"),
+			property.MustBuildPackageRequired("missing", ">=1.0.0"),
+			property.MustBuildGVKRequired("nothing.io", "v1", "Nothing"),
+			property.MustBuildLabelRequired("nowhere"),
+		}},
+	)
+	addChannel(m, "db", "stable",
+		testBundle{version: "1.0.0"},
+		testBundle{version: "1.1.0", replaces: "1.0.0", props: []property.Property{property.MustBuildDeprecated()}},
+		testBundle{version: "2.0.0", replaces: "1.1.0"},
+	)
+	addChannel(m, "db", "legacy",
+		testBundle{version: "1.2.0", props: []property.Property{property.MustBuildGVK("cache.io", "v1", "Cache")}},
+	)
+	// Both packages provide the cache API; "cache-a" is chosen because it
+	// sorts first, unless the API is already provided by a selected bundle.
+	for _, name := range []string{"cache-b", "cache-a"} {
+		addChannel(m, name, "stable",
+			testBundle{version: "1.0.0", props: []property.Property{
+				property.MustBuildGVK("cache.io", "v1", "Cache"),
+				property.MustBuildPackageRequired("db", ">=1.0.0"),
+				property.MustBuildLabel("monitoring"),
+			}},
+		)
+	}
+	return m
+}
+
+func TestResolve(t *testing.T) {
+	res, err := Resolve(buildModel(), Request{Package: "app"})
+	require.NoError(t, err)
+	assert.True(t, res.Satisfied())
+	assert.Equal(t, []Selection{
+		{
+			Package: "app", Channel: "stable", Bundle: "app.v2.0.0", Version: "2.0.0", Image: "app:v2.0.0",
+			Reasons: []string{"requested"},
+		},
+		{
+			Package: "db", Channel: "stable", Bundle: "db.v1.0.0", Version: "1.0.0", Image: "db:v1.0.0",
+			Reasons: []string{
+				`required by "app.v2.0.0": package "db" with version range ">=1.0.0 <2.0.0"`,
+				`required by "cache-a.v1.0.0": package "db" with version range ">=1.0.0"`,
+			},
+		},
+		{
+			Package: "cache-a", Channel: "stable", Bundle: "cache-a.v1.0.0", Version: "1.0.0", Image: "cache-a:v1.0.0",
+			Reasons: []string{
+				`required by "app.v2.0.0": API cache.io/v1, Kind=Cache`,
+				`required by "app.v2.0.0": label "monitoring"`,
+			},
+		},
+	}, res.Bundles)
+}
+
+func TestResolveUnsatisfied(t *testing.T) {
+	res, err := Resolve(buildModel(), Request{Package: "app", Channel: "broken"})
+	require.NoError(t, err)
+	assert.False(t, res.Satisfied())
+	require.Len(t, res.Bundles, 1)
+	assert.Equal(t, []Unsatisfied{
+		{
+			Package:    "app",
+			Bundle:     "app.v3.0.0",
+			Constraint: `package "db" with version range ">=5.0.0"`,
+			Message:    "no bundle that is not deprecated is in range",
+		},
+		{
+			Package:    "app",
+			Bundle:     "app.v3.0.0",
+			Constraint: `package "missing" with version range ">=1.0.0"`,
+			Message:    "package not found",
+		},
+		{
+			Package:    "app",
+			Bundle:     "app.v3.0.0",
+			Constraint: "API nothing.io/v1, Kind=Nothing",
+			Message:    "no default channel head provides it",
+		},
+		{
+			Package:    "app",
+			Bundle:     "app.v3.0.0",
+			Constraint: `label "nowhere"`,
+			Message:    "no default channel head provides it",
+		},
+	}, res.Unsatisfied)
+}
+
+func TestResolveConflict(t *testing.T) {
+	m := buildModel()
+	// The app requires a db version that is only in the legacy channel. It
+	// also provides the cache API, but cache-a is still needed for the label
+	// and requires a db version that conflicts with it.
+	app := m["app"].Channels["stable"].Bundles["app.v2.0.0"]
+	app.Properties[2] = property.MustBuildPackageRequired("db", "1.2.0")
+	cache := m["cache-a"].Channels["stable"].Bundles["cache-a.v1.0.0"]
+	cache.Properties[3] = property.MustBuildPackageRequired("db", ">=2.0.0")
+
+	res, err := Resolve(m, Request{Package: "app"})
+	require.NoError(t, err)
+	assert.False(t, res.Satisfied())
+
+	var names []string
+	for _, s := range res.Bundles {
+		names = append(names, s.Bundle)
+	}
+	assert.Equal(t, []string{"app.v2.0.0", "db.v1.2.0", "cache-a.v1.0.0"}, names)
+	assert.Equal(t, []string{
+		`required by "app.v2.0.0": package "db" with version range "1.2.0"`,
+		`required by "app.v2.0.0": API cache.io/v1, Kind=Cache`,
+	}, res.Bundles[1].Reasons)
+	assert.Equal(t, []Unsatisfied{
+		{
+			Package:    "cache-a",
+			Bundle:     "cache-a.v1.0.0",
+			Constraint: `package "db" with version range ">=2.0.0"`,
+			Message:    `package is already resolved to bundle "db.v1.2.0" with version 1.2.0, which is not in range`,
+		},
+	}, res.Unsatisfied)
+}
+
+func TestResolveInvalidRequest(t *testing.T) {
+	m := buildModel()
+	for _, req := range []Request{
+		{Package: "nope"},
+		{Package: "app", Channel: "nope"},
+		{Package: "app", Bundle: "nope"},
+	} {
+		_, err := Resolve(m, req)
+		assert.Error(t, err, "%+v", req)
+	}
+}
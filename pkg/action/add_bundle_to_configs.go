@@ -16,6 +16,9 @@ import (
 type AddConfigRequest struct {
 	ConfigsDir string
 	Bundles    []BundleExtractor
+	// DigestResolver, if set, pins the images of the bundles to the digests
+	// it resolves, see registry.PinBundleImages.
+	DigestResolver registry.DigestResolver
 }
 
 type BundleAdder struct {
@@ -42,6 +45,14 @@ func (b BundleAdder) AddToConfig(request AddConfigRequest) error {
 
 	for _, bundle := range request.Bundles {
 		b, err := bundle.ExtractBundle(context.TODO())
+		if err != nil {
+			return err
+		}
+		if request.DigestResolver != nil {
+			if err := registry.PinBundleImages(context.TODO(), b, request.DigestResolver); err != nil {
+				return fmt.Errorf("error pinning images of bundle %q: %v", b.BundleImage, err)
+			}
+		}
 		mBundles, err := registry.ConvertRegistryBundleToModelBundles(b)
 		if err != nil {
 			return fmt.Errorf("error creating internal model bundles from registry bundle %q: %v", b.BundleImage, err)
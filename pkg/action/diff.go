@@ -0,0 +1,50 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// Diff compares two catalogs. Each side is rendered from its refs, which
+// may be any source supported by Render, and converted to a normalized model.
+type Diff struct {
+	OldRefs []string
+	NewRefs []string
+
+	Registry image.Registry
+	Logger   *logrus.Entry
+}
+
+type DiffResult struct {
+	// Diff lists the packages, channels and bundles that changed.
+	Diff model.Diff
+
+	// Delta contains the new and changed bundles of the new catalog,
+	// along with their upgrade graph ancestors.
+	Delta declcfg.DeclarativeConfig
+}
+
+func (d Diff) Run(ctx context.Context) (*DiffResult, error) {
+	oldModel, err := Render{Refs: d.OldRefs, Registry: d.Registry, Logger: d.Logger}.RenderModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("render old catalog: %v", err)
+	}
+	newModel, err := Render{Refs: d.NewRefs, Registry: d.Registry, Logger: d.Logger}.RenderModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("render new catalog: %v", err)
+	}
+	delta, err := model.Delta(oldModel, newModel)
+	if err != nil {
+		return nil, fmt.Errorf("compute delta: %v", err)
+	}
+	return &DiffResult{
+		Diff:  model.Compare(oldModel, newModel),
+		Delta: declcfg.ConvertFromModel(delta),
+	}, nil
+}
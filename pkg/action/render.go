@@ -0,0 +1,255 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+// Render converts catalog sources into declarative config. Each ref may be
+// a SQLite index database file, a declarative config directory, a
+// package-manifests directory, an index image of a database or of
+// declarative configs, or a bundle image.
+type Render struct {
+	Refs []string
+
+	// Registry is used to pull image refs. It is only required if one
+	// of the refs is an image.
+	Registry image.Registry
+	Logger   *logrus.Entry
+}
+
+func (r Render) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
+	if r.Logger == nil {
+		r.Logger = logrus.NewEntry(logrus.New())
+	}
+	var cfgs []declcfg.DeclarativeConfig
+	for _, ref := range r.Refs {
+		cfg, err := r.renderRef(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("render reference %q: %v", ref, err)
+		}
+		cfgs = append(cfgs, *cfg)
+	}
+	return combineConfigs(cfgs), nil
+}
+
+// RenderModel renders the refs and converts the result to a validated model.
+func (r Render) RenderModel(ctx context.Context) (model.Model, error) {
+	cfg, err := r.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return declcfg.ConvertToModel(*cfg)
+}
+
+func (r Render) renderRef(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	info, err := os.Stat(ref)
+	switch {
+	case err == nil && info.IsDir():
+		return r.renderDir(ctx, ref)
+	case err == nil:
+		return renderDBFile(ctx, ref)
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	if r.Registry == nil {
+		return nil, fmt.Errorf("no such file or directory, and no image registry is configured to pull it as an image")
+	}
+	return r.renderImage(ctx, ref)
+}
+
+func (r Render) renderDir(ctx context.Context, dir string) (*declcfg.DeclarativeConfig, error) {
+	isManifests, err := isPackageManifestsDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !isManifests {
+		return declcfg.LoadDir(dir)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "render-manifests-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	dbFile := filepath.Join(tmpDir, "index.db")
+	db, err := sqlite.Open(dbFile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	load, err := sqlite.NewSQLLiteLoader(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := load.Migrate(ctx); err != nil {
+		return nil, err
+	}
+	if err := sqlite.NewSQLLoaderForDirectory(load, dir).Populate(); err != nil {
+		return nil, fmt.Errorf("load package manifests: %v", err)
+	}
+	return sqliteToDeclcfg(ctx, sqlite.NewSQLLiteQuerierFromDb(db))
+}
+
+// errFoundPackageManifest stops the walk of isPackageManifestsDir once a
+// package manifest is found.
+var errFoundPackageManifest = errors.New("found package manifest")
+
+// isPackageManifestsDir reports whether dir is in the legacy package
+// manifests format, i.e. contains a *.package.yaml file.
+func isPackageManifestsDir(dir string) (bool, error) {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && (strings.HasSuffix(path, ".package.yaml") || strings.HasSuffix(path, ".package.yml")) {
+			return errFoundPackageManifest
+		}
+		return nil
+	})
+	if err == errFoundPackageManifest {
+		return true, nil
+	}
+	return false, err
+}
+
+// renderDBFile renders an index database. The database is migrated to the
+// latest schema on a copy so that the original file is never modified.
+func renderDBFile(ctx context.Context, dbFile string) (*declcfg.DeclarativeConfig, error) {
+	tmpDir, err := ioutil.TempDir("", "render-db-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	dbCopy := filepath.Join(tmpDir, "index.db")
+	if err := copyFile(dbFile, dbCopy); err != nil {
+		return nil, fmt.Errorf("copy index database: %v", err)
+	}
+
+	db, err := sqlite.Open(dbCopy)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	migrator, err := sqlite.NewSQLLiteMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.Migrate(ctx); err != nil {
+		return nil, fmt.Errorf("migrate index database: %v", err)
+	}
+	return sqliteToDeclcfg(ctx, sqlite.NewSQLLiteQuerierFromDb(db))
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func sqliteToDeclcfg(ctx context.Context, q *sqlite.SQLQuerier) (*declcfg.DeclarativeConfig, error) {
+	m, err := sqlite.ToModel(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("convert index database to model: %v", err)
+	}
+	cfg := declcfg.ConvertFromModel(m)
+	return &cfg, nil
+}
+
+func (r Render) renderImage(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	imageRef := image.SimpleReference(ref)
+	r.Logger.Infof("pulling image %q", ref)
+	if err := r.Registry.Pull(ctx, imageRef); err != nil {
+		return nil, fmt.Errorf("pull image: %v", err)
+	}
+	labels, err := r.Registry.Labels(ctx, imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("get image labels: %v", err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "render-unpack-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := r.Registry.Unpack(ctx, imageRef, tmpDir); err != nil {
+		return nil, fmt.Errorf("unpack image: %v", err)
+	}
+
+	if configsLocation, ok := labels[containertools.ConfigsLocationLabel]; ok {
+		configsDir, err := containertools.JoinLocation(tmpDir, configsLocation)
+		if err != nil {
+			return nil, fmt.Errorf("label %q: %v", containertools.ConfigsLocationLabel, err)
+		}
+		return declcfg.LoadDir(configsDir)
+	}
+	if dbLocation, ok := labels[containertools.DbLocationLabel]; ok {
+		dbFile, err := containertools.JoinLocation(tmpDir, dbLocation)
+		if err != nil {
+			return nil, fmt.Errorf("label %q: %v", containertools.DbLocationLabel, err)
+		}
+		return renderDBFile(ctx, dbFile)
+	}
+	if _, ok := labels[bundle.PackageLabel]; ok {
+		return renderBundle(imageRef, tmpDir)
+	}
+	return nil, fmt.Errorf("image is neither an index image (missing labels %q and %q) nor a bundle image (missing label %q)", containertools.ConfigsLocationLabel, containertools.DbLocationLabel, bundle.PackageLabel)
+}
+
+// renderBundle renders the bundle blobs for an unpacked bundle image. No
+// package blob is rendered because a bundle does not describe its package.
+func renderBundle(ref image.Reference, dir string) (*declcfg.DeclarativeConfig, error) {
+	img, err := registry.NewImageInput(ref, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %v", err)
+	}
+	bundles, err := registry.ConvertRegistryBundleToModelBundles(img.Bundle)
+	if err != nil {
+		return nil, fmt.Errorf("convert bundle: %v", err)
+	}
+	m := model.Model{}
+	for _, b := range bundles {
+		m.AddBundle(b)
+	}
+	cfg := declcfg.ConvertFromModel(m)
+	cfg.Packages = nil
+	return &cfg, nil
+}
+
+func combineConfigs(cfgs []declcfg.DeclarativeConfig) *declcfg.DeclarativeConfig {
+	out := &declcfg.DeclarativeConfig{}
+	for _, in := range cfgs {
+		out.Packages = append(out.Packages, in.Packages...)
+		out.Channels = append(out.Channels, in.Channels...)
+		out.Bundles = append(out.Bundles, in.Bundles...)
+		out.Others = append(out.Others, in.Others...)
+	}
+	return out
+}
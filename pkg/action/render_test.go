@@ -0,0 +1,131 @@
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+func TestRender(t *testing.T) {
+	declcfgDir, err := ioutil.TempDir("", "render_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(declcfgDir)
+	for _, f := range []string{"cockroachdb.json", "etcd.yaml"} {
+		require.NoError(t, copyFile(filepath.Join("../../internal/declcfg/testdata/valid", f), filepath.Join(declcfgDir, f)))
+	}
+
+	type spec struct {
+		name            string
+		refs            []string
+		assertion       require.ErrorAssertionFunc
+		expectPackages  []string
+		expectNumBundle int
+	}
+	specs := []spec{
+		{
+			name:            "SQLiteFile",
+			refs:            []string{"../lib/indexer/testdata/bundles.db"},
+			assertion:       require.NoError,
+			expectPackages:  []string{"etcd", "prometheus", "strimzi-kafka-operator"},
+			expectNumBundle: 10,
+		},
+		{
+			name:            "PackageManifestsDir",
+			refs:            []string{"../../manifests"},
+			assertion:       require.NoError,
+			expectPackages:  []string{"etcd", "prometheus", "strimzi-kafka-operator"},
+			expectNumBundle: 10,
+		},
+		{
+			name:            "DeclarativeConfigDir",
+			refs:            []string{declcfgDir},
+			assertion:       require.NoError,
+			expectPackages:  []string{"cockroachdb", "etcd"},
+			expectNumBundle: 11,
+		},
+		{
+			name:            "NotADatabase",
+			refs:            []string{"../lib/indexer/testdata/bundles.db", "../../internal/declcfg/testdata/valid/cockroachdb.json"},
+			assertion:       require.Error,
+			expectPackages:  nil,
+			expectNumBundle: 0,
+		},
+		{
+			name:      "ImageWithoutRegistry",
+			refs:      []string{"quay.io/operator-framework/does-not-exist:latest"},
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := Render{Refs: s.refs}.Run(context.TODO())
+			s.assertion(t, err)
+			if err != nil {
+				return
+			}
+			var pkgs []string
+			for _, p := range cfg.Packages {
+				pkgs = append(pkgs, p.Name)
+			}
+			require.ElementsMatch(t, s.expectPackages, pkgs)
+			require.Len(t, cfg.Bundles, s.expectNumBundle)
+		})
+	}
+}
+
+func TestRenderConfigsImage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+	rootCAs, err := certs.RootCAs(cafile)
+	require.NoError(t, err)
+	newRegistry := func() *containerdregistry.Registry {
+		cacheDir, err := ioutil.TempDir("", "render_test-cache-")
+		require.NoError(t, err)
+		reg, err := containerdregistry.NewRegistry(containerdregistry.WithCacheDir(cacheDir), containerdregistry.WithRootCAs(rootCAs))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, reg.Destroy()) })
+		return reg
+	}
+
+	var layer bytes.Buffer
+	tw := tar.NewWriter(&layer)
+	for _, f := range []string{"cockroachdb.json", "etcd.yaml"} {
+		data, err := ioutil.ReadFile(filepath.Join("../../internal/declcfg/testdata/valid", f))
+		require.NoError(t, err)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "configs/" + f, Mode: 0644, Size: int64(len(data))}))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	ref := image.SimpleReference(host + "/test/configs:v1")
+	reg := newRegistry()
+	require.NoError(t, reg.Pack(ctx, nil, ref, &layer, containerdregistry.WithLabels(map[string]string{
+		containertools.ConfigsLocationLabel: containertools.DefaultConfigsLocation,
+	})))
+	require.NoError(t, reg.Push(ctx, ref))
+
+	cfg, err := Render{Refs: []string{ref.String()}, Registry: newRegistry()}.Run(ctx)
+	require.NoError(t, err)
+	var pkgs []string
+	for _, p := range cfg.Packages {
+		pkgs = append(pkgs, p.Name)
+	}
+	require.ElementsMatch(t, []string{"cockroachdb", "etcd"}, pkgs)
+	require.Len(t, cfg.Bundles, 11)
+}
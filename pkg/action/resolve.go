@@ -0,0 +1,29 @@
+package action
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/internal/resolve"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// Resolve computes the bundles that OLM would need to install to satisfy a
+// request for a bundle of a catalog. The catalog is rendered from Refs,
+// which may be any source supported by Render.
+type Resolve struct {
+	Refs    []string
+	Request resolve.Request
+
+	Registry image.Registry
+	Logger   *logrus.Entry
+}
+
+func (r Resolve) Run(ctx context.Context) (*resolve.Resolution, error) {
+	m, err := Render{Refs: r.Refs, Registry: r.Registry, Logger: r.Logger}.RenderModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resolve.Resolve(m, r.Request)
+}
@@ -29,6 +29,7 @@ func ConvertAPIBundleToModelBundle(b *Bundle) (*model.Bundle, error) {
 		Image:         b.BundlePath,
 		Replaces:      b.Replaces,
 		Skips:         b.Skips,
+		SkipRange:     b.SkipRange,
 		CsvJSON:       b.CsvJson,
 		Objects:       b.Object,
 		Properties:    bundleProps,
@@ -91,6 +92,11 @@ func convertAPIBundleToModelProperties(b *Bundle) ([]property.Property, error) {
 				return nil, property.ParseError{Idx: i, Typ: p.Type, Err: err}
 			}
 			out = append(out, property.MustBuildPackageRequired(v.PackageName, v.Version))
+		case property.TypeLabel:
+			out = append(out, property.Property{
+				Type:  property.TypeLabelRequired,
+				Value: json.RawMessage(p.Value),
+			})
 		}
 	}
 
@@ -13,8 +13,8 @@ func ConvertModelBundleToAPIBundle(b model.Bundle) (*Bundle, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse properties: %v", err)
 	}
-	skipRange := ""
-	if len(props.SkipRanges) > 0 {
+	skipRange := b.SkipRange
+	if skipRange == "" && len(props.SkipRanges) > 0 {
 		skipRange = string(props.SkipRanges[0])
 	}
 
@@ -121,6 +121,11 @@ func convertModelPropertiesToAPIDependencies(props []property.Property) ([]*Depe
 				Type:  pkg.Type,
 				Value: string(pkg.Value),
 			})
+		case property.TypeLabelRequired:
+			out = append(out, &Dependency{
+				Type:  property.TypeLabel,
+				Value: string(prop.Value),
+			})
 		}
 	}
 	return out, nil
@@ -28,6 +28,11 @@ type RegistryClient interface {
 	GetLatestChannelEntriesThatProvide(ctx context.Context, in *GetLatestProvidersRequest, opts ...grpc.CallOption) (Registry_GetLatestChannelEntriesThatProvideClient, error)
 	GetDefaultBundleThatProvides(ctx context.Context, in *GetDefaultProviderRequest, opts ...grpc.CallOption) (*Bundle, error)
 	ListBundles(ctx context.Context, in *ListBundlesRequest, opts ...grpc.CallOption) (Registry_ListBundlesClient, error)
+	ListBundlesPage(ctx context.Context, in *ListBundlesPageRequest, opts ...grpc.CallOption) (*ListBundlesPageResponse, error)
+	WatchCatalog(ctx context.Context, in *WatchCatalogRequest, opts ...grpc.CallOption) (Registry_WatchCatalogClient, error)
+	GetPackageGraph(ctx context.Context, in *GetPackageGraphRequest, opts ...grpc.CallOption) (*PackageGraph, error)
+	ListBundlesWithProperty(ctx context.Context, in *ListBundlesWithPropertyRequest, opts ...grpc.CallOption) (Registry_ListBundlesWithPropertyClient, error)
+	GetDeprecations(ctx context.Context, in *GetDeprecationsRequest, opts ...grpc.CallOption) (Registry_GetDeprecationsClient, error)
 }
 
 type registryClient struct {
@@ -243,6 +248,120 @@ func (x *registryListBundlesClient) Recv() (*Bundle, error) {
 	return m, nil
 }
 
+func (c *registryClient) ListBundlesPage(ctx context.Context, in *ListBundlesPageRequest, opts ...grpc.CallOption) (*ListBundlesPageResponse, error) {
+	out := new(ListBundlesPageResponse)
+	err := c.cc.Invoke(ctx, "/api.Registry/ListBundlesPage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) WatchCatalog(ctx context.Context, in *WatchCatalogRequest, opts ...grpc.CallOption) (Registry_WatchCatalogClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Registry_serviceDesc.Streams[5], "/api.Registry/WatchCatalog", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryWatchCatalogClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Registry_WatchCatalogClient interface {
+	Recv() (*CatalogEvent, error)
+	grpc.ClientStream
+}
+
+type registryWatchCatalogClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryWatchCatalogClient) Recv() (*CatalogEvent, error) {
+	m := new(CatalogEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *registryClient) GetPackageGraph(ctx context.Context, in *GetPackageGraphRequest, opts ...grpc.CallOption) (*PackageGraph, error) {
+	out := new(PackageGraph)
+	err := c.cc.Invoke(ctx, "/api.Registry/GetPackageGraph", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) ListBundlesWithProperty(ctx context.Context, in *ListBundlesWithPropertyRequest, opts ...grpc.CallOption) (Registry_ListBundlesWithPropertyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Registry_serviceDesc.Streams[6], "/api.Registry/ListBundlesWithProperty", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryListBundlesWithPropertyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Registry_ListBundlesWithPropertyClient interface {
+	Recv() (*Bundle, error)
+	grpc.ClientStream
+}
+
+type registryListBundlesWithPropertyClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryListBundlesWithPropertyClient) Recv() (*Bundle, error) {
+	m := new(Bundle)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *registryClient) GetDeprecations(ctx context.Context, in *GetDeprecationsRequest, opts ...grpc.CallOption) (Registry_GetDeprecationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Registry_serviceDesc.Streams[7], "/api.Registry/GetDeprecations", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryGetDeprecationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Registry_GetDeprecationsClient interface {
+	Recv() (*Deprecation, error)
+	grpc.ClientStream
+}
+
+type registryGetDeprecationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryGetDeprecationsClient) Recv() (*Deprecation, error) {
+	m := new(Deprecation)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // RegistryServer is the server API for Registry service.
 // All implementations must embed UnimplementedRegistryServer
 // for forward compatibility
@@ -257,6 +376,11 @@ type RegistryServer interface {
 	GetLatestChannelEntriesThatProvide(*GetLatestProvidersRequest, Registry_GetLatestChannelEntriesThatProvideServer) error
 	GetDefaultBundleThatProvides(context.Context, *GetDefaultProviderRequest) (*Bundle, error)
 	ListBundles(*ListBundlesRequest, Registry_ListBundlesServer) error
+	ListBundlesPage(context.Context, *ListBundlesPageRequest) (*ListBundlesPageResponse, error)
+	WatchCatalog(*WatchCatalogRequest, Registry_WatchCatalogServer) error
+	GetPackageGraph(context.Context, *GetPackageGraphRequest) (*PackageGraph, error)
+	ListBundlesWithProperty(*ListBundlesWithPropertyRequest, Registry_ListBundlesWithPropertyServer) error
+	GetDeprecations(*GetDeprecationsRequest, Registry_GetDeprecationsServer) error
 	mustEmbedUnimplementedRegistryServer()
 }
 
@@ -294,6 +418,21 @@ func (*UnimplementedRegistryServer) GetDefaultBundleThatProvides(context.Context
 func (*UnimplementedRegistryServer) ListBundles(*ListBundlesRequest, Registry_ListBundlesServer) error {
 	return status.Errorf(codes.Unimplemented, "method ListBundles not implemented")
 }
+func (*UnimplementedRegistryServer) ListBundlesPage(context.Context, *ListBundlesPageRequest) (*ListBundlesPageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBundlesPage not implemented")
+}
+func (*UnimplementedRegistryServer) WatchCatalog(*WatchCatalogRequest, Registry_WatchCatalogServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCatalog not implemented")
+}
+func (*UnimplementedRegistryServer) GetPackageGraph(context.Context, *GetPackageGraphRequest) (*PackageGraph, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPackageGraph not implemented")
+}
+func (*UnimplementedRegistryServer) ListBundlesWithProperty(*ListBundlesWithPropertyRequest, Registry_ListBundlesWithPropertyServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListBundlesWithProperty not implemented")
+}
+func (*UnimplementedRegistryServer) GetDeprecations(*GetDeprecationsRequest, Registry_GetDeprecationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetDeprecations not implemented")
+}
 func (*UnimplementedRegistryServer) mustEmbedUnimplementedRegistryServer() {}
 
 func RegisterRegistryServer(s *grpc.Server, srv RegistryServer) {
@@ -495,6 +634,105 @@ func (x *registryListBundlesServer) Send(m *Bundle) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Registry_ListBundlesPage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBundlesPageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).ListBundlesPage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Registry/ListBundlesPage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).ListBundlesPage(ctx, req.(*ListBundlesPageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_WatchCatalog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCatalogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).WatchCatalog(m, &registryWatchCatalogServer{stream})
+}
+
+type Registry_WatchCatalogServer interface {
+	Send(*CatalogEvent) error
+	grpc.ServerStream
+}
+
+type registryWatchCatalogServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryWatchCatalogServer) Send(m *CatalogEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Registry_GetPackageGraph_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPackageGraphRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetPackageGraph(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Registry/GetPackageGraph",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetPackageGraph(ctx, req.(*GetPackageGraphRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_ListBundlesWithProperty_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListBundlesWithPropertyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).ListBundlesWithProperty(m, &registryListBundlesWithPropertyServer{stream})
+}
+
+type Registry_ListBundlesWithPropertyServer interface {
+	Send(*Bundle) error
+	grpc.ServerStream
+}
+
+type registryListBundlesWithPropertyServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryListBundlesWithPropertyServer) Send(m *Bundle) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Registry_GetDeprecations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetDeprecationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).GetDeprecations(m, &registryGetDeprecationsServer{stream})
+}
+
+type Registry_GetDeprecationsServer interface {
+	Send(*Deprecation) error
+	grpc.ServerStream
+}
+
+type registryGetDeprecationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryGetDeprecationsServer) Send(m *Deprecation) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Registry_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "api.Registry",
 	HandlerType: (*RegistryServer)(nil),
@@ -519,6 +757,14 @@ var _Registry_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetDefaultBundleThatProvides",
 			Handler:    _Registry_GetDefaultBundleThatProvides_Handler,
 		},
+		{
+			MethodName: "ListBundlesPage",
+			Handler:    _Registry_ListBundlesPage_Handler,
+		},
+		{
+			MethodName: "GetPackageGraph",
+			Handler:    _Registry_GetPackageGraph_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -546,6 +792,21 @@ var _Registry_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Registry_ListBundles_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchCatalog",
+			Handler:       _Registry_WatchCatalog_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListBundlesWithProperty",
+			Handler:       _Registry_ListBundlesWithProperty_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetDeprecations",
+			Handler:       _Registry_GetDeprecations_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "registry.proto",
 }
@@ -18,7 +18,13 @@ type Interface interface {
 	GetReplacementBundleInPackageChannel(ctx context.Context, currentName, packageName, channelName string) (*api.Bundle, error)
 	GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error)
 	ListBundles(ctx context.Context) (*BundleIterator, error)
+	ListFilteredBundles(ctx context.Context, req *api.ListBundlesRequest) (*BundleIterator, error)
+	ListBundlesInPages(ctx context.Context, req *api.ListBundlesRequest, pageSize int32) (*BundleIterator, error)
 	GetPackage(ctx context.Context, packageName string) (*api.Package, error)
+	GetPackageGraph(ctx context.Context, packageName string) (*api.PackageGraph, error)
+	ListBundlesWithProperty(ctx context.Context, propertyType, value string) (*BundleIterator, error)
+	GetDeprecations(ctx context.Context, packageName string) ([]*api.Deprecation, error)
+	WatchCatalog(ctx context.Context, includeSnapshot bool) (*CatalogEventIterator, error)
 	HealthCheck(ctx context.Context, reconnectTimeout time.Duration) (bool, error)
 	Close() error
 }
@@ -62,6 +68,91 @@ func (it *BundleIterator) Error() error {
 	return it.error
 }
 
+// bundlePageStream is a BundleStream that requests pages of bundles as they
+// are received.
+type bundlePageStream struct {
+	ctx      context.Context
+	registry api.RegistryClient
+	req      *api.ListBundlesPageRequest
+	bundles  []*api.Bundle
+	done     bool
+}
+
+func (s *bundlePageStream) fetch() error {
+	res, err := s.registry.ListBundlesPage(s.ctx, s.req)
+	if err != nil {
+		return err
+	}
+	s.bundles = res.GetBundles()
+	s.req.PageToken = res.GetNextPageToken()
+	s.done = s.req.PageToken == ""
+	return nil
+}
+
+func (s *bundlePageStream) Recv() (*api.Bundle, error) {
+	for len(s.bundles) == 0 {
+		if s.done {
+			return nil, io.EOF
+		}
+		if err := s.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	next := s.bundles[0]
+	s.bundles = s.bundles[1:]
+	return next, nil
+}
+
+// BundleFieldsWithoutManifests returns a field mask for ListBundlesRequest
+// that omits the csvJson and object fields, which hold the manifests of each
+// bundle and make up most of its size.
+func BundleFieldsWithoutManifests() []string {
+	var mask []string
+	fields := (&api.Bundle{}).ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if name := string(fields.Get(i).Name()); name != "csvJson" && name != "object" {
+			mask = append(mask, name)
+		}
+	}
+	return mask
+}
+
+type CatalogEventStream interface {
+	Recv() (*api.CatalogEvent, error)
+}
+
+// CatalogEventIterator iterates over the events of a catalog watch. The
+// first event is always a SNAPSHOT event carrying the revision that the
+// following events apply to.
+type CatalogEventIterator struct {
+	stream CatalogEventStream
+	error  error
+}
+
+func NewCatalogEventIterator(stream CatalogEventStream) *CatalogEventIterator {
+	return &CatalogEventIterator{stream: stream}
+}
+
+// Next blocks until the next event is received. It returns nil when the
+// watch ends, in which case Error reports why.
+func (it *CatalogEventIterator) Next() *api.CatalogEvent {
+	if it.error != nil {
+		return nil
+	}
+	next, err := it.stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		it.error = err
+	}
+	return next
+}
+
+func (it *CatalogEventIterator) Error() error {
+	return it.error
+}
+
 func (c *Client) GetBundle(ctx context.Context, packageName, channelName, csvName string) (*api.Bundle, error) {
 	return c.Registry.GetBundle(ctx, &api.GetBundleRequest{PkgName: packageName, ChannelName: channelName, CsvName: csvName})
 }
@@ -86,10 +177,82 @@ func (c *Client) ListBundles(ctx context.Context) (*BundleIterator, error) {
 	return NewBundleIterator(stream), nil
 }
 
+// ListFilteredBundles streams the bundles that match the filters of req,
+// with only the fields in its field mask.
+func (c *Client) ListFilteredBundles(ctx context.Context, req *api.ListBundlesRequest) (*BundleIterator, error) {
+	stream, err := c.Registry.ListBundles(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return NewBundleIterator(stream), nil
+}
+
+// ListBundlesInPages is like ListFilteredBundles, but requests the bundles in
+// pages of up to pageSize bundles instead of a single stream. The server
+// chooses the page size if it is zero. The first page is requested before
+// returning; the rest are requested as the iterator reaches them.
+func (c *Client) ListBundlesInPages(ctx context.Context, req *api.ListBundlesRequest, pageSize int32) (*BundleIterator, error) {
+	stream := &bundlePageStream{
+		ctx:      ctx,
+		registry: c.Registry,
+		req:      &api.ListBundlesPageRequest{Filter: req, PageSize: pageSize},
+	}
+	if err := stream.fetch(); err != nil {
+		return nil, err
+	}
+	return NewBundleIterator(stream), nil
+}
+
 func (c *Client) GetPackage(ctx context.Context, packageName string) (*api.Package, error) {
 	return c.Registry.GetPackage(ctx, &api.GetPackageRequest{Name: packageName})
 }
 
+// GetPackageGraph returns the upgrade graph of every channel in a package.
+func (c *Client) GetPackageGraph(ctx context.Context, packageName string) (*api.PackageGraph, error) {
+	return c.Registry.GetPackageGraph(ctx, &api.GetPackageGraphRequest{PkgName: packageName})
+}
+
+// ListBundlesWithProperty streams the bundles that have a property of the
+// given type and, if value is set, an equivalent JSON value.
+func (c *Client) ListBundlesWithProperty(ctx context.Context, propertyType, value string) (*BundleIterator, error) {
+	stream, err := c.Registry.ListBundlesWithProperty(ctx, &api.ListBundlesWithPropertyRequest{Type: propertyType, Value: value})
+	if err != nil {
+		return nil, err
+	}
+	return NewBundleIterator(stream), nil
+}
+
+// GetDeprecations returns the deprecated bundles of a package, or of every
+// package if packageName is empty.
+func (c *Client) GetDeprecations(ctx context.Context, packageName string) ([]*api.Deprecation, error) {
+	stream, err := c.Registry.GetDeprecations(ctx, &api.GetDeprecationsRequest{PkgName: packageName})
+	if err != nil {
+		return nil, err
+	}
+	var deprecations []*api.Deprecation
+	for {
+		d, err := stream.Recv()
+		if err == io.EOF {
+			return deprecations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		deprecations = append(deprecations, d)
+	}
+}
+
+// WatchCatalog watches the catalog for changes to its packages and bundles.
+// If includeSnapshot is set, the full contents of the catalog are sent as
+// ADDED events before any changes. The watch ends when ctx is cancelled.
+func (c *Client) WatchCatalog(ctx context.Context, includeSnapshot bool) (*CatalogEventIterator, error) {
+	stream, err := c.Registry.WatchCatalog(ctx, &api.WatchCatalogRequest{IncludeSnapshot: includeSnapshot})
+	if err != nil {
+		return nil, err
+	}
+	return NewCatalogEventIterator(stream), nil
+}
+
 func (c *Client) Close() error {
 	if c.Conn == nil {
 		return nil
@@ -115,8 +278,18 @@ func (c *Client) HealthCheck(ctx context.Context, reconnectTimeout time.Duration
 	return true, nil
 }
 
-func NewClient(address string) (*Client, error) {
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
+// NewClient connects to the registry server at address. The connection is
+// insecure unless TLS is configured with WithTLS or WithClientCertificate.
+func NewClient(address string, opts ...ClientOption) (*Client, error) {
+	config := &clientConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	creds, err := config.transportCredentials()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(address, append([]grpc.DialOption{creds}, config.dialOptions...)...)
 	if err != nil {
 		return nil, err
 	}
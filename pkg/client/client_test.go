@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
@@ -11,13 +12,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
 type RegistryClientStub struct {
-	ListBundlesClient api.Registry_ListBundlesClient
-	PackageName       string
-	Package           *api.Package
-	Error             error
+	ListBundlesClient     api.Registry_ListBundlesClient
+	WatchCatalogClient    api.Registry_WatchCatalogClient
+	GetDeprecationsClient api.Registry_GetDeprecationsClient
+	Pages                 map[string]*api.ListBundlesPageResponse
+	PageRequests          []*api.ListBundlesPageRequest
+	PackageName           string
+	Package               *api.Package
+	Error                 error
 }
 
 func (s *RegistryClientStub) ListPackages(ctx context.Context, in *api.ListPackageRequest, opts ...grpc.CallOption) (api.Registry_ListPackagesClient, error) {
@@ -61,6 +67,34 @@ func (s *RegistryClientStub) ListBundles(ctx context.Context, in *api.ListBundle
 	return s.ListBundlesClient, s.Error
 }
 
+func (s *RegistryClientStub) ListBundlesPage(ctx context.Context, in *api.ListBundlesPageRequest, opts ...grpc.CallOption) (*api.ListBundlesPageResponse, error) {
+	s.PageRequests = append(s.PageRequests, proto.Clone(in).(*api.ListBundlesPageRequest))
+	if s.Error != nil {
+		return nil, s.Error
+	}
+	page, ok := s.Pages[in.GetPageToken()]
+	if !ok {
+		return nil, errors.New("unknown page")
+	}
+	return page, nil
+}
+
+func (s *RegistryClientStub) WatchCatalog(ctx context.Context, in *api.WatchCatalogRequest, opts ...grpc.CallOption) (api.Registry_WatchCatalogClient, error) {
+	return s.WatchCatalogClient, s.Error
+}
+
+func (s *RegistryClientStub) GetPackageGraph(ctx context.Context, in *api.GetPackageGraphRequest, opts ...grpc.CallOption) (*api.PackageGraph, error) {
+	return nil, nil
+}
+
+func (s *RegistryClientStub) ListBundlesWithProperty(ctx context.Context, in *api.ListBundlesWithPropertyRequest, opts ...grpc.CallOption) (api.Registry_ListBundlesWithPropertyClient, error) {
+	return s.ListBundlesClient, s.Error
+}
+
+func (s *RegistryClientStub) GetDeprecations(ctx context.Context, in *api.GetDeprecationsRequest, opts ...grpc.CallOption) (api.Registry_GetDeprecationsClient, error) {
+	return s.GetDeprecationsClient, s.Error
+}
+
 func (s *RegistryClientStub) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest, opts ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
 	return nil, nil
 }
@@ -130,6 +164,129 @@ func TestListBundlesNext(t *testing.T) {
 	require.Equal(t, expected, actual)
 }
 
+func TestListBundlesInPages(t *testing.T) {
+	stub := &RegistryClientStub{
+		Pages: map[string]*api.ListBundlesPageResponse{
+			"":       {Bundles: []*api.Bundle{{CsvName: "a"}, {CsvName: "b"}}, NextPageToken: "second"},
+			"second": {NextPageToken: "third"},
+			"third":  {Bundles: []*api.Bundle{{CsvName: "c"}}},
+		},
+	}
+	c := Client{Registry: stub, Health: stub}
+
+	req := &api.ListBundlesRequest{PkgName: "pkg", FieldMask: BundleFieldsWithoutManifests()}
+	it, err := c.ListBundlesInPages(context.TODO(), req, 2)
+	require.NoError(t, err)
+
+	var names []string
+	for b := it.Next(); b != nil; b = it.Next() {
+		names = append(names, b.CsvName)
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, []string{"a", "b", "c"}, names)
+
+	require.Len(t, stub.PageRequests, 3)
+	for i, token := range []string{"", "second", "third"} {
+		require.Equal(t, token, stub.PageRequests[i].PageToken)
+		require.Equal(t, int32(2), stub.PageRequests[i].PageSize)
+		require.True(t, proto.Equal(req, stub.PageRequests[i].Filter))
+	}
+	require.NotContains(t, req.FieldMask, "csvJson")
+	require.NotContains(t, req.FieldMask, "object")
+	require.Contains(t, req.FieldMask, "csvName")
+}
+
+func TestListBundlesInPagesError(t *testing.T) {
+	expected := errors.New("test error")
+	stub := &RegistryClientStub{Error: expected}
+	c := Client{Registry: stub, Health: stub}
+
+	_, err := c.ListBundlesInPages(context.TODO(), &api.ListBundlesRequest{}, 0)
+	require.Equal(t, expected, err)
+}
+
+type DeprecationReceiverStub struct {
+	Deprecations []*api.Deprecation
+	Error        error
+	grpc.ClientStream
+}
+
+func (s *DeprecationReceiverStub) Recv() (*api.Deprecation, error) {
+	if len(s.Deprecations) == 0 {
+		return nil, s.Error
+	}
+	d := s.Deprecations[0]
+	s.Deprecations = s.Deprecations[1:]
+	return d, nil
+}
+
+func TestGetDeprecations(t *testing.T) {
+	expected := []*api.Deprecation{
+		{PackageName: "etcd", CsvName: "etcdoperator.v0.6.1", Channels: []string{"alpha", "stable"}},
+		{PackageName: "etcd", CsvName: "etcdoperator.v0.9.0", Channels: []string{"alpha"}},
+	}
+	stub := &RegistryClientStub{
+		GetDeprecationsClient: &DeprecationReceiverStub{
+			Deprecations: append([]*api.Deprecation{}, expected...),
+			Error:        io.EOF,
+		},
+	}
+	c := Client{Registry: stub, Health: stub}
+
+	actual, err := c.GetDeprecations(context.TODO(), "etcd")
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	recvErr := errors.New("test error")
+	stub.GetDeprecationsClient = &DeprecationReceiverStub{Deprecations: expected, Error: recvErr}
+	_, err = c.GetDeprecations(context.TODO(), "etcd")
+	require.Equal(t, recvErr, err)
+}
+
+type CatalogEventReceiverStub struct {
+	Events []*api.CatalogEvent
+	Error  error
+	grpc.ClientStream
+}
+
+func (s *CatalogEventReceiverStub) Recv() (*api.CatalogEvent, error) {
+	if len(s.Events) == 0 {
+		return nil, s.Error
+	}
+	e := s.Events[0]
+	s.Events = s.Events[1:]
+	return e, nil
+}
+
+func TestWatchCatalogNext(t *testing.T) {
+	expected := []*api.CatalogEvent{
+		{Type: api.CatalogEvent_SNAPSHOT, Revision: "a"},
+		{Type: api.CatalogEvent_ADDED, Revision: "b", Bundle: &api.Bundle{CsvName: "test"}},
+	}
+	recvErr := errors.New("test error")
+	rstub := &CatalogEventReceiverStub{
+		Events: append([]*api.CatalogEvent{}, expected...),
+		Error:  recvErr,
+	}
+	cstub := &RegistryClientStub{
+		WatchCatalogClient: rstub,
+	}
+	c := Client{
+		Registry: cstub,
+		Health:   cstub,
+	}
+
+	it, err := c.WatchCatalog(context.TODO(), false)
+	require.NoError(t, err)
+
+	var actual []*api.CatalogEvent
+	for e := it.Next(); e != nil; e = it.Next() {
+		actual = append(actual, e)
+	}
+	require.Equal(t, expected, actual)
+	require.Equal(t, recvErr, it.Error())
+}
+
 func TestGetPackage(t *testing.T) {
 	for _, tt := range []struct {
 		Name        string
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+)
+
+type clientConfig struct {
+	tls         bool
+	rootCAs     *x509.CertPool
+	certFile    string
+	keyFile     string
+	dialOptions []grpc.DialOption
+}
+
+// ClientOption configures how NewClient connects to a registry server.
+type ClientOption func(*clientConfig)
+
+// WithTLS connects to the server with TLS, verifying its certificate against
+// rootCAs, or against the system roots if rootCAs is nil.
+func WithTLS(rootCAs *x509.CertPool) ClientOption {
+	return func(c *clientConfig) {
+		c.tls = true
+		c.rootCAs = rootCAs
+	}
+}
+
+// WithClientCertificate presents the certificate and key in certFile and
+// keyFile to servers that require client certificates. The files are
+// reloaded when they change. It implies WithTLS(nil) unless WithTLS is also
+// used.
+func WithClientCertificate(certFile, keyFile string) ClientOption {
+	return func(c *clientConfig) {
+		c.tls = true
+		c.certFile = certFile
+		c.keyFile = keyFile
+	}
+}
+
+// WithDialOptions adds options to the dial of the server connection.
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *clientConfig) {
+		c.dialOptions = append(c.dialOptions, opts...)
+	}
+}
+
+func (c *clientConfig) transportCredentials() (grpc.DialOption, error) {
+	if !c.tls {
+		return grpc.WithInsecure(), nil
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    c.rootCAs,
+	}
+	if c.certFile != "" || c.keyFile != "" {
+		kp, err := certs.NewKeyPairReloader(c.certFile, c.keyFile, logrus.NewEntry(logrus.StandardLogger()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		config.GetClientCertificate = kp.GetClientCertificate
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/server"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *testCA) writeCA(t *testing.T, file string) {
+	require.NoError(t, ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600))
+}
+
+// issue writes a certificate signed by ca for 127.0.0.1, and its key, to
+// certFile and keyFile.
+func (ca *testCA) issue(t *testing.T, usage x509.ExtKeyUsage, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+func serveHealth(t *testing.T, opts []grpc.ServerOption) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(opts...)
+	grpc_health_v1.RegisterHealthServer(s, server.NewHealthServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func checkHealth(t *testing.T, address string, opts ...ClientOption) error {
+	c, err := NewClient(address, opts...)
+	require.NoError(t, err)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.HealthCheck(ctx, time.Second)
+	return err
+}
+
+func TestNewClientTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client_tls_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	ca := newTestCA(t)
+	ca.writeCA(t, path("ca.crt"))
+	ca.issue(t, x509.ExtKeyUsageServerAuth, path("server.crt"), path("server.key"))
+	ca.issue(t, x509.ExtKeyUsageClientAuth, path("client.crt"), path("client.key"))
+
+	logger := logrus.New()
+	_, err = server.TLSServerOptions("", path("server.key"), "", logger)
+	require.Error(t, err)
+	_, err = server.TLSServerOptions("", "", path("ca.crt"), logger)
+	require.Error(t, err)
+	opts, err := server.TLSServerOptions("", "", "", logger)
+	require.NoError(t, err)
+	require.Empty(t, opts)
+
+	t.Run("TLS", func(t *testing.T) {
+		opts, err := server.TLSServerOptions(path("server.crt"), path("server.key"), "", logger)
+		require.NoError(t, err)
+		address := serveHealth(t, opts)
+
+		require.NoError(t, checkHealth(t, address, WithTLS(ca.pool())))
+		require.Error(t, checkHealth(t, address, WithTLS(newTestCA(t).pool())))
+		require.Error(t, checkHealth(t, address))
+	})
+
+	t.Run("MutualTLS", func(t *testing.T) {
+		opts, err := server.TLSServerOptions(path("server.crt"), path("server.key"), path("ca.crt"), logger)
+		require.NoError(t, err)
+		address := serveHealth(t, opts)
+
+		require.NoError(t, checkHealth(t, address, WithTLS(ca.pool()), WithClientCertificate(path("client.crt"), path("client.key"))))
+		require.Error(t, checkHealth(t, address, WithTLS(ca.pool())))
+
+		// Clients of a new CA are accepted once the server's client CA file
+		// is replaced with it.
+		other := newTestCA(t)
+		other.issue(t, x509.ExtKeyUsageClientAuth, path("other.crt"), path("other.key"))
+		require.Error(t, checkHealth(t, address, WithTLS(ca.pool()), WithClientCertificate(path("other.crt"), path("other.key"))))
+		other.writeCA(t, path("ca.crt"))
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(path("ca.crt"), later, later))
+		require.NoError(t, checkHealth(t, address, WithTLS(ca.pool()), WithClientCertificate(path("other.crt"), path("other.key"))))
+	})
+
+	_, err = NewClient("127.0.0.1:0", WithClientCertificate(path("missing.crt"), path("missing.key")))
+	require.Error(t, err)
+}
@@ -8,6 +8,18 @@ import (
 )
 
 type FakeDockerfileGenerator struct {
+	GenerateConfigsDockerfileStub        func(string, string) string
+	generateConfigsDockerfileMutex       sync.RWMutex
+	generateConfigsDockerfileArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	generateConfigsDockerfileReturns struct {
+		result1 string
+	}
+	generateConfigsDockerfileReturnsOnCall map[int]struct {
+		result1 string
+	}
 	GenerateIndexDockerfileStub        func(string, string) string
 	generateIndexDockerfileMutex       sync.RWMutex
 	generateIndexDockerfileArgsForCall []struct {
@@ -24,6 +36,67 @@ type FakeDockerfileGenerator struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeDockerfileGenerator) GenerateConfigsDockerfile(arg1 string, arg2 string) string {
+	fake.generateConfigsDockerfileMutex.Lock()
+	ret, specificReturn := fake.generateConfigsDockerfileReturnsOnCall[len(fake.generateConfigsDockerfileArgsForCall)]
+	fake.generateConfigsDockerfileArgsForCall = append(fake.generateConfigsDockerfileArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GenerateConfigsDockerfile", []interface{}{arg1, arg2})
+	fake.generateConfigsDockerfileMutex.Unlock()
+	if fake.GenerateConfigsDockerfileStub != nil {
+		return fake.GenerateConfigsDockerfileStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.generateConfigsDockerfileReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDockerfileGenerator) GenerateConfigsDockerfileCallCount() int {
+	fake.generateConfigsDockerfileMutex.RLock()
+	defer fake.generateConfigsDockerfileMutex.RUnlock()
+	return len(fake.generateConfigsDockerfileArgsForCall)
+}
+
+func (fake *FakeDockerfileGenerator) GenerateConfigsDockerfileCalls(stub func(string, string) string) {
+	fake.generateConfigsDockerfileMutex.Lock()
+	defer fake.generateConfigsDockerfileMutex.Unlock()
+	fake.GenerateConfigsDockerfileStub = stub
+}
+
+func (fake *FakeDockerfileGenerator) GenerateConfigsDockerfileArgsForCall(i int) (string, string) {
+	fake.generateConfigsDockerfileMutex.RLock()
+	defer fake.generateConfigsDockerfileMutex.RUnlock()
+	argsForCall := fake.generateConfigsDockerfileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDockerfileGenerator) GenerateConfigsDockerfileReturns(result1 string) {
+	fake.generateConfigsDockerfileMutex.Lock()
+	defer fake.generateConfigsDockerfileMutex.Unlock()
+	fake.GenerateConfigsDockerfileStub = nil
+	fake.generateConfigsDockerfileReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeDockerfileGenerator) GenerateConfigsDockerfileReturnsOnCall(i int, result1 string) {
+	fake.generateConfigsDockerfileMutex.Lock()
+	defer fake.generateConfigsDockerfileMutex.Unlock()
+	fake.GenerateConfigsDockerfileStub = nil
+	if fake.generateConfigsDockerfileReturnsOnCall == nil {
+		fake.generateConfigsDockerfileReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.generateConfigsDockerfileReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeDockerfileGenerator) GenerateIndexDockerfile(arg1 string, arg2 string) string {
 	fake.generateIndexDockerfileMutex.Lock()
 	ret, specificReturn := fake.generateIndexDockerfileReturnsOnCall[len(fake.generateIndexDockerfileArgsForCall)]
@@ -88,6 +161,8 @@ func (fake *FakeDockerfileGenerator) GenerateIndexDockerfileReturnsOnCall(i int,
 func (fake *FakeDockerfileGenerator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.generateConfigsDockerfileMutex.RLock()
+	defer fake.generateConfigsDockerfileMutex.RUnlock()
 	fake.generateIndexDockerfileMutex.RLock()
 	defer fake.generateIndexDockerfileMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
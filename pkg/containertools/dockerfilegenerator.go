@@ -8,14 +8,17 @@ import (
 )
 
 const (
-	defaultBinarySourceImage = "quay.io/operator-framework/upstream-opm-builder"
+	DefaultBinarySourceImage = "quay.io/operator-framework/upstream-opm-builder"
 	DefaultDbLocation        = "/database/index.db"
 	DbLocationLabel          = "operators.operatorframework.io.index.database.v1"
+	DefaultConfigsLocation   = "/configs"
+	ConfigsLocationLabel     = "operators.operatorframework.io.index.configs.v1"
 )
 
 // DockerfileGenerator defines functions to generate index dockerfiles
 type DockerfileGenerator interface {
 	GenerateIndexDockerfile(string, string) string
+	GenerateConfigsDockerfile(string, string) string
 }
 
 // IndexDockerfileGenerator struct implementation of DockerfileGenerator interface
@@ -36,7 +39,7 @@ func (g *IndexDockerfileGenerator) GenerateIndexDockerfile(binarySourceImage, da
 	var dockerfile string
 
 	if binarySourceImage == "" {
-		binarySourceImage = defaultBinarySourceImage
+		binarySourceImage = DefaultBinarySourceImage
 	}
 
 	g.Logger.Info("Generating dockerfile")
@@ -55,3 +58,29 @@ func (g *IndexDockerfileGenerator) GenerateIndexDockerfile(binarySourceImage, da
 
 	return dockerfile
 }
+
+// GenerateConfigsDockerfile builds a string representation of a dockerfile to use when building
+// an index image that serves declarative configs
+func (g *IndexDockerfileGenerator) GenerateConfigsDockerfile(binarySourceImage, configsDir string) string {
+	var dockerfile string
+
+	if binarySourceImage == "" {
+		binarySourceImage = DefaultBinarySourceImage
+	}
+
+	g.Logger.Info("Generating dockerfile")
+
+	// From
+	dockerfile += fmt.Sprintf("FROM %s\n", binarySourceImage)
+
+	// Labels
+	dockerfile += fmt.Sprintf("LABEL %s=%s\n", ConfigsLocationLabel, DefaultConfigsLocation)
+
+	// Content
+	dockerfile += fmt.Sprintf("ADD %s %s\n", configsDir, DefaultConfigsLocation)
+	dockerfile += fmt.Sprintf("EXPOSE 50051\n")
+	dockerfile += fmt.Sprintf("ENTRYPOINT [\"/bin/opm\"]\n")
+	dockerfile += fmt.Sprintf("CMD [\"alpha\", \"serve\", \"%s\"]\n", DefaultConfigsLocation)
+
+	return dockerfile
+}
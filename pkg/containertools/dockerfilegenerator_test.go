@@ -56,3 +56,27 @@ CMD ["registry", "serve", "--database", "/database/index.db"]
 	dockerfile := dockerfileGenerator.GenerateIndexDockerfile("", databasePath)
 	require.Equal(t, dockerfile, expectedDockerfile)
 }
+
+func TestGenerateConfigsDockerfile(t *testing.T) {
+	binarySourceImage := "quay.io/operator-framework/builder"
+	configsDir := "index_build_tmp/configs"
+	expectedDockerfile := `FROM quay.io/operator-framework/builder
+LABEL operators.operatorframework.io.index.configs.v1=/configs
+ADD index_build_tmp/configs /configs
+EXPOSE 50051
+ENTRYPOINT ["/bin/opm"]
+CMD ["alpha", "serve", "/configs"]
+`
+
+	logger := logrus.NewEntry(logrus.New())
+
+	dockerfileGenerator := containertools.IndexDockerfileGenerator{
+		Logger: logger,
+	}
+
+	dockerfile := dockerfileGenerator.GenerateConfigsDockerfile(binarySourceImage, configsDir)
+	require.Equal(t, expectedDockerfile, dockerfile)
+
+	dockerfile = dockerfileGenerator.GenerateConfigsDockerfile("", configsDir)
+	require.Contains(t, dockerfile, "FROM quay.io/operator-framework/upstream-opm-builder\n")
+}
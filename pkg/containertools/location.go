@@ -0,0 +1,22 @@
+package containertools
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// JoinLocation returns the path of a location label, such as the one of
+// DbLocationLabel or ConfigsLocationLabel, within root, the directory an image
+// is unpacked into. Locations that lead out of root are rejected.
+func JoinLocation(root, location string) (string, error) {
+	p := filepath.Join(root, filepath.Clean(location))
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return "", fmt.Errorf("invalid location %q: %v", location, err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid location %q: outside of the image", location)
+	}
+	return p, nil
+}
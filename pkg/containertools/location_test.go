@@ -0,0 +1,38 @@
+package containertools_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+)
+
+func TestJoinLocation(t *testing.T) {
+	root := filepath.Join("tmp", "root")
+	for _, tt := range []struct {
+		location string
+		expected string
+		wantErr  bool
+	}{
+		{location: "/configs", expected: filepath.Join(root, "configs")},
+		{location: "database/index.db", expected: filepath.Join(root, "database", "index.db")},
+		{location: "/configs/../database/./index.db", expected: filepath.Join(root, "database", "index.db")},
+		{location: "/", expected: root},
+		{location: "..", wantErr: true},
+		{location: "../../etc/passwd", wantErr: true},
+		{location: "/configs/../../..", expected: root},
+		{location: "configs/../../..", wantErr: true},
+	} {
+		t.Run(tt.location, func(t *testing.T) {
+			p, err := containertools.JoinLocation(root, tt.location)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, p)
+		})
+	}
+}
@@ -26,7 +26,8 @@ type ContainerCommandRunner struct {
 }
 
 type RunnerConfig struct {
-	SkipTLS bool
+	SkipTLS  bool
+	AuthFile string
 }
 
 type RunnerOption func(config *RunnerConfig)
@@ -37,6 +38,15 @@ func SkipTLS(skip bool) RunnerOption {
 	}
 }
 
+// WithAuthFile authenticates pulls with the credentials of the auth file at
+// path. Only podman accepts an auth file per command; docker always uses the
+// credentials of its own config.
+func WithAuthFile(path string) RunnerOption {
+	return func(config *RunnerConfig) {
+		config.AuthFile = path
+	}
+}
+
 func (r *RunnerConfig) apply(options []RunnerOption) {
 	for _, option := range options {
 		option(r)
@@ -54,6 +64,12 @@ func (r *ContainerCommandRunner) argsForCmd(cmd string, args ...string) []string
 				cmdArgs = append(cmdArgs, "--tls-verify=false")
 			}
 		}
+		switch cmd {
+		case "pull", "push", "login", "logout", "search":
+			if r.config.AuthFile != "" {
+				cmdArgs = append(cmdArgs, "--authfile", r.config.AuthFile)
+			}
+		}
 	default:
 	}
 	cmdArgs = append(cmdArgs, args...)
@@ -0,0 +1,179 @@
+package containerdregistry
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/containerd/containerd/images"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// staleIngestAge is the age after which content that was never completely
+// written to a shared cache, e.g. because the pull was interrupted, is
+// removed by GarbageCollectCache. It matches the expiry containerd uses for
+// ingests.
+const staleIngestAge = 24 * time.Hour
+
+// cacheLockFile is the file in a shared cache that registries using the
+// cache hold a shared lock on, and that GarbageCollectCache holds an
+// exclusive lock on, so that content isn't removed while it is being pulled.
+const cacheLockFile = "lock"
+
+var errCacheInUse = errors.New("the cache is in use by another command")
+
+// lockCache locks the shared cache in dir until the returned function is
+// called. Shared locks wait for an exclusive lock to be released, whereas
+// an exclusive lock fails right away if the cache is locked.
+func lockCache(dir string, exclusive bool) (func() error, error) {
+	f, err := os.OpenFile(filepath.Join(dir, cacheLockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Closing the file releases the lock.
+	return f.Close, nil
+}
+
+// blobPath returns the path of a blob in the shared cache in dir. It follows
+// the layout of the containerd local content store.
+func blobPath(dir string, dgst digest.Digest) string {
+	return filepath.Join(dir, "blobs", dgst.Algorithm().String(), dgst.Hex())
+}
+
+// touch marks the blobs of the image rooted at desc as used now, if they are
+// in a shared cache.
+func (r *Registry) touch(ctx context.Context, root ocispec.Descriptor) error {
+	if r.sharedCacheDir == "" {
+		return nil
+	}
+	now := time.Now()
+	mark := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if err := os.Chtimes(blobPath(r.sharedCacheDir, desc.Digest), now, now); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, nil
+	})
+	return images.Walk(ctx, images.Handlers(mark, images.ChildrenHandler(r.Content())), root)
+}
+
+// CacheUsage is an amount of content in a shared cache.
+type CacheUsage struct {
+	Blobs int
+	Size  int64
+}
+
+func (u *CacheUsage) add(size int64) {
+	u.Blobs++
+	u.Size += size
+}
+
+// GarbageCollectCache removes content from the shared cache in dir. Blobs
+// that haven't been used by a pull for longer than maxAge are removed, then
+// the least recently used blobs until the cache holds no more than maxSize
+// bytes. A limit of zero is not enforced. It returns the usage of the removed
+// and of the remaining content.
+//
+// Content is removed regardless of which images use it, so it fails if other
+// commands are using the cache, and registries created while it runs wait
+// for it to finish.
+func GarbageCollectCache(dir string, maxAge time.Duration, maxSize int64) (removed, kept CacheUsage, err error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return removed, kept, nil
+	}
+	unlock, err := lockCache(dir, true)
+	if err != nil {
+		return removed, kept, err
+	}
+	defer func() {
+		if unlockErr := unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if err := removeStaleIngests(dir); err != nil {
+		return removed, kept, err
+	}
+
+	type blob struct {
+		path     string
+		size     int64
+		lastUsed time.Time
+	}
+	var blobs []blob
+	algorithms, err := ioutil.ReadDir(filepath.Join(dir, "blobs"))
+	if err != nil && !os.IsNotExist(err) {
+		return removed, kept, err
+	}
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(dir, "blobs", algorithm.Name()))
+		if err != nil {
+			return removed, kept, err
+		}
+		for _, f := range files {
+			if f.Mode().IsRegular() {
+				blobs = append(blobs, blob{
+					path:     filepath.Join(dir, "blobs", algorithm.Name(), f.Name()),
+					size:     f.Size(),
+					lastUsed: f.ModTime(),
+				})
+			}
+		}
+	}
+
+	// Most recently used first, so that the blobs past either limit are at
+	// the end.
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].lastUsed.After(blobs[j].lastUsed) })
+	cutoff := time.Now().Add(-maxAge)
+	full := false
+	for _, b := range blobs {
+		expired := maxAge > 0 && b.lastUsed.Before(cutoff)
+		full = full || maxSize > 0 && kept.Size+b.size > maxSize
+		if !expired && !full {
+			kept.add(b.size)
+			continue
+		}
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return removed, kept, err
+		}
+		removed.add(b.size)
+	}
+	return removed, kept, nil
+}
+
+// removeStaleIngests removes content that hasn't been written to for longer
+// than staleIngestAge from the ingest directory of a shared cache.
+func removeStaleIngests(dir string) error {
+	ingests, err := ioutil.ReadDir(filepath.Join(dir, "ingest"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	cutoff := time.Now().Add(-staleIngestAge)
+	for _, ingest := range ingests {
+		if ingest.ModTime().After(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, "ingest", ingest.Name())
+		if data, err := os.Stat(filepath.Join(path, "data")); err == nil && data.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
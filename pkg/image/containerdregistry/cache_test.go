@@ -0,0 +1,144 @@
+package containerdregistry_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/platforms"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+func TestSharedCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storageDir, err := ioutil.TempDir("", "cache-test-storage-")
+	require.NoError(t, err)
+	defer os.RemoveAll(storageDir)
+	sharedDir, err := ioutil.TempDir("", "cache-test-shared-")
+	require.NoError(t, err)
+	defer os.RemoveAll(sharedDir)
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, storageDir)
+	require.NoError(t, err)
+	rootCAs, err := certs.RootCAs(cafile)
+	require.NoError(t, err)
+	newSharedRegistry := func() *containerdregistry.Registry {
+		cacheDir, err := ioutil.TempDir("", "cache-test-")
+		require.NoError(t, err)
+		r, err := containerdregistry.NewRegistry(
+			containerdregistry.WithLog(logrus.New().WithField("test", t.Name())),
+			containerdregistry.WithCacheDir(cacheDir),
+			containerdregistry.WithRootCAs(rootCAs),
+			containerdregistry.WithSharedCache(sharedDir),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, r.Destroy())
+			require.NoDirExists(t, cacheDir)
+		})
+		return r
+	}
+
+	ref := image.SimpleReference(host + "/test/bundle:v1")
+	r := newRegistry(t, cafile)
+	require.NoError(t, r.Pack(ctx, nil, ref, tarLayer(t, map[string]string{"bundle.txt": "bundle"})))
+	require.NoError(t, r.Push(ctx, ref))
+	img, err := r.Images().Get(namespaces.WithNamespace(ctx, namespaces.Default), ref.String())
+	require.NoError(t, err)
+	manifest, err := images.Manifest(namespaces.WithNamespace(ctx, namespaces.Default), r.Content(), img.Target, platforms.Default())
+	require.NoError(t, err)
+	layer := manifest.Layers[0].Digest
+
+	// Pulling once fills the shared cache, which outlives the registry.
+	require.NoError(t, newSharedRegistry().Pull(ctx, ref))
+	cached := filepath.Join(sharedDir, "blobs", layer.Algorithm().String(), layer.Hex())
+	require.FileExists(t, cached)
+
+	// Once the layer is gone from the remote registry, it can only be pulled
+	// from the shared cache.
+	require.NoError(t, os.RemoveAll(filepath.Join(storageDir, "docker", "registry", "v2", "blobs", layer.Algorithm().String(), layer.Hex()[:2], layer.Hex())))
+	require.Error(t, newRegistry(t, cafile).Pull(ctx, ref))
+
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(cached, old, old))
+	r = newSharedRegistry()
+	require.NoError(t, r.Pull(ctx, ref))
+	dir, err := ioutil.TempDir("", "cache-test-unpacked-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, r.Unpack(ctx, ref, dir))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "bundle.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "bundle", string(data))
+
+	// Pulls mark the content they use as recently used.
+	info, err := os.Stat(cached)
+	require.NoError(t, err)
+	require.True(t, info.ModTime().After(old))
+}
+
+func TestGarbageCollectCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-test-gc-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	writeFile := func(path string, size int, lastUsed time.Time) string {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, ioutil.WriteFile(path, make([]byte, size), 0644))
+		require.NoError(t, os.Chtimes(path, lastUsed, lastUsed))
+		return path
+	}
+	recent := writeFile("blobs/sha256/a", 100, now.Add(-time.Minute))
+	older := writeFile("blobs/sha256/b", 100, now.Add(-time.Hour))
+	oldest := writeFile("blobs/sha256/c", 10, now.Add(-2*time.Hour))
+	expired := writeFile("blobs/sha256/d", 10, now.Add(-48*time.Hour))
+	staleIngest := writeFile("ingest/stale/data", 10, now.Add(-48*time.Hour))
+	require.NoError(t, os.Chtimes(filepath.Dir(staleIngest), now.Add(-48*time.Hour), now.Add(-48*time.Hour)))
+	activeIngest := writeFile("ingest/active/data", 10, now)
+
+	removed, kept, err := containerdregistry.GarbageCollectCache(dir, 24*time.Hour, 0)
+	require.NoError(t, err)
+	require.Equal(t, containerdregistry.CacheUsage{Blobs: 1, Size: 10}, removed)
+	require.Equal(t, containerdregistry.CacheUsage{Blobs: 3, Size: 210}, kept)
+	require.NoFileExists(t, expired)
+	require.NoDirExists(t, filepath.Dir(staleIngest))
+	require.FileExists(t, activeIngest)
+
+	// The least recently used blobs are removed once the cache is full, even
+	// those small enough to fit.
+	removed, kept, err = containerdregistry.GarbageCollectCache(dir, 0, 150)
+	require.NoError(t, err)
+	require.Equal(t, containerdregistry.CacheUsage{Blobs: 2, Size: 110}, removed)
+	require.Equal(t, containerdregistry.CacheUsage{Blobs: 1, Size: 100}, kept)
+	require.FileExists(t, recent)
+	require.NoFileExists(t, older)
+	require.NoFileExists(t, oldest)
+
+	// Content isn't collected while a registry uses the cache.
+	cacheDir, err := ioutil.TempDir("", "cache-test-")
+	require.NoError(t, err)
+	r, err := containerdregistry.NewRegistry(containerdregistry.WithCacheDir(cacheDir), containerdregistry.WithSharedCache(dir))
+	require.NoError(t, err)
+	_, _, err = containerdregistry.GarbageCollectCache(dir, 0, 1)
+	require.Error(t, err)
+	require.FileExists(t, recent)
+	require.NoError(t, r.Destroy())
+	removed, _, err = containerdregistry.GarbageCollectCache(dir, 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, containerdregistry.CacheUsage{Blobs: 1, Size: 100}, removed)
+}
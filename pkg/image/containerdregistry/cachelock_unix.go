@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package containerdregistry
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func lockFile(f *os.File, exclusive bool) error {
+	how := unix.LOCK_SH
+	if exclusive {
+		how = unix.LOCK_EX | unix.LOCK_NB
+	}
+	err := unix.Flock(int(f.Fd()), how)
+	if err == unix.EWOULDBLOCK {
+		return errCacheInUse
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+package containerdregistry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
+)
+
+// copyBlobWorkers is the number of blobs of an image copied at once.
+const copyBlobWorkers = 8
+
+// Copy copies the image src refers to from its registry to the registry of
+// dst, including every manifest of a manifest list and every blob, whatever
+// its platform.
+//
+// Blobs the destination already has aren't fetched or uploaded, and an image
+// the destination already has at dst isn't copied at all. Blobs are fetched
+// into the content store of the registry before they are uploaded, so that a
+// copy that was interrupted resumes from the content it had fetched when the
+// store is kept, as with WithSharedCache.
+func (r *Registry) Copy(ctx context.Context, src, dst image.Reference) (err error) {
+	ctx, span := tracing.Start(ctx, "containerdregistry.Copy", tracing.String("image", src.String()), tracing.String("destination", dst.String()))
+	defer func() { span.EndWithError(err) }()
+
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	fetcher, root, err := r.resolveSource(ctx, src)
+	if err != nil {
+		return err
+	}
+	target, err := pushTarget(dst.String(), root)
+	if err != nil {
+		return err
+	}
+
+	// A resolver of its own keeps pushes of the same blob to other
+	// repositories from being taken for pushes to this one.
+	resolver, err := r.newResolver()
+	if err != nil {
+		return err
+	}
+	if _, desc, err := resolver.Resolve(ctx, dst.String()); err == nil && desc.Digest == root.Digest {
+		r.log.WithField("digest", root.Digest).Debugf("%s already has %s", dst, src)
+		return nil
+	}
+	pusher, err := resolver.Pusher(ctx, target)
+	if err != nil {
+		return fmt.Errorf("error creating pusher for %s: %v", dst, err)
+	}
+
+	if err := r.copy(ctx, fetcher, pusher, root); err != nil {
+		return fmt.Errorf("error copying %s to %s: %v", src, dst, err)
+	}
+	r.log.WithField("digest", root.Digest).Debugf("copied %s to %s", src, dst)
+	return nil
+}
+
+// CopyToLayout copies the image src refers to from its registry to layout,
+// where it is named name. Like with Copy, blobs layout already has aren't
+// fetched, and an interrupted copy resumes from the content it had written.
+func (r *Registry) CopyToLayout(ctx context.Context, src image.Reference, layout *OCILayout, name string) (err error) {
+	ctx, span := tracing.Start(ctx, "containerdregistry.CopyToLayout", tracing.String("image", src.String()), tracing.String("name", name))
+	defer func() { span.EndWithError(err) }()
+
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	fetcher, root, err := r.resolveSource(ctx, src)
+	if err != nil {
+		return err
+	}
+	if desc, ok, err := layout.Get(name); err != nil {
+		return err
+	} else if ok && desc.Digest == root.Digest {
+		r.log.WithField("digest", root.Digest).Debugf("layout already has %s", src)
+		return nil
+	}
+
+	if err := r.copy(ctx, fetcher, layout, root); err != nil {
+		return fmt.Errorf("error copying %s to layout: %v", src, err)
+	}
+	if err := layout.Tag(name, root); err != nil {
+		return fmt.Errorf("error naming %s in layout: %v", src, err)
+	}
+	r.log.WithField("digest", root.Digest).Debugf("copied %s to layout as %s", src, name)
+	return nil
+}
+
+// resolveSource resolves src and verifies it against the verification policy
+// of the registry, if any.
+func (r *Registry) resolveSource(ctx context.Context, src image.Reference) (remotes.Fetcher, ocispec.Descriptor, error) {
+	name, root, err := r.resolver.Resolve(ctx, src.String())
+	if err != nil {
+		return nil, root, fmt.Errorf("error resolving name %s: %v", src, err)
+	}
+	if root.MediaType == images.MediaTypeDockerSchema1Manifest {
+		return nil, root, fmt.Errorf("specified image is a docker schema v1 manifest, which is not supported")
+	}
+
+	if r.verifier != nil {
+		if err := r.verifier.verify(ctx, r.resolver, src.String(), root); err != nil {
+			return nil, root, fmt.Errorf("image %s failed verification: %v", src, err)
+		}
+		r.log.Debugf("verified %s", src)
+	}
+
+	fetcher, err := r.resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, root, err
+	}
+	return fetcher, root, nil
+}
+
+// pushTarget returns the reference to push the image rooted at root to so
+// that it ends up at dst. Tagged destinations get the digest of the image, so
+// that only root is pushed to the tag and the manifests it references are
+// pushed by digest.
+func pushTarget(dst string, root ocispec.Descriptor) (string, error) {
+	named, err := reference.ParseNormalizedNamed(dst)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference %s: %v", dst, err)
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		if digested.Digest() != root.Digest {
+			return "", fmt.Errorf("can't copy image with digest %s to %s", root.Digest, dst)
+		}
+		return named.String(), nil
+	}
+	named = reference.TagNameOnly(named)
+	digested, err := reference.WithDigest(named, root.Digest)
+	if err != nil {
+		return "", err
+	}
+	return digested.String(), nil
+}
+
+// copy copies the content of the image rooted at root from fetcher to
+// pusher. Manifests are always fetched, to find the content they reference,
+// and are pushed after it, so that a destination never has a manifest
+// without its content. Other blobs are only fetched if pusher doesn't have
+// them yet, and up to copyBlobWorkers of them are copied concurrently.
+func (r *Registry) copy(ctx context.Context, fetcher remotes.Fetcher, pusher remotes.Pusher, root ocispec.Descriptor) error {
+	var (
+		mu        sync.Mutex
+		manifests []ocispec.Descriptor
+	)
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		switch desc.MediaType {
+		case images.MediaTypeDockerSchema2Manifest, ocispec.MediaTypeImageManifest,
+			images.MediaTypeDockerSchema2ManifestList, ocispec.MediaTypeImageIndex:
+			if _, err := remotes.FetchHandler(r.Content(), fetcher)(ctx, desc); err != nil {
+				return nil, err
+			}
+			mu.Lock()
+			manifests = append(manifests, desc)
+			mu.Unlock()
+			return images.Children(ctx, r.Content(), desc)
+		case images.MediaTypeDockerSchema1Manifest:
+			return nil, fmt.Errorf("%v not supported", desc.MediaType)
+		default:
+			return nil, r.copyBlob(ctx, fetcher, pusher, desc)
+		}
+	})
+	if err := images.Dispatch(ctx, handler, semaphore.NewWeighted(copyBlobWorkers), root); err != nil {
+		return err
+	}
+
+	// Manifests were found parents first, so push them in reverse.
+	for i := len(manifests) - 1; i >= 0; i-- {
+		if err := r.copyBlob(ctx, fetcher, pusher, manifests[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyBlob pushes desc to pusher unless it already has it, fetching it into
+// the content store first if it isn't there yet.
+func (r *Registry) copyBlob(ctx context.Context, fetcher remotes.Fetcher, pusher remotes.Pusher, desc ocispec.Descriptor) error {
+	cw, err := pusher.Push(ctx, desc)
+	if errdefs.IsAlreadyExists(err) {
+		r.log.WithField("digest", desc.Digest).Debug("skipped, destination has it")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer cw.Close()
+
+	if _, err := remotes.FetchHandler(r.Content(), fetcher)(ctx, desc); err != nil {
+		return err
+	}
+	ra, err := r.Content().ReaderAt(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer ra.Close()
+
+	if err := content.Copy(ctx, cw, io.NewSectionReader(ra, 0, desc.Size), desc.Size, desc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return err
+	}
+	r.log.WithField("digest", desc.Digest).Debug("copied")
+	return nil
+}
@@ -0,0 +1,171 @@
+package containerdregistry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+// pushMultiPlatform pushes an index of an image for each of platforms to
+// repo, each with a single file naming its platform, and returns the
+// descriptor of the index and of the image of each platform.
+func pushMultiPlatform(ctx context.Context, t *testing.T, r *containerdregistry.Registry, repo string, platforms ...string) (ocispec.Descriptor, map[string]ocispec.Descriptor) {
+	ctx = namespaces.WithNamespace(ctx, namespaces.Default)
+	manifests := map[string]ocispec.Descriptor{}
+	index := ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}}
+	for _, arch := range platforms {
+		ref := image.SimpleReference(fmt.Sprintf("%s:%s", repo, arch))
+		require.NoError(t, r.Pack(ctx, nil, ref, tarLayer(t, map[string]string{"platform.txt": repo + "/" + arch})))
+		require.NoError(t, r.Push(ctx, ref))
+		img, err := r.Images().Get(ctx, ref.String())
+		require.NoError(t, err)
+		desc := img.Target
+		desc.Platform = &ocispec.Platform{OS: "linux", Architecture: arch}
+		manifests[arch] = desc
+		index.Manifests = append(index.Manifests, desc)
+	}
+
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+	root := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, Digest: digest.FromBytes(data), Size: int64(len(data))}
+	require.NoError(t, content.WriteBlob(ctx, r.Content(), root.Digest.String(), bytes.NewReader(data), root))
+	ref := image.SimpleReference(repo + ":v1")
+	_, err = r.Images().Create(ctx, images.Image{Name: ref.String(), Target: root})
+	require.NoError(t, err)
+	require.NoError(t, r.Push(ctx, ref))
+	return root, manifests
+}
+
+func TestCopy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+
+	src := host + "/test/multi"
+	root, manifests := pushMultiPlatform(ctx, t, newRegistry(t, cafile), src, "amd64", "arm64")
+	srcRef := image.SimpleReference(src + ":v1")
+
+	// Every platform is copied, whatever the platform of the registry.
+	dst := image.SimpleReference(host + "/mirror/multi:v1")
+	require.NoError(t, newRegistry(t, cafile).Copy(ctx, srcRef, dst))
+	for arch, desc := range manifests {
+		r := newRegistry(t, cafile)
+		ref := image.SimpleReference(fmt.Sprintf("%s/mirror/multi@%s", host, desc.Digest))
+		require.NoError(t, r.Pull(ctx, ref))
+		dir, err := ioutil.TempDir("", "copy-test-")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		require.NoError(t, r.Unpack(ctx, ref, dir))
+		data, err := ioutil.ReadFile(filepath.Join(dir, "platform.txt"))
+		require.NoError(t, err)
+		require.Equal(t, src+"/"+arch, string(data))
+	}
+	resolved, err := newRegistry(t, cafile).ResolveDigest(ctx, dst)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("%s/mirror/multi@%s", host, root.Digest), resolved.String())
+
+	// Copying to a repository that has the content fetches no blob, and
+	// copying an image that's already there fetches nothing at all.
+	has := func(r *containerdregistry.Registry, dgst digest.Digest) bool {
+		_, err := r.Content().Info(namespaces.WithNamespace(ctx, namespaces.Default), dgst)
+		if errdefs.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+	r := newRegistry(t, cafile)
+	require.NoError(t, r.Copy(ctx, srcRef, image.SimpleReference(host+"/mirror/multi:v2")))
+	require.True(t, has(r, manifests["arm64"].Digest), "manifests are always fetched")
+	manifest, err := images.Manifest(namespaces.WithNamespace(ctx, namespaces.Default), r.Content(), manifests["arm64"], nil)
+	require.NoError(t, err)
+	require.False(t, has(r, manifest.Layers[0].Digest), "layer the destination has was fetched")
+
+	r = newRegistry(t, cafile)
+	require.NoError(t, r.Copy(ctx, srcRef, dst))
+	require.False(t, has(r, root.Digest), "image the destination has was fetched")
+
+	// A destination by digest must be the digest of the image.
+	require.NoError(t, newRegistry(t, cafile).Copy(ctx, srcRef, image.SimpleReference(fmt.Sprintf("%s/mirror/bydigest@%s", host, root.Digest))))
+	require.Error(t, newRegistry(t, cafile).Copy(ctx, srcRef, image.SimpleReference(fmt.Sprintf("%s/mirror/bydigest@%s", host, manifests["amd64"].Digest))))
+
+	require.Error(t, newRegistry(t, cafile).Copy(ctx, image.SimpleReference(host+"/test/missing:v1"), dst))
+}
+
+func TestCopyToLayout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+
+	src := host + "/test/layout"
+	root, _ := pushMultiPlatform(ctx, t, newRegistry(t, cafile), src, "amd64", "arm64")
+	srcRef := image.SimpleReference(src + ":v1")
+
+	dir, err := ioutil.TempDir("", "copy-test-layout-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	layout, err := containerdregistry.NewOCILayout(dir)
+	require.NoError(t, err)
+
+	const name = "mirror.example.com/test/layout:v1"
+	require.NoError(t, newRegistry(t, cafile).CopyToLayout(ctx, srcRef, layout, name))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, ocispec.ImageLayoutFile))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"imageLayoutVersion":"1.0.0"}`, string(data))
+	data, err = ioutil.ReadFile(filepath.Join(dir, "index.json"))
+	require.NoError(t, err)
+	var index ocispec.Index
+	require.NoError(t, json.Unmarshal(data, &index))
+	require.Len(t, index.Manifests, 1)
+	require.Equal(t, root.Digest, index.Manifests[0].Digest)
+	require.Equal(t, name, index.Manifests[0].Annotations[ocispec.AnnotationRefName])
+
+	// The layout has the content of every platform.
+	var blobs int
+	count := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		blobs++
+		_, err := os.Stat(filepath.Join(dir, "blobs", desc.Digest.Algorithm().String(), desc.Digest.Hex()))
+		return nil, err
+	})
+	require.NoError(t, images.Walk(ctx, images.Handlers(count, images.ChildrenHandler(layout.Content())), root))
+	require.Equal(t, 7, blobs, "index, and manifest, config and layer of each platform")
+
+	// Copying again, even to a layout opened anew, skips the image.
+	layout, err = containerdregistry.NewOCILayout(dir)
+	require.NoError(t, err)
+	r := newRegistry(t, cafile)
+	require.NoError(t, r.CopyToLayout(ctx, srcRef, layout, name))
+	_, err = r.Content().Info(namespaces.WithNamespace(ctx, namespaces.Default), root.Digest)
+	require.True(t, errdefs.IsNotFound(err), "image the layout has was fetched")
+
+	desc, ok, err := layout.Get(name)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, root.Digest, desc.Digest)
+	_, ok, err = layout.Get("mirror.example.com/test/missing:v1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,187 @@
+package containerdregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/containerd/containerd/content"
+	contentlocal "github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/remotes"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// layoutIndexFile is the name of the index of an OCI image layout.
+const layoutIndexFile = "index.json"
+
+// OCILayout is a directory holding images in the OCI image layout format,
+// where each image is named by an org.opencontainers.image.ref.name
+// annotation of its entry in the index. Blobs are kept where the containerd
+// local content store keeps them, which is where the layout expects them.
+//
+// An OCILayout is safe for concurrent use, but not for use by several
+// processes at once.
+type OCILayout struct {
+	dir   string
+	store content.Store
+	mu    sync.Mutex
+}
+
+var _ remotes.Pusher = &OCILayout{}
+
+// NewOCILayout returns the OCI image layout in dir, creating it if it doesn't
+// exist.
+func NewOCILayout(dir string) (*OCILayout, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	layoutFile := filepath.Join(dir, ocispec.ImageLayoutFile)
+	data, err := ioutil.ReadFile(layoutFile)
+	switch {
+	case os.IsNotExist(err):
+		data, err = json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(layoutFile, data, 0644); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		var layout ocispec.ImageLayout
+		if err := json.Unmarshal(data, &layout); err != nil {
+			return nil, fmt.Errorf("invalid %s in %s: %v", ocispec.ImageLayoutFile, dir, err)
+		}
+		if layout.Version != ocispec.ImageLayoutVersion {
+			return nil, fmt.Errorf("unsupported image layout version %q in %s", layout.Version, dir)
+		}
+	}
+
+	store, err := contentlocal.NewStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := &OCILayout{dir: dir, store: store}
+
+	// The index is required even when the layout has no images.
+	if _, err := os.Stat(filepath.Join(dir, layoutIndexFile)); os.IsNotExist(err) {
+		index, err := l.index()
+		if err != nil {
+			return nil, err
+		}
+		if err := l.writeIndex(index); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Content returns the store of the blobs of the layout.
+func (l *OCILayout) Content() content.Store {
+	return l.store
+}
+
+// Push returns a writer for desc, or an error that errdefs.IsAlreadyExists
+// accepts if the layout already has it. A write that was interrupted resumes
+// where it stopped.
+func (l *OCILayout) Push(ctx context.Context, desc ocispec.Descriptor) (content.Writer, error) {
+	// The local store locks writes by ref across stores, so the ref mustn't
+	// be the one the registry fetches the same blob with.
+	ref := "layout-" + remotes.MakeRefKey(ctx, desc)
+	return content.OpenWriter(ctx, l.store, content.WithRef(ref), content.WithDescriptor(desc))
+}
+
+// Get returns the descriptor of the image named name, and whether there is
+// one.
+func (l *OCILayout) Get(name string) (ocispec.Descriptor, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	index, err := l.index()
+	if err != nil {
+		return ocispec.Descriptor{}, false, err
+	}
+	for _, desc := range index.Manifests {
+		if desc.Annotations[ocispec.AnnotationRefName] == name {
+			return desc, true, nil
+		}
+	}
+	return ocispec.Descriptor{}, false, nil
+}
+
+// Tag names the image rooted at desc name, replacing the image previously
+// named name if any. The layout must have the content of the image.
+func (l *OCILayout) Tag(name string, desc ocispec.Descriptor) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	index, err := l.index()
+	if err != nil {
+		return err
+	}
+	manifests := index.Manifests[:0]
+	for _, m := range index.Manifests {
+		if m.Annotations[ocispec.AnnotationRefName] != name {
+			manifests = append(manifests, m)
+		}
+	}
+
+	desc.Annotations = map[string]string{ocispec.AnnotationRefName: name}
+	index.Manifests = append(manifests, desc)
+	return l.writeIndex(index)
+}
+
+func (l *OCILayout) index() (*ocispec.Index, error) {
+	return readLayoutIndex(l.dir)
+}
+
+// readLayoutIndex reads the index of the OCI image layout in dir. A layout
+// without index has no images.
+func readLayoutIndex(dir string) (*ocispec.Index, error) {
+	index := &ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}}
+	data, err := ioutil.ReadFile(filepath.Join(dir, layoutIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("invalid %s in %s: %v", layoutIndexFile, dir, err)
+	}
+	return index, nil
+}
+
+// writeIndex replaces the index of the layout at once, so that it's never
+// left half written.
+func (l *OCILayout) writeIndex(index *ocispec.Index) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(l.dir, layoutIndexFile+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(l.dir, layoutIndexFile))
+}
@@ -0,0 +1,319 @@
+package containerdregistry
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// dockerArchiveManifestFile is the name of the file listing the images of an
+// archive written by docker save.
+const dockerArchiveManifestFile = "manifest.json"
+
+// dockerArchiveManifest is an entry of the manifest of an archive written by
+// docker save.
+type dockerArchiveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// pullLocal stores the image local refers to under ref, with the content of
+// all its platforms.
+func (r *Registry) pullLocal(ctx context.Context, ref image.Reference, local *image.LocalReference) error {
+	// Local images carry no signatures and aren't referenced by digest, so
+	// they can't comply with a policy.
+	if r.verifier != nil {
+		return fmt.Errorf("image %s failed verification: local images can't be verified", ref)
+	}
+
+	unlock := r.locks.lock(ref.String())
+	defer unlock()
+
+	var (
+		root ocispec.Descriptor
+		err  error
+	)
+	switch local.Scheme {
+	case image.OCILayoutScheme:
+		root, err = r.importLayout(ctx, local)
+	case image.OCIArchiveScheme, image.DockerArchiveScheme:
+		root, err = r.importArchive(ctx, local)
+	default:
+		err = fmt.Errorf("unsupported scheme %q", local.Scheme)
+	}
+	if err != nil {
+		return fmt.Errorf("error importing %s: %v", ref, err)
+	}
+	r.log.WithField("digest", root.Digest).Debugf("imported %s", ref)
+
+	return r.storeImage(ctx, ref, root)
+}
+
+// importLayout copies the content of the image of an OCI image layout
+// directory to the content store. The layout is only read.
+func (r *Registry) importLayout(ctx context.Context, local *image.LocalReference) (ocispec.Descriptor, error) {
+	if _, err := os.Stat(filepath.Join(local.Path, ocispec.ImageLayoutFile)); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not an OCI image layout: %v", local.Path, err)
+	}
+	index, err := readLayoutIndex(local.Path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	root, err := selectImage(index, local.Name)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	// Descriptors come from the layout, so their digests are checked before
+	// they name a file, and the files against their descriptors as they are
+	// written.
+	copyBlob := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if err := desc.Digest.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid digest %q in %s: %v", desc.Digest, local.Path, err)
+		}
+		f, err := os.Open(blobPath(local.Path, desc.Digest))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() != desc.Size {
+			return nil, fmt.Errorf("blob %s in %s has size %d, expected %d", desc.Digest, local.Path, info.Size(), desc.Size)
+		}
+		return nil, content.WriteBlob(ctx, r.Content(), remotes.MakeRefKey(ctx, desc), io.LimitReader(f, desc.Size), desc)
+	})
+	if err := images.Dispatch(ctx, images.Handlers(copyBlob, images.ChildrenHandler(r.Content())), nil, root); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return root, nil
+}
+
+// importArchive writes the files of an OCI image layout archive or of a
+// docker save archive to the content store, and returns the root of the
+// image the archive holds. Compressed archives are accepted.
+func (r *Registry) importArchive(ctx context.Context, local *image.LocalReference) (ocispec.Descriptor, error) {
+	f, err := os.Open(local.Path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer f.Close()
+	decompressed, err := compression.DecompressStream(f)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer decompressed.Close()
+
+	var (
+		tr       = tar.NewReader(decompressed)
+		files    = map[string]ocispec.Descriptor{}
+		metadata = map[string][]byte{}
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("error reading %s: %v", local.Path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		switch name {
+		case ocispec.ImageLayoutFile, layoutIndexFile, dockerArchiveManifestFile:
+			if metadata[name], err = ioutil.ReadAll(tr); err != nil {
+				return ocispec.Descriptor{}, err
+			}
+			continue
+		}
+
+		// Blobs of OCI layouts are named after their digest, which is
+		// checked as they are written.
+		var expected digest.Digest
+		if parts := strings.Split(name, "/"); len(parts) == 3 && parts[0] == "blobs" {
+			expected = digest.NewDigestFromEncoded(digest.Algorithm(parts[1]), parts[2])
+		}
+		if files[name], err = r.writeArchiveFile(ctx, local, name, tr, hdr.Size, expected); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("error reading %s from %s: %v", name, local.Path, err)
+		}
+	}
+
+	if local.Scheme == image.DockerArchiveScheme {
+		return r.dockerArchiveImage(ctx, local, metadata[dockerArchiveManifestFile], files)
+	}
+
+	if _, ok := metadata[ocispec.ImageLayoutFile]; !ok {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not an OCI image layout archive: missing %s", local.Path, ocispec.ImageLayoutFile)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(metadata[layoutIndexFile], &index); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("invalid %s in %s: %v", layoutIndexFile, local.Path, err)
+	}
+	root, err := selectImage(&index, local.Name)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	// The archive must have the content of the image.
+	present := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		_, err := r.Content().Info(ctx, desc.Digest)
+		if errdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("%s is missing blob %s", local.Path, desc.Digest)
+		}
+		return nil, err
+	})
+	if err := images.Walk(ctx, images.Handlers(present, images.ChildrenHandler(r.Content())), root); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return root, nil
+}
+
+// writeArchiveFile writes the file name of an archive to the content store
+// and returns its descriptor. If expected is set, the file must have this
+// digest.
+func (r *Registry) writeArchiveFile(ctx context.Context, local *image.LocalReference, name string, rd io.Reader, size int64, expected digest.Digest) (ocispec.Descriptor, error) {
+	ref := "import-" + digest.FromString(local.Path+":"+name).Encoded()
+	w, err := content.OpenWriter(ctx, r.Content(), content.WithRef(ref), content.WithDescriptor(ocispec.Descriptor{Digest: expected, Size: size}))
+	if errdefs.IsAlreadyExists(err) {
+		return ocispec.Descriptor{Digest: expected, Size: size}, nil
+	}
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer w.Close()
+
+	// A previous import may have left part of the file behind.
+	if err := w.Truncate(0); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if _, err := io.Copy(w, rd); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{Digest: w.Digest(), Size: size}
+	if err := w.Commit(ctx, size, expected); err != nil && !errdefs.IsAlreadyExists(err) {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// dockerArchiveImage writes a manifest for the image of a docker save
+// archive, whose files are in the content store, and returns its descriptor.
+func (r *Registry) dockerArchiveImage(ctx context.Context, local *image.LocalReference, data []byte, files map[string]ocispec.Descriptor) (ocispec.Descriptor, error) {
+	if data == nil {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not a docker archive: missing %s", local.Path, dockerArchiveManifestFile)
+	}
+	var entries []dockerArchiveManifest
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("invalid %s in %s: %v", dockerArchiveManifestFile, local.Path, err)
+	}
+
+	var entry *dockerArchiveManifest
+	for i, e := range entries {
+		if local.Name == "" || matchesAny(local.Name, e.RepoTags) {
+			if entry != nil {
+				return ocispec.Descriptor{}, fmt.Errorf("%s holds more than one image, select one by name", local.Path)
+			}
+			entry = &entries[i]
+		}
+	}
+	if entry == nil {
+		return ocispec.Descriptor{}, fmt.Errorf("image %q not found in %s", local.Name, local.Path)
+	}
+
+	file := func(name, mediaType string) (ocispec.Descriptor, error) {
+		desc, ok := files[path.Clean(name)]
+		if !ok {
+			return desc, fmt.Errorf("%s is missing %s", local.Path, name)
+		}
+		desc.MediaType = mediaType
+		return desc, nil
+	}
+	manifest := ocispec.Manifest{Versioned: specs.Versioned{SchemaVersion: 2}}
+	var err error
+	if manifest.Config, err = file(entry.Config, images.MediaTypeDockerSchema2Config); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	for _, l := range entry.Layers {
+		layer, err := file(l, images.MediaTypeDockerSchema2Layer)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		manifest.Layers = append(manifest.Layers, layer)
+	}
+
+	// The media type is that of docker manifests, which is not part of the
+	// OCI manifest.
+	raw, err := json.Marshal(struct {
+		MediaType string `json:"mediaType"`
+		ocispec.Manifest
+	}{MediaType: images.MediaTypeDockerSchema2Manifest, Manifest: manifest})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	root := ocispec.Descriptor{MediaType: images.MediaTypeDockerSchema2Manifest, Digest: digest.FromBytes(raw), Size: int64(len(raw))}
+	if err := content.WriteBlob(ctx, r.Content(), remotes.MakeRefKey(ctx, root), bytes.NewReader(raw), root); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return root, nil
+}
+
+// selectImage returns the image of index named name, or its only image if
+// name is empty.
+func selectImage(index *ocispec.Index, name string) (ocispec.Descriptor, error) {
+	if name == "" {
+		if len(index.Manifests) != 1 {
+			return ocispec.Descriptor{}, fmt.Errorf("found %d images, select one by name", len(index.Manifests))
+		}
+		return index.Manifests[0], nil
+	}
+	for _, desc := range index.Manifests {
+		if desc.Annotations[ocispec.AnnotationRefName] == name {
+			return desc, nil
+		}
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("image %q not found", name)
+}
+
+// matchesAny returns true if name refers to the same image as one of refs,
+// once both are normalized.
+func matchesAny(name string, refs []string) bool {
+	normalize := func(ref string) string {
+		named, err := reference.ParseNormalizedNamed(ref)
+		if err != nil {
+			return ref
+		}
+		return reference.TagNameOnly(named).String()
+	}
+	for _, ref := range refs {
+		if normalize(ref) == normalize(name) {
+			return true
+		}
+	}
+	return false
+}
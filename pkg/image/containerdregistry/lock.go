@@ -0,0 +1,45 @@
+package containerdregistry
+
+import (
+	"sync"
+)
+
+// refLocks holds a lock per image reference, so that operations on the same
+// reference are serialized while operations on different references run
+// concurrently. A reference's lock is dropped once nothing holds or waits on it.
+type refLocks struct {
+	mu    sync.Mutex
+	locks map[string]*refLock
+}
+
+type refLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newRefLocks() *refLocks {
+	return &refLocks{locks: map[string]*refLock{}}
+}
+
+// lock locks ref and returns the function that unlocks it.
+func (l *refLocks) lock(ref string) func() {
+	l.mu.Lock()
+	rl, ok := l.locks[ref]
+	if !ok {
+		rl = &refLock{}
+		l.locks[ref] = rl
+	}
+	rl.refs++
+	l.mu.Unlock()
+
+	rl.Lock()
+	return func() {
+		rl.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		rl.refs--
+		if rl.refs == 0 {
+			delete(l.locks, ref)
+		}
+	}
+}
@@ -18,11 +18,18 @@ import (
 type RegistryConfig struct {
 	Log               *logrus.Entry
 	ResolverConfigDir string
+	AuthFile          string
 	DBPath            string
 	CacheDir          string
+	SharedCacheDir    string
 	PreserveCache     bool
 	SkipTLS           bool
 	Roots             *x509.CertPool
+	Policy            *VerificationPolicy
+	PolicyFile        string
+	// Platform is the platform of the images pulled from manifest lists and
+	// of the images packed from scratch, see WithPlatform.
+	Platform specs.Platform
 }
 
 func (r *RegistryConfig) apply(options []RegistryOption) {
@@ -40,6 +47,26 @@ func (r *RegistryConfig) complete() error {
 		r.DBPath = filepath.Join(r.CacheDir, "metadata.db")
 	}
 
+	if r.Platform.OS == "" {
+		r.Platform = platforms.DefaultSpec()
+		r.Platform.OS = "linux"
+	}
+	r.Platform = platforms.Normalize(r.Platform)
+
+	if r.SharedCacheDir != "" {
+		if err := os.MkdirAll(r.SharedCacheDir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	if r.Policy == nil && r.PolicyFile != "" {
+		policy, err := LoadVerificationPolicy(r.PolicyFile)
+		if err != nil {
+			return err
+		}
+		r.Policy = policy
+	}
+
 	return nil
 }
 
@@ -62,7 +89,34 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 		return
 	}
 
-	cs, err := contentlocal.NewStore(config.CacheDir)
+	// Holding a lock on a shared cache keeps GarbageCollectCache from
+	// removing content while the registry uses it.
+	var unlockCache func() error
+	if config.SharedCacheDir != "" {
+		if unlockCache, err = lockCache(config.SharedCacheDir, false); err != nil {
+			return
+		}
+		defer func() {
+			if err != nil {
+				unlockCache()
+			}
+		}()
+	}
+
+	var v *verifier
+	if config.Policy != nil {
+		if v, err = newVerifier(config.Policy); err != nil {
+			return
+		}
+	}
+
+	// Content is kept with the rest of the cache unless a shared cache is
+	// used, in which case only the image records are private to the registry.
+	contentDir := config.CacheDir
+	if config.SharedCacheDir != "" {
+		contentDir = config.SharedCacheDir
+	}
+	cs, err := contentlocal.NewStore(contentDir)
 	if err != nil {
 		return
 	}
@@ -76,6 +130,13 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 	var once sync.Once
 	destroy := func() (destroyErr error) {
 		once.Do(func() {
+			if unlockCache != nil {
+				defer func() {
+					if err := unlockCache(); destroyErr == nil {
+						destroyErr = err
+					}
+				}()
+			}
 			if destroyErr = bdb.Close(); destroyErr != nil {
 				return
 			}
@@ -89,8 +150,13 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 		return
 	}
 
-	var resolver remotes.Resolver
-	resolver, err = NewResolver(config.ResolverConfigDir, config.SkipTLS, config.Roots)
+	newResolver := func() (remotes.Resolver, error) {
+		if config.AuthFile != "" {
+			return NewResolverForAuthFile(config.AuthFile, config.SkipTLS, config.Roots)
+		}
+		return NewResolver(config.ResolverConfigDir, config.SkipTLS, config.Roots)
+	}
+	resolver, err := newResolver()
 	if err != nil {
 		return
 	}
@@ -100,10 +166,15 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 		destroy:  destroy,
 		log:      config.Log,
 		resolver: resolver,
-		platform: platforms.Ordered(platforms.DefaultSpec(), specs.Platform{
+		platform: platforms.Ordered(config.Platform, specs.Platform{
 			OS:           "linux",
 			Architecture: "amd64",
 		}),
+		platformSpec:   config.Platform,
+		locks:          newRefLocks(),
+		sharedCacheDir: config.SharedCacheDir,
+		verifier:       v,
+		newResolver:    newResolver,
 	}
 	return
 }
@@ -122,6 +193,24 @@ func WithResolverConfigDir(path string) RegistryOption {
 	}
 }
 
+// WithAuthFile authenticates with the credentials of the auth file at path, a
+// docker config.json or a containers auth.json, rather than with those of the
+// docker config in the resolver config dir.
+func WithAuthFile(path string) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.AuthFile = path
+	}
+}
+
+// WithPlatform sets the platform of the images the registry pulls from
+// manifest lists, falling back to linux/amd64, and of the images Pack creates
+// from scratch. It defaults to linux on the architecture opm runs on.
+func WithPlatform(platform specs.Platform) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.Platform = platform
+	}
+}
+
 func WithCacheDir(dir string) RegistryOption {
 	return func(config *RegistryConfig) {
 		config.CacheDir = dir
@@ -134,6 +223,33 @@ func WithRootCAs(pool *x509.CertPool) RegistryOption {
 	}
 }
 
+// WithSharedCache keeps the content of pulled images in dir, a cache keyed by
+// digest that outlives the registry and can be used by several registries at
+// once. Layers already in the cache aren't fetched again. Image records are
+// still kept in the cache dir of the registry, which Destroy removes as usual.
+func WithSharedCache(dir string) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.SharedCacheDir = dir
+	}
+}
+
+// WithVerificationPolicy makes Pull reject images that don't comply with
+// policy.
+func WithVerificationPolicy(policy *VerificationPolicy) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.Policy = policy
+	}
+}
+
+// WithVerificationPolicyFile makes Pull reject images that don't comply with
+// the policy in the file at path, see LoadVerificationPolicy. An empty path
+// sets no policy.
+func WithVerificationPolicyFile(path string) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.PolicyFile = path
+	}
+}
+
 func PreserveCache(preserve bool) RegistryOption {
 	return func(config *RegistryConfig) {
 		config.PreserveCache = preserve
@@ -0,0 +1,197 @@
+package containerdregistry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
+)
+
+// PackOption updates the config of an image created by Pack.
+type PackOption func(config *ocispec.ImageConfig)
+
+// WithLabels adds labels to the image, replacing labels with the same keys.
+func WithLabels(labels map[string]string) PackOption {
+	return func(config *ocispec.ImageConfig) {
+		if config.Labels == nil {
+			config.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			config.Labels[k] = v
+		}
+	}
+}
+
+// WithEntrypoint sets the entrypoint of the image.
+func WithEntrypoint(entrypoint ...string) PackOption {
+	return func(config *ocispec.ImageConfig) {
+		config.Entrypoint = entrypoint
+	}
+}
+
+// WithCmd sets the default arguments of the entrypoint of the image.
+func WithCmd(cmd ...string) PackOption {
+	return func(config *ocispec.ImageConfig) {
+		config.Cmd = cmd
+	}
+}
+
+// WithExposedPorts adds exposed ports, in the form "<port>/<protocol>", to the image.
+func WithExposedPorts(ports ...string) PackOption {
+	return func(config *ocispec.ImageConfig) {
+		if config.ExposedPorts == nil {
+			config.ExposedPorts = map[string]struct{}{}
+		}
+		for _, port := range ports {
+			config.ExposedPorts[port] = struct{}{}
+		}
+	}
+}
+
+// Pack creates and stores an image named by ref, made of the layers of the base image followed by a new layer
+// read from an uncompressed tar stream. The config of the base image is inherited and updated by the given options.
+// If base is nil, the image is created from scratch. Otherwise, the base image must already be stored, e.g. by Pull.
+// The new image uses the same manifest format, Docker or OCI, as its base; images created from scratch use OCI.
+// Images are packed for the platform of the registry, see WithPlatform, and the base image must be for that platform.
+func (r *Registry) Pack(ctx context.Context, base, ref image.Reference, layer io.Reader, opts ...PackOption) (err error) {
+	ctx, span := tracing.Start(ctx, "containerdregistry.Pack", tracing.String("image", ref.String()))
+	defer func() { span.EndWithError(err) }()
+
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	var manifest ocispec.Manifest
+	var config ocispec.Image
+	if base != nil {
+		m, err := r.getManifest(ctx, base)
+		if err != nil {
+			return fmt.Errorf("error getting manifest of base image %s: %v", base, err)
+		}
+		c, err := r.getImage(ctx, *m)
+		if err != nil {
+			return fmt.Errorf("error getting config of base image %s: %v", base, err)
+		}
+		// The base of a manifest list falls back to linux/amd64 when it has no
+		// manifest for the platform, which would silently change the platform
+		// of the packed image.
+		if basePlatform := (ocispec.Platform{OS: c.OS, Architecture: c.Architecture}); c.Architecture != "" && !platforms.NewMatcher(r.platformSpec).Match(basePlatform) {
+			return fmt.Errorf("base image %s is for platform %s, not %s", base, platforms.Format(basePlatform), platforms.Format(r.platformSpec))
+		}
+		manifest, config = *m, *c
+	} else {
+		manifest = ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Config:    ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig},
+		}
+		config = ocispec.Image{
+			OS:           r.platformSpec.OS,
+			Architecture: r.platformSpec.Architecture,
+			RootFS:       ocispec.RootFS{Type: "layers"},
+		}
+	}
+
+	manifestType, layerType := ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageLayerGzip
+	if manifest.Config.MediaType == images.MediaTypeDockerSchema2Config {
+		manifestType, layerType = images.MediaTypeDockerSchema2Manifest, images.MediaTypeDockerSchema2LayerGzip
+	}
+
+	layerDesc, diffID, err := r.writeLayer(ctx, layer, layerType)
+	if err != nil {
+		return fmt.Errorf("error writing layer: %v", err)
+	}
+	r.log.WithField("digest", layerDesc.Digest).Debug("wrote layer")
+
+	created := time.Now().UTC()
+	config.Created = &created
+	// History entries must match the layers of the image, so they are only
+	// added if the base image has them or there is no base image.
+	if len(config.History) > 0 || len(config.RootFS.DiffIDs) == 0 {
+		config.History = append(config.History, ocispec.History{Created: &created, CreatedBy: "opm"})
+	}
+	config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, diffID)
+	for _, opt := range opts {
+		opt(&config.Config)
+	}
+
+	configDesc, err := r.writeJSON(ctx, manifest.Config.MediaType, config)
+	if err != nil {
+		return fmt.Errorf("error writing image config: %v", err)
+	}
+	manifest.Config = configDesc
+	manifest.Layers = append(manifest.Layers, layerDesc)
+
+	manifestDesc, err := r.writeJSON(ctx, manifestType, struct {
+		MediaType string `json:"mediaType"`
+		ocispec.Manifest
+	}{manifestType, manifest})
+	if err != nil {
+		return fmt.Errorf("error writing manifest: %v", err)
+	}
+	r.log.WithField("digest", manifestDesc.Digest).Debugf("packed %s", ref)
+
+	unlock := r.locks.lock(ref.String())
+	defer unlock()
+	return r.storeImage(ctx, ref, manifestDesc)
+}
+
+// writeLayer compresses and stores a layer, returning its descriptor and the digest of its uncompressed content.
+func (r *Registry) writeLayer(ctx context.Context, layer io.Reader, mediaType string) (ocispec.Descriptor, digest.Digest, error) {
+	w, err := content.OpenWriter(ctx, r.Content(), content.WithRef(fmt.Sprintf("pack-layer-%d", time.Now().UnixNano())))
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	defer w.Close()
+
+	diffID := digest.Canonical.Digester()
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, io.TeeReader(layer, diffID.Hash())); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	if err := gz.Close(); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    w.Digest(),
+		Size:      status.Offset,
+	}
+	if err := w.Commit(ctx, desc.Size, desc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return ocispec.Descriptor{}, "", err
+	}
+	return desc, diffID.Digest(), nil
+}
+
+func (r *Registry) writeJSON(ctx context.Context, mediaType string, v interface{}) (ocispec.Descriptor, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	if err := content.WriteBlob(ctx, r.Content(), desc.Digest.String(), bytes.NewReader(data), desc); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
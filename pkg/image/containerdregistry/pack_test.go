@@ -0,0 +1,129 @@
+package containerdregistry_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+func newRegistry(t *testing.T, cafile string, opts ...containerdregistry.RegistryOption) *containerdregistry.Registry {
+	cacheDir, err := ioutil.TempDir("", "pack-test-")
+	require.NoError(t, err)
+	rootCAs, err := certs.RootCAs(cafile)
+	require.NoError(t, err)
+	r, err := containerdregistry.NewRegistry(append([]containerdregistry.RegistryOption{
+		containerdregistry.WithLog(logrus.New().WithField("test", t.Name())),
+		containerdregistry.WithCacheDir(cacheDir),
+		containerdregistry.WithRootCAs(rootCAs),
+	}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, r.Destroy())
+	})
+	return r
+}
+
+func tarLayer(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+		}))
+		_, err := tw.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return &buf
+}
+
+func TestPackAndPush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+
+	baseRef := image.SimpleReference(host + "/test/base:v1")
+	nextRef := image.SimpleReference(host + "/test/next:v1")
+
+	r := newRegistry(t, cafile)
+	require.NoError(t, r.Pack(ctx, nil, baseRef, tarLayer(t, map[string]string{"base.txt": "base"}),
+		containerdregistry.WithLabels(map[string]string{"base": "true", "overridden": "base"}),
+		containerdregistry.WithEntrypoint("/bin/base"),
+	))
+	require.NoError(t, r.Push(ctx, baseRef))
+
+	// Pack on top of the pushed base from a fresh cache.
+	r = newRegistry(t, cafile)
+	require.NoError(t, r.Pull(ctx, baseRef))
+	require.NoError(t, r.Pack(ctx, baseRef, nextRef, tarLayer(t, map[string]string{"database/index.db": "db"}),
+		containerdregistry.WithLabels(map[string]string{"overridden": "next"}),
+		containerdregistry.WithCmd("serve"),
+		containerdregistry.WithExposedPorts("50051/tcp"),
+	))
+	require.NoError(t, r.Push(ctx, nextRef))
+
+	r = newRegistry(t, cafile)
+	require.NoError(t, r.Pull(ctx, nextRef))
+	labels, err := r.Labels(ctx, nextRef)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"base": "true", "overridden": "next"}, labels)
+
+	dir, err := ioutil.TempDir("", "pack-test-unpacked-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, r.Unpack(ctx, nextRef, dir))
+	for name, expected := range map[string]string{"base.txt": "base", "database/index.db": "db"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(data))
+	}
+
+	require.Error(t, r.Push(ctx, image.SimpleReference(host+"/test/missing:v1")))
+}
+
+func TestPackPlatform(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+
+	amd64 := containerdregistry.WithPlatform(specs.Platform{OS: "linux", Architecture: "amd64"})
+	arm64 := containerdregistry.WithPlatform(specs.Platform{OS: "linux", Architecture: "arm64"})
+	amd64Ref := image.SimpleReference(host + "/test/base:amd64")
+	arm64Ref := image.SimpleReference(host + "/test/base:arm64")
+
+	r := newRegistry(t, cafile, amd64)
+	require.NoError(t, r.Pack(ctx, nil, amd64Ref, tarLayer(t, map[string]string{"base.txt": "amd64"})))
+	require.NoError(t, r.Push(ctx, amd64Ref))
+	r = newRegistry(t, cafile, arm64)
+	require.NoError(t, r.Pack(ctx, nil, arm64Ref, tarLayer(t, map[string]string{"base.txt": "arm64"})))
+	require.NoError(t, r.Push(ctx, arm64Ref))
+
+	// Images are only packed on top of a base for the platform of the registry.
+	r = newRegistry(t, cafile, arm64)
+	require.NoError(t, r.Pull(ctx, arm64Ref))
+	require.NoError(t, r.Pack(ctx, arm64Ref, image.SimpleReference(host+"/test/next:arm64"), tarLayer(t, map[string]string{"next.txt": "next"})))
+	require.NoError(t, r.Pull(ctx, amd64Ref))
+	err = r.Pack(ctx, amd64Ref, image.SimpleReference(host+"/test/next:amd64"), tarLayer(t, map[string]string{"next.txt": "next"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is for platform linux/amd64, not linux/arm64")
+}
@@ -0,0 +1,406 @@
+package containerdregistry
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/ghodss/yaml"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// cosignSignatureMediaType is the media type of the layers of a cosign
+	// signature image, each holding a simple signing payload.
+	cosignSignatureMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// cosignSignatureAnnotation holds the base64 encoded signature of the
+	// payload of a cosign signature layer.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// inTotoPayloadType is the payload type of DSSE envelopes holding in-toto
+	// statements.
+	inTotoPayloadType = "application/vnd.in-toto+json"
+
+	// maxSignatureSize bounds the size of the signature manifests and
+	// payloads read from registries.
+	maxSignatureSize = 4 << 20
+)
+
+// VerificationPolicy describes the images a Registry accepts to pull.
+type VerificationPolicy struct {
+	// RequireDigest rejects images that aren't referenced by digest, so that
+	// what is pulled can't change under a tag.
+	RequireDigest bool `json:"requireDigest,omitempty"`
+
+	// PublicKeys are the paths of PEM encoded ECDSA or RSA public keys. If
+	// any are set, images must be signed by one of them, either by a cosign
+	// signature stored next to the image in its registry or by one of
+	// Bundles.
+	PublicKeys []string `json:"publicKeys,omitempty"`
+
+	// Bundles are the paths of sigstore bundles signing image manifests,
+	// either directly with a message signature or with a DSSE envelope
+	// holding an in-toto statement about the manifest.
+	Bundles []string `json:"bundles,omitempty"`
+}
+
+// LoadVerificationPolicy reads a verification policy from a YAML or JSON
+// file. Relative paths in the policy are relative to the directory of the
+// file.
+func LoadVerificationPolicy(path string) (*VerificationPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading verification policy: %v", err)
+	}
+	var policy VerificationPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("error parsing verification policy %s: %v", path, err)
+	}
+	dir := filepath.Dir(path)
+	for _, paths := range [][]string{policy.PublicKeys, policy.Bundles} {
+		for i, p := range paths {
+			if !filepath.IsAbs(p) {
+				paths[i] = filepath.Join(dir, p)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// verifier enforces a verification policy.
+type verifier struct {
+	requireDigest bool
+	keys          []crypto.PublicKey
+	bundles       []sigstoreBundle
+}
+
+func newVerifier(policy *VerificationPolicy) (*verifier, error) {
+	v := &verifier{requireDigest: policy.RequireDigest}
+	for _, path := range policy.PublicKeys {
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, key)
+	}
+	for _, path := range policy.Bundles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading sigstore bundle: %v", err)
+		}
+		var b sigstoreBundle
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("error parsing sigstore bundle %s: %v", path, err)
+		}
+		if b.MessageSignature == nil && b.DSSEEnvelope == nil {
+			return nil, fmt.Errorf("sigstore bundle %s holds neither a message signature nor a DSSE envelope", path)
+		}
+		v.bundles = append(v.bundles, b)
+	}
+	if len(v.bundles) > 0 && len(v.keys) == 0 {
+		return nil, errors.New("sigstore bundles can't be verified without public keys")
+	}
+	return v, nil
+}
+
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading public key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded public key found in %s", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key %s: %v", path, err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T in %s; only ECDSA and RSA keys are supported", key, path)
+	}
+}
+
+// verify checks that the image named ref, which resolved to root, complies
+// with the policy.
+func (v *verifier) verify(ctx context.Context, resolver remotes.Resolver, ref string, root ocispec.Descriptor) error {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return fmt.Errorf("invalid reference: %v", err)
+	}
+	if v.requireDigest {
+		digested, ok := named.(reference.Digested)
+		if !ok {
+			return errors.New("the verification policy requires images to be referenced by digest")
+		}
+		if digested.Digest() != root.Digest {
+			return fmt.Errorf("resolved to %s instead of the referenced digest %s", root.Digest, digested.Digest())
+		}
+	}
+	if len(v.keys) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, b := range v.bundles {
+		ok, err := b.verifies(root.Digest, v.keys)
+		if ok {
+			return nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err = v.verifyCosignSignatures(ctx, resolver, named, root.Digest)
+	if err == nil {
+		return nil
+	}
+	errs = append(errs, err)
+	return fmt.Errorf("no valid signature found: %v", utilerrors.NewAggregate(errs))
+}
+
+// verifyCosignSignatures checks the signatures cosign stores in the registry
+// of an image, as an image tagged with the digest of the signed manifest.
+func (v *verifier) verifyCosignSignatures(ctx context.Context, resolver remotes.Resolver, named reference.Named, dgst digest.Digest) error {
+	sigRef := fmt.Sprintf("%s:%s-%s.sig", reference.TrimNamed(named).String(), dgst.Algorithm(), dgst.Hex())
+	name, desc, err := resolver.Resolve(ctx, sigRef)
+	if err != nil {
+		return fmt.Errorf("error resolving cosign signatures %s: %v", sigRef, err)
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return err
+	}
+	data, err := fetchSignatureContent(ctx, fetcher, desc)
+	if err != nil {
+		return fmt.Errorf("error fetching cosign signatures %s: %v", sigRef, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("error parsing cosign signatures %s: %v", sigRef, err)
+	}
+
+	var errs []error
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != cosignSignatureMediaType {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(layer.Annotations[cosignSignatureAnnotation])
+		if err != nil || len(sig) == 0 {
+			errs = append(errs, fmt.Errorf("cosign signature layer %s has no valid signature annotation", layer.Digest))
+			continue
+		}
+		payload, err := fetchSignatureContent(ctx, fetcher, layer)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := verifySimpleSigning(payload, sig, named, dgst, v.keys); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no cosign signatures found in %s", sigRef)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func fetchSignatureContent(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Size > maxSignatureSize {
+		return nil, fmt.Errorf("%s is too large to be a signature", desc.Digest)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(rc, maxSignatureSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if desc.Digest.Algorithm().FromBytes(data) != desc.Digest {
+		return nil, fmt.Errorf("content of %s doesn't match its digest", desc.Digest)
+	}
+	return data, nil
+}
+
+// simpleSigningTypes are the types of simple signing payloads that sign a
+// container image, as written by cosign and by containers/image.
+var simpleSigningTypes = map[string]bool{
+	"cosign container image signature": true,
+	"atomic container signature":       true,
+}
+
+// simpleSigningPayload is the part of a simple signing payload that names
+// the signed image and manifest.
+type simpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// verifySimpleSigning checks that sig is a signature of payload by one of
+// keys, and that payload signs the manifest dgst of the image named.
+func verifySimpleSigning(payload, sig []byte, named reference.Named, dgst digest.Digest, keys []crypto.PublicKey) error {
+	if err := verifySignature(keys, sha256.Sum256(payload), sig); err != nil {
+		return err
+	}
+	var p simpleSigningPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("error parsing signature payload: %v", err)
+	}
+	if !simpleSigningTypes[p.Critical.Type] {
+		return fmt.Errorf("signature has unsupported type %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != dgst {
+		return fmt.Errorf("signature is for %s, not %s", p.Critical.Image.DockerManifestDigest, dgst)
+	}
+	return matchSignedIdentity(p.Critical.Identity.DockerReference, named)
+}
+
+// matchSignedIdentity checks that the image reference a signature was made
+// for names the same repository as named, and the same tag if it has one.
+func matchSignedIdentity(identity string, named reference.Named) error {
+	signed, err := reference.ParseNormalizedNamed(identity)
+	if err != nil {
+		return fmt.Errorf("signature has invalid identity %q: %v", identity, err)
+	}
+	if signed.Name() != named.Name() {
+		return fmt.Errorf("signature is for %s, not %s", signed.Name(), named.Name())
+	}
+	if signedTag, ok := signed.(reference.Tagged); ok {
+		tagged, ok := named.(reference.Tagged)
+		if !ok || tagged.Tag() != signedTag.Tag() {
+			return fmt.Errorf("signature is for %s, not %s", reference.FamiliarString(signed), reference.FamiliarString(named))
+		}
+	}
+	return nil
+}
+
+// sigstoreBundle is the part of a sigstore bundle holding its signature.
+// Verification material is ignored, since signatures are verified with the
+// keys of the policy.
+type sigstoreBundle struct {
+	MediaType        string `json:"mediaType"`
+	MessageSignature *struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature,omitempty"`
+	DSSEEnvelope *struct {
+		Payload     []byte `json:"payload"`
+		PayloadType string `json:"payloadType"`
+		Signatures  []struct {
+			Sig []byte `json:"sig"`
+		} `json:"signatures"`
+	} `json:"dsseEnvelope,omitempty"`
+}
+
+// inTotoStatement is the part of an in-toto statement naming its subjects.
+type inTotoStatement struct {
+	Subject []struct {
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+}
+
+// verifies returns whether the bundle holds a valid signature of the
+// manifest with digest dgst. Bundles about other manifests are skipped
+// without error.
+func (b sigstoreBundle) verifies(dgst digest.Digest, keys []crypto.PublicKey) (bool, error) {
+	if dgst.Algorithm() != digest.SHA256 {
+		return false, nil
+	}
+	if m := b.MessageSignature; m != nil {
+		if m.MessageDigest.Algorithm != "SHA2_256" || hex.EncodeToString(m.MessageDigest.Digest) != dgst.Hex() {
+			return false, nil
+		}
+		var sum [sha256.Size]byte
+		copy(sum[:], m.MessageDigest.Digest)
+		if err := verifySignature(keys, sum, m.Signature); err != nil {
+			return false, fmt.Errorf("sigstore bundle for %s: %v", dgst, err)
+		}
+		return true, nil
+	}
+
+	e := b.DSSEEnvelope
+	if e.PayloadType != inTotoPayloadType {
+		return false, nil
+	}
+	var statement inTotoStatement
+	if err := json.Unmarshal(e.Payload, &statement); err != nil {
+		return false, nil
+	}
+	found := false
+	for _, s := range statement.Subject {
+		found = found || s.Digest["sha256"] == dgst.Hex()
+	}
+	if !found {
+		return false, nil
+	}
+	pae := sha256.Sum256(dssePAE(e.PayloadType, e.Payload))
+	var errs []error
+	for _, s := range e.Signatures {
+		err := verifySignature(keys, pae, s.Sig)
+		if err == nil {
+			return true, nil
+		}
+		errs = append(errs, err)
+	}
+	return false, fmt.Errorf("sigstore bundle for %s: %v", dgst, utilerrors.NewAggregate(errs))
+}
+
+// dssePAE returns the pre-authentication encoding of a DSSE payload, which
+// is what DSSE signatures sign.
+func dssePAE(payloadType string, payload []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// verifySignature checks that sig is a signature of the SHA-256 digest sum
+// by one of keys.
+func verifySignature(keys []crypto.PublicKey, sum [sha256.Size]byte, sig []byte) error {
+	for _, key := range keys {
+		switch key := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(key, sum[:], sig) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) == nil || rsa.VerifyPSS(key, crypto.SHA256, sum[:], sig, nil) == nil {
+				return nil
+			}
+		}
+	}
+	return errors.New("signature doesn't match any of the trusted public keys")
+}
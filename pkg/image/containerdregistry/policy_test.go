@@ -0,0 +1,228 @@
+package containerdregistry_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+func writePublicKey(t *testing.T, path string, key *ecdsa.PrivateKey) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	sum := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	require.NoError(t, err)
+	return sig
+}
+
+// pushCosignSignature signs the manifest dgst of the image in repo with key,
+// for the image identity with a payload of type typ, and pushes the
+// signature where cosign would.
+func pushCosignSignature(ctx context.Context, t *testing.T, r *containerdregistry.Registry, repo, identity, typ string, dgst digest.Digest, key *ecdsa.PrivateKey) {
+	ctx = namespaces.WithNamespace(ctx, namespaces.Default)
+	write := func(mediaType string, data []byte) ocispec.Descriptor {
+		desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(data), Size: int64(len(data))}
+		require.NoError(t, content.WriteBlob(ctx, r.Content(), desc.Digest.String(), bytes.NewReader(data), desc))
+		return desc
+	}
+
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":%q},"optional":null}`, identity, dgst, typ))
+	layer := write("application/vnd.dev.cosign.simplesigning.v1+json", payload)
+	layer.Annotations = map[string]string{"dev.cosignproject.cosign/signature": base64.StdEncoding.EncodeToString(sign(t, key, payload))}
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    write(ocispec.MediaTypeImageConfig, []byte(fmt.Sprintf(`{"architecture":"","os":"","rootfs":{"type":"layers","diff_ids":[%q]}}`, layer.Digest))),
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	require.NoError(t, err)
+
+	ref := image.SimpleReference(fmt.Sprintf("%s:%s-%s.sig", repo, dgst.Algorithm(), dgst.Hex()))
+	_, err = r.Images().Create(ctx, images.Image{Name: ref.String(), Target: write(ocispec.MediaTypeImageManifest, manifest)})
+	require.NoError(t, err)
+	require.NoError(t, r.Push(ctx, ref))
+}
+
+func TestVerificationPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+	rootCAs, err := certs.RootCAs(cafile)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "policy-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	trusted, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	untrusted, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	writePublicKey(t, filepath.Join(dir, "trusted.pub"), trusted)
+	writePublicKey(t, filepath.Join(dir, "untrusted.pub"), untrusted)
+
+	// Push an image signed in the registry, one signed by an untrusted key,
+	// ones whose signatures are for another image or of the wrong type, and
+	// one signed only by bundles.
+	r := newRegistry(t, cafile)
+	push := func(name string) (image.Reference, digest.Digest) {
+		ref := image.SimpleReference(fmt.Sprintf("%s/test/%s:v1", host, name))
+		require.NoError(t, r.Pack(ctx, nil, ref, tarLayer(t, map[string]string{"name.txt": name})))
+		require.NoError(t, r.Push(ctx, ref))
+		img, err := r.Images().Get(namespaces.WithNamespace(ctx, namespaces.Default), ref.String())
+		require.NoError(t, err)
+		return ref, img.Target.Digest
+	}
+	signedRef, signedDigest := push("signed")
+	const cosignType = "cosign container image signature"
+	pushCosignSignature(ctx, t, r, host+"/test/signed", host+"/test/signed", cosignType, signedDigest, trusted)
+	untrustedRef, untrustedDigest := push("untrusted")
+	pushCosignSignature(ctx, t, r, host+"/test/untrusted", host+"/test/untrusted", cosignType, untrustedDigest, untrusted)
+	otherIdentityRef, otherIdentityDigest := push("other-identity")
+	pushCosignSignature(ctx, t, r, host+"/test/other-identity", host+"/test/signed", cosignType, otherIdentityDigest, trusted)
+	otherTagRef, otherTagDigest := push("other-tag")
+	pushCosignSignature(ctx, t, r, host+"/test/other-tag", host+"/test/other-tag:v2", cosignType, otherTagDigest, trusted)
+	taggedRef, taggedDigest := push("tagged")
+	pushCosignSignature(ctx, t, r, host+"/test/tagged", host+"/test/tagged:v1", "atomic container signature", taggedDigest, trusted)
+	wrongTypeRef, wrongTypeDigest := push("wrong-type")
+	pushCosignSignature(ctx, t, r, host+"/test/wrong-type", host+"/test/wrong-type", "attestation", wrongTypeDigest, trusted)
+	bundledRef, bundledDigest := push("bundled")
+	dsseRef, dsseDigest := push("dsse")
+
+	writeBundle := func(name string, bundle interface{}) {
+		data, err := json.Marshal(bundle)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), data, 0644))
+	}
+	rawDigest, err := hex.DecodeString(bundledDigest.Hex())
+	require.NoError(t, err)
+	messageSig, err := ecdsa.SignASN1(rand.Reader, trusted, rawDigest)
+	require.NoError(t, err)
+	writeBundle("message.sigstore.json", map[string]interface{}{
+		"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2",
+		"messageSignature": map[string]interface{}{
+			"messageDigest": map[string]interface{}{"algorithm": "SHA2_256", "digest": rawDigest},
+			"signature":     messageSig,
+		},
+	})
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"%s/test/dsse","digest":{"sha256":%q}}],"predicateType":"https://example.com/approved","predicate":{}}`, host, dsseDigest.Hex()))
+	pae := []byte(fmt.Sprintf("DSSEv1 %d %s %d ", len("application/vnd.in-toto+json"), "application/vnd.in-toto+json", len(statement)))
+	writeBundle("dsse.sigstore.json", map[string]interface{}{
+		"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2",
+		"dsseEnvelope": map[string]interface{}{
+			"payload":     statement,
+			"payloadType": "application/vnd.in-toto+json",
+			"signatures":  []map[string]interface{}{{"sig": sign(t, trusted, append(pae, statement...))}},
+		},
+	})
+
+	writePolicy := func(name, policy string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(policy), 0644))
+		return path
+	}
+	digestPolicy := writePolicy("digest.yaml", "requireDigest: true\n")
+	keyPolicy := writePolicy("key.yaml", "publicKeys:\n- trusted.pub\n")
+	untrustedPolicy := writePolicy("untrusted.yaml", "publicKeys:\n- untrusted.pub\n")
+	bundlePolicy := writePolicy("bundle.yaml", "publicKeys:\n- trusted.pub\nbundles:\n- message.sigstore.json\n- dsse.sigstore.json\n")
+	strictPolicy := writePolicy("strict.json", `{"requireDigest":true,"publicKeys":["trusted.pub"]}`)
+
+	byDigest := func(name string, dgst digest.Digest) image.Reference {
+		return image.SimpleReference(fmt.Sprintf("%s/test/%s@%s", host, name, dgst))
+	}
+
+	tests := []struct {
+		name   string
+		policy string
+		ref    image.Reference
+		valid  bool
+	}{
+		{name: "NoPolicy", ref: untrustedRef, valid: true},
+		{name: "TagWithDigestRequired", policy: digestPolicy, ref: signedRef},
+		{name: "DigestWithDigestRequired", policy: digestPolicy, ref: byDigest("signed", signedDigest), valid: true},
+		{name: "CosignSignature", policy: keyPolicy, ref: signedRef, valid: true},
+		{name: "UntrustedCosignSignature", policy: keyPolicy, ref: untrustedRef},
+		{name: "OtherTrustedKey", policy: untrustedPolicy, ref: untrustedRef, valid: true},
+		{name: "SignatureForOtherImage", policy: keyPolicy, ref: otherIdentityRef},
+		{name: "SignatureForOtherTag", policy: keyPolicy, ref: otherTagRef},
+		{name: "SignatureForTag", policy: keyPolicy, ref: taggedRef, valid: true},
+		{name: "WrongSignatureType", policy: keyPolicy, ref: wrongTypeRef},
+		{name: "Unsigned", policy: keyPolicy, ref: bundledRef},
+		{name: "MessageSignatureBundle", policy: bundlePolicy, ref: bundledRef, valid: true},
+		{name: "DSSEBundle", policy: bundlePolicy, ref: dsseRef, valid: true},
+		{name: "CosignSignatureWithBundles", policy: bundlePolicy, ref: signedRef, valid: true},
+		{name: "StrictByTag", policy: strictPolicy, ref: signedRef},
+		{name: "StrictByDigest", policy: strictPolicy, ref: byDigest("signed", signedDigest), valid: true},
+		{name: "StrictUnsignedByDigest", policy: strictPolicy, ref: byDigest("bundled", bundledDigest)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir, err := ioutil.TempDir("", "policy-test-cache-")
+			require.NoError(t, err)
+			r, err := containerdregistry.NewRegistry(
+				containerdregistry.WithLog(logrus.New().WithField("test", t.Name())),
+				containerdregistry.WithCacheDir(cacheDir),
+				containerdregistry.WithRootCAs(rootCAs),
+				containerdregistry.WithVerificationPolicyFile(tt.policy),
+			)
+			require.NoError(t, err)
+			defer func() { require.NoError(t, r.Destroy()) }()
+
+			err = r.Pull(ctx, tt.ref)
+			if !tt.valid {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "failed verification")
+				_, err = r.Images().Get(namespaces.WithNamespace(ctx, namespaces.Default), tt.ref.String())
+				require.Error(t, err, "image failing verification was stored")
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("InvalidPolicy", func(t *testing.T) {
+		for _, policy := range []string{
+			writePolicy("missing-key.yaml", "publicKeys:\n- missing.pub\n"),
+			writePolicy("bundle-without-key.yaml", "bundles:\n- dsse.sigstore.json\n"),
+			filepath.Join(dir, "missing.yaml"),
+		} {
+			_, err := containerdregistry.NewRegistry(
+				containerdregistry.WithCacheDir(filepath.Join(dir, "invalid-cache")),
+				containerdregistry.WithVerificationPolicyFile(policy),
+			)
+			require.Error(t, err, policy)
+		}
+	})
+}
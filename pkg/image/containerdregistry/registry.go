@@ -19,37 +19,82 @@ import (
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 
 	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 // Registry enables manipulation of images via containerd modules.
+//
+// A Registry is safe for concurrent use. Content is stored in a transactional
+// store shared by all images, and operations on the same image reference are
+// serialized, so that an image is never unpacked while it is being pulled.
 type Registry struct {
 	Store
 	destroy  func() error
 	log      *logrus.Entry
 	resolver remotes.Resolver
 	platform platforms.MatchComparer
+	locks    *refLocks
+
+	// platformSpec is the platform images are packed for, see WithPlatform.
+	platformSpec ocispec.Platform
+
+	// sharedCacheDir is the shared content cache, if any, see WithSharedCache.
+	sharedCacheDir string
+
+	// verifier enforces the verification policy, if any, see
+	// WithVerificationPolicy.
+	verifier *verifier
+
+	// newResolver returns a resolver configured like resolver. Copy uses a
+	// new resolver for each destination, since the upload tracker of a
+	// resolver would skip blobs already pushed to another repository.
+	newResolver func() (remotes.Resolver, error)
 }
 
 var _ image.Registry = &Registry{}
 
 var nonRetriablePullError = regexp.MustCompile("specified image is a docker schema v1 manifest, which is not supported")
 
-// Pull fetches and stores an image by reference.
-func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
+// Pull fetches and stores an image by reference. References to images on
+// the local filesystem, see image.ParseLocalReference, are imported from
+// there.
+func (r *Registry) Pull(ctx context.Context, ref image.Reference) (err error) {
+	ctx, span := tracing.Start(ctx, "containerdregistry.Pull", tracing.String("image", ref.String()))
+	defer func() { span.EndWithError(err) }()
+
 	// Set the default namespace if unset
 	ctx = ensureNamespace(ctx)
 
+	if local, ok, err := image.ParseLocalReference(ref); err != nil {
+		return err
+	} else if ok {
+		return r.pullLocal(ctx, ref, local)
+	}
+
 	name, root, err := r.resolver.Resolve(ctx, ref.String())
 	if err != nil {
 		return fmt.Errorf("error resolving name %s: %v", name, err)
 	}
 	r.log.Debugf("resolved name: %s", name)
+	span.SetAttributes(tracing.String("digest", root.Digest.String()))
+
+	if r.verifier != nil {
+		if err := r.verifier.verify(ctx, r.resolver, ref.String(), root); err != nil {
+			return fmt.Errorf("image %s failed verification: %v", ref, err)
+		}
+		r.log.Debugf("verified %s", ref)
+	}
+
+	unlock := r.locks.lock(ref.String())
+	defer unlock()
 
 	fetcher, err := r.resolver.Fetcher(ctx, name)
 	if err != nil {
@@ -76,25 +121,94 @@ func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
 		return err
 	}
 
-	img := images.Image{
-		Name:   ref.String(),
-		Target: root,
+	if err := r.storeImage(ctx, ref, root); err != nil {
+		return err
 	}
-	if _, err = r.Images().Create(ctx, img); err != nil {
-		if errdefs.IsAlreadyExists(err) {
-			_, err = r.Images().Update(ctx, img)
+
+	// Blobs the image uses are marked as recently used, so that garbage
+	// collection of the shared cache evicts them last.
+	if err := r.touch(ctx, root); err != nil {
+		r.log.WithError(err).Warnf("unable to mark cached content of %s as used", ref)
+	}
+	return nil
+}
+
+// ResolveDigest returns a reference by digest to the image ref refers to. An
+// image that was pulled resolves to the digest it was pulled at, others to
+// the digest their registry currently serves. Local images, see
+// image.ParseLocalReference, have no name to pin and can't be resolved.
+func (r *Registry) ResolveDigest(ctx context.Context, ref image.Reference) (image.Reference, error) {
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	// Local references would parse as names of images in a registry.
+	if image.IsLocalReference(ref) {
+		return nil, fmt.Errorf("can't resolve digest of local image %s", ref)
+	}
+	named, err := reference.ParseNormalizedNamed(ref.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %s: %v", ref, err)
+	}
+
+	var dgst digest.Digest
+	if img, err := r.Images().Get(ctx, ref.String()); err == nil {
+		dgst = img.Target.Digest
+	} else if !errdefs.IsNotFound(err) {
+		return nil, err
+	} else {
+		_, root, err := r.resolver.Resolve(ctx, ref.String())
+		if err != nil {
+			return nil, fmt.Errorf("error resolving name %s: %v", ref, err)
 		}
+		dgst = root.Digest
 	}
 
-	return err
+	digested, err := reference.WithDigest(reference.TrimNamed(named), dgst)
+	if err != nil {
+		return nil, err
+	}
+	return image.SimpleReference(reference.FamiliarString(digested)), nil
+}
+
+// Push uploads an image to the remote registry of its reference.
+// If the referenced image does not exist in the registry, an error is returned.
+func (r *Registry) Push(ctx context.Context, ref image.Reference) (err error) {
+	ctx, span := tracing.Start(ctx, "containerdregistry.Push", tracing.String("image", ref.String()))
+	defer func() { span.EndWithError(err) }()
+
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	img, err := r.Images().Get(ctx, ref.String())
+	if err != nil {
+		return err
+	}
+
+	pusher, err := r.resolver.Pusher(ctx, ref.String())
+	if err != nil {
+		return fmt.Errorf("error creating pusher for %s: %v", ref, err)
+	}
+
+	if err := remotes.PushContent(ctx, pusher, img.Target, r.Content(), r.platform, nil); err != nil {
+		return fmt.Errorf("error pushing %s: %v", ref, err)
+	}
+	r.log.WithField("digest", img.Target.Digest).Debugf("pushed %s", ref)
+
+	return nil
 }
 
 // Unpack writes the unpackaged content of an image to a directory.
 // If the referenced image does not exist in the registry, an error is returned.
-func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) error {
+func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) (err error) {
+	ctx, span := tracing.Start(ctx, "containerdregistry.Unpack", tracing.String("image", ref.String()))
+	defer func() { span.EndWithError(err) }()
+
 	// Set the default namespace if unset
 	ctx = ensureNamespace(ctx)
 
+	unlock := r.locks.lock(ref.String())
+	defer unlock()
+
 	manifest, err := r.getManifest(ctx, ref)
 	if err != nil {
 		return err
@@ -104,6 +218,7 @@ func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string)
 		return err
 	}
 
+	span.SetAttributes(tracing.Int("layers", len(manifest.Layers)))
 	for _, layer := range manifest.Layers {
 		r.log.Debugf("unpacking layer: %v", layer)
 		if err := r.unpackLayer(ctx, layer, dir); err != nil {
@@ -136,6 +251,20 @@ func (r *Registry) Destroy() (err error) {
 	return r.destroy()
 }
 
+// storeImage creates or updates the image record for ref.
+func (r *Registry) storeImage(ctx context.Context, ref image.Reference, target ocispec.Descriptor) error {
+	img := images.Image{
+		Name:   ref.String(),
+		Target: target,
+	}
+	_, err := r.Images().Create(ctx, img)
+	if errdefs.IsAlreadyExists(err) {
+		_, err = r.Images().Update(ctx, img)
+	}
+
+	return err
+}
+
 func (r *Registry) getManifest(ctx context.Context, ref image.Reference) (*ocispec.Manifest, error) {
 	img, err := r.Images().Get(ctx, ref.String())
 	if err != nil {
@@ -164,7 +293,7 @@ func (r *Registry) getImage(ctx context.Context, manifest ocispec.Manifest) (*oc
 	if _, err := io.Copy(&buf, decompressed); err != nil {
 		return nil, err
 	}
-	r.log.Warn(buf.String())
+	r.log.Debug(buf.String())
 
 	var imageConfig ocispec.Image
 
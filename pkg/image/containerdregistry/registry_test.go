@@ -0,0 +1,112 @@
+package containerdregistry_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/containerd/containerd/namespaces"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+func TestConcurrentPullAndUnpack(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+
+	// Push images sharing a base layer, so that concurrent pulls race to
+	// store the same content.
+	r := newRegistry(t, cafile)
+	baseRef := image.SimpleReference(host + "/test/base:v1")
+	require.NoError(t, r.Pack(ctx, nil, baseRef, tarLayer(t, map[string]string{"base.txt": "base"})))
+	var refs []image.Reference
+	for i := 0; i < 8; i++ {
+		ref := image.SimpleReference(fmt.Sprintf("%s/test/bundle:v%d", host, i))
+		require.NoError(t, r.Pack(ctx, baseRef, ref, tarLayer(t, map[string]string{"bundle.txt": ref.String()})))
+		require.NoError(t, r.Push(ctx, ref))
+		refs = append(refs, ref)
+	}
+
+	dir, err := ioutil.TempDir("", "concurrent-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Each image is pulled and unpacked twice at once by a fresh registry.
+	r = newRegistry(t, cafile)
+	var wg sync.WaitGroup
+	errs := make([]error, 2*len(refs))
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ref := refs[i%len(refs)]
+			if err := r.Pull(ctx, ref); err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = r.Unpack(ctx, ref, filepath.Join(dir, fmt.Sprint(i)))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		require.NoError(t, err)
+		ref := refs[i%len(refs)]
+		for name, expected := range map[string]string{"base.txt": "base", "bundle.txt": ref.String()} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprint(i), name))
+			require.NoError(t, err)
+			require.Equal(t, expected, string(data))
+		}
+	}
+}
+
+func TestResolveDigest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+
+	r := newRegistry(t, cafile)
+	ref := image.SimpleReference(host + "/test/resolve:v1")
+	require.NoError(t, r.Pack(ctx, nil, ref, tarLayer(t, map[string]string{"v1.txt": "v1"})))
+	require.NoError(t, r.Push(ctx, ref))
+	img, err := r.Images().Get(namespaces.WithNamespace(ctx, namespaces.Default), ref.String())
+	require.NoError(t, err)
+	v1 := image.SimpleReference(fmt.Sprintf("%s/test/resolve@%s", host, img.Target.Digest))
+
+	// Not pulled, so resolved by the registry.
+	r = newRegistry(t, cafile)
+	resolved, err := r.ResolveDigest(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, v1, resolved)
+	require.NoError(t, r.Pull(ctx, ref))
+
+	// Moving the tag doesn't change the digest of the pulled image.
+	other := newRegistry(t, cafile)
+	require.NoError(t, other.Pack(ctx, nil, ref, tarLayer(t, map[string]string{"v2.txt": "v2"})))
+	require.NoError(t, other.Push(ctx, ref))
+	resolved, err = r.ResolveDigest(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, v1, resolved)
+
+	resolved, err = newRegistry(t, cafile).ResolveDigest(ctx, ref)
+	require.NoError(t, err)
+	require.NotEqual(t, v1, resolved)
+
+	_, err = r.ResolveDigest(ctx, image.SimpleReference(host+"/test/missing:v1"))
+	require.Error(t, err)
+
+	// Local images have no digest in a registry.
+	_, err = r.ResolveDigest(ctx, image.SimpleReference("oci-archive:bundle.tar:"+ref.String()))
+	require.Error(t, err)
+}
@@ -3,8 +3,10 @@ package containerdregistry
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/containerd/containerd/remotes"
@@ -15,7 +17,31 @@ import (
 	"github.com/docker/docker/registry"
 )
 
+// NewResolver returns a resolver that authenticates with the credentials of
+// the docker config in configDir, or in the default docker config dir if it is
+// empty. Credential helpers and stores configured there are used as docker
+// would use them.
 func NewResolver(configDir string, insecure bool, roots *x509.CertPool) (remotes.Resolver, error) {
+	cfg, err := loadConfig(configDir)
+	if err != nil {
+		return nil, err
+	}
+	return newResolver(cfg, insecure, roots), nil
+}
+
+// NewResolverForAuthFile returns a resolver that authenticates with the
+// credentials of the auth file at path, which is either a docker config.json
+// or a containers auth.json. Unlike with NewResolver, no default credential
+// store is used unless the file sets one.
+func NewResolverForAuthFile(path string, insecure bool, roots *x509.CertPool) (remotes.Resolver, error) {
+	cfg, err := loadAuthFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newResolver(cfg, insecure, roots), nil
+}
+
+func newResolver(cfg *configfile.ConfigFile, insecure bool, roots *x509.CertPool) remotes.Resolver {
 	transport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -42,11 +68,6 @@ func NewResolver(configDir string, insecure bool, roots *x509.CertPool) (remotes
 
 	client := &http.Client{Transport: transport}
 
-	cfg, err := loadConfig(configDir)
-	if err != nil {
-		return nil, err
-	}
-
 	regopts := []docker.RegistryOpt{
 		docker.WithAuthorizer(docker.NewDockerAuthorizer(
 			docker.WithAuthClient(client),
@@ -64,7 +85,7 @@ func NewResolver(configDir string, insecure bool, roots *x509.CertPool) (remotes
 		Headers: headers,
 	}
 
-	return docker.NewResolver(opts), nil
+	return docker.NewResolver(opts)
 }
 
 func credential(cfg *configfile.ConfigFile) func(string) (string, string, error) {
@@ -102,6 +123,20 @@ func loadConfig(dir string) (*configfile.ConfigFile, error) {
 	return cfg, nil
 }
 
+func loadAuthFile(path string) (*configfile.ConfigFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading registry auth file: %v", err)
+	}
+	defer f.Close()
+
+	cfg := configfile.New(path)
+	if err := cfg.LoadFromReader(f); err != nil {
+		return nil, fmt.Errorf("error parsing registry auth file %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
 // resolveHostname resolves Docker specific hostnames
 func resolveHostname(hostname string) string {
 	switch hostname {
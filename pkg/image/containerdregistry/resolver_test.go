@@ -0,0 +1,114 @@
+package containerdregistry_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+// credentialHelper installs a docker credential helper named name on the
+// PATH, which returns the given credentials for any server.
+func credentialHelper(t *testing.T, name, username, secret string) {
+	dir, err := ioutil.TempDir("", "resolver-test-helper-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	script := fmt.Sprintf(`#!/bin/sh
+read server
+echo '{"ServerURL":"'"$server"'","Username":"%s","Secret":"%s"}'
+`, username, secret)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker-credential-"+name), []byte(script), 0755))
+
+	path := os.Getenv("PATH")
+	require.NoError(t, os.Setenv("PATH", dir+string(os.PathListSeparator)+path))
+	t.Cleanup(func() { os.Setenv("PATH", path) })
+}
+
+func TestRegistryAuth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "", libimage.WithBasicAuth("opm", "s3cr3t"))
+	require.NoError(t, err)
+	rootCAs, err := certs.RootCAs(cafile)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "resolver-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeConfig := func(name, config string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, ioutil.WriteFile(path, []byte(config), 0600))
+		return path
+	}
+	auths := func(username, password string) string {
+		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		return fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, host, auth)
+	}
+
+	authFile := writeConfig("auth.json", auths("opm", "s3cr3t"))
+	wrongAuthFile := writeConfig("wrong.json", auths("opm", "wrong"))
+	configDir := filepath.Dir(writeConfig("docker/config.json", auths("opm", "s3cr3t")))
+	credentialHelper(t, "opmtest", "opm", "s3cr3t")
+	helperFile := writeConfig("helper.json", fmt.Sprintf(`{"credHelpers":{%q:"opmtest"}}`, host))
+	storeFile := writeConfig("store.json", `{"credsStore":"opmtest"}`)
+	helperConfigDir := filepath.Dir(writeConfig("helper/config.json", fmt.Sprintf(`{"credHelpers":{%q:"opmtest"}}`, host)))
+
+	newRegistry := func(opts ...containerdregistry.RegistryOption) (*containerdregistry.Registry, error) {
+		cacheDir, err := ioutil.TempDir("", "resolver-test-cache-")
+		require.NoError(t, err)
+		r, err := containerdregistry.NewRegistry(append([]containerdregistry.RegistryOption{
+			containerdregistry.WithLog(logrus.New().WithField("test", t.Name())),
+			containerdregistry.WithCacheDir(cacheDir),
+			containerdregistry.WithRootCAs(rootCAs),
+			// Keep the credentials of the user running the tests out of the way.
+			containerdregistry.WithResolverConfigDir(filepath.Join(dir, "empty")),
+		}, opts...)...)
+		if err == nil {
+			t.Cleanup(func() { require.NoError(t, r.Destroy()) })
+		}
+		return r, err
+	}
+
+	ref := image.SimpleReference(host + "/test/bundle:v1")
+	r, err := newRegistry(containerdregistry.WithAuthFile(authFile))
+	require.NoError(t, err)
+	require.NoError(t, r.Pack(ctx, nil, ref, tarLayer(t, map[string]string{"bundle.txt": "bundle"})))
+	require.NoError(t, r.Push(ctx, ref))
+
+	for _, tt := range []struct {
+		description string
+		opts        []containerdregistry.RegistryOption
+		assertion   require.ErrorAssertionFunc
+	}{
+		{description: "NoCredentials", assertion: require.Error},
+		{description: "WrongCredentials", opts: []containerdregistry.RegistryOption{containerdregistry.WithAuthFile(wrongAuthFile)}, assertion: require.Error},
+		{description: "AuthFile", opts: []containerdregistry.RegistryOption{containerdregistry.WithAuthFile(authFile)}, assertion: require.NoError},
+		{description: "DockerConfigDir", opts: []containerdregistry.RegistryOption{containerdregistry.WithResolverConfigDir(configDir)}, assertion: require.NoError},
+		{description: "CredentialHelper", opts: []containerdregistry.RegistryOption{containerdregistry.WithAuthFile(helperFile)}, assertion: require.NoError},
+		{description: "CredentialStore", opts: []containerdregistry.RegistryOption{containerdregistry.WithAuthFile(storeFile)}, assertion: require.NoError},
+		{description: "DockerConfigDirCredentialHelper", opts: []containerdregistry.RegistryOption{containerdregistry.WithResolverConfigDir(helperConfigDir)}, assertion: require.NoError},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			r, err := newRegistry(tt.opts...)
+			require.NoError(t, err)
+			tt.assertion(t, r.Pull(ctx, ref))
+		})
+	}
+
+	_, err = newRegistry(containerdregistry.WithAuthFile(filepath.Join(dir, "missing.json")))
+	require.Error(t, err)
+}
@@ -2,11 +2,15 @@ package execregistry
 
 import (
 	"context"
+	"io/ioutil"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 // CommandRunner provides some basic methods for manipulating images via an external container tool.
@@ -17,9 +21,17 @@ type CommandRunner interface {
 }
 
 // Registry enables manipulation of images via exec podman/docker commands.
+//
+// Container tools can't pull images on the local filesystem, see
+// image.ParseLocalReference, so those are handled by a containerd registry
+// instead, created on first use.
 type Registry struct {
 	log *logrus.Entry
 	cmd CommandRunner
+
+	localOnce sync.Once
+	local     *containerdregistry.Registry
+	localErr  error
 }
 
 // Adapt the cmd interface to the registry interface
@@ -35,24 +47,70 @@ func NewRegistry(tool containertools.ContainerTool, logger *logrus.Entry, opts .
 
 // Pull fetches and stores an image by reference.
 func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
-	return r.cmd.Pull(ref.String())
+	if image.IsLocalReference(ref) {
+		local, err := r.localRegistry()
+		if err != nil {
+			return err
+		}
+		return local.Pull(ctx, ref)
+	}
+
+	_, span := tracing.Start(ctx, "execregistry.Pull", tracing.String("image", ref.String()))
+	err := r.cmd.Pull(ref.String())
+	span.EndWithError(err)
+	return err
 }
 
 // Unpack writes the unpackaged content of an image to a directory.
 // If the referenced image does not exist in the registry, an error is returned.
 func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) error {
-	return r.cmd.Unpack(ref.String(), "/.", dir)
+	if image.IsLocalReference(ref) {
+		local, err := r.localRegistry()
+		if err != nil {
+			return err
+		}
+		return local.Unpack(ctx, ref, dir)
+	}
+
+	_, span := tracing.Start(ctx, "execregistry.Unpack", tracing.String("image", ref.String()))
+	err := r.cmd.Unpack(ref.String(), "/.", dir)
+	span.EndWithError(err)
+	return err
 }
 
 // Labels gets the labels for an image reference.
 func (r *Registry) Labels(ctx context.Context, ref image.Reference) (map[string]string, error) {
+	if image.IsLocalReference(ref) {
+		local, err := r.localRegistry()
+		if err != nil {
+			return nil, err
+		}
+		return local.Labels(ctx, ref)
+	}
+
 	return containertools.ImageLabelReader{
 		Cmd:    r.cmd,
 		Logger: r.log,
 	}.GetLabelsFromImage(ref.String())
 }
 
-// Destroy is no-op for exec tools
+// Destroy is no-op for exec tools, but cleans up the registry of local images
+// if any
 func (r *Registry) Destroy() error {
+	if r.local != nil {
+		return r.local.Destroy()
+	}
 	return nil
 }
+
+// localRegistry returns the registry that handles local images.
+func (r *Registry) localRegistry() (*containerdregistry.Registry, error) {
+	r.localOnce.Do(func() {
+		var cacheDir string
+		if cacheDir, r.localErr = ioutil.TempDir("", "local-images-"); r.localErr != nil {
+			return
+		}
+		r.local, r.localErr = containerdregistry.NewRegistry(containerdregistry.WithLog(r.log), containerdregistry.WithCacheDir(cacheDir))
+	})
+	return r.local, r.localErr
+}
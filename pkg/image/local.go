@@ -0,0 +1,102 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
+
+// Schemes of references to images on the local filesystem rather than in a
+// registry, see ParseLocalReference.
+const (
+	// OCILayoutScheme references an image of an OCI image layout directory.
+	OCILayoutScheme = "oci"
+	// OCIArchiveScheme references an image of a tar archive of an OCI image
+	// layout.
+	OCIArchiveScheme = "oci-archive"
+	// DockerArchiveScheme references an image of a tar archive written by
+	// docker save.
+	DockerArchiveScheme = "docker-archive"
+)
+
+// LocalReference is a reference to an image on the local filesystem, in the
+// form SCHEME:PATH[:NAME], such as oci-archive:bundle.tar or
+// oci:layout:quay.io/example/bundle:v1.
+type LocalReference struct {
+	// Scheme is the format of the image, one of OCILayoutScheme,
+	// OCIArchiveScheme and DockerArchiveScheme.
+	Scheme string
+	// Path is the path of the layout or archive.
+	Path string
+	// Name selects the image of the layout or archive by the name it was
+	// given there, its org.opencontainers.image.ref.name annotation or one of
+	// its docker tags. If empty, the layout or archive must hold a single
+	// image.
+	Name string
+}
+
+// ParseLocalReference parses ref as a LocalReference. It returns false if
+// ref has none of the local schemes, in which case it's a reference to an
+// image in a registry.
+func ParseLocalReference(ref Reference) (*LocalReference, bool, error) {
+	s := ref.String()
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return nil, false, nil
+	}
+	scheme, rest := s[:i], s[i+1:]
+	switch scheme {
+	case OCILayoutScheme, OCIArchiveScheme, DockerArchiveScheme:
+	default:
+		return nil, false, nil
+	}
+
+	local := &LocalReference{Scheme: scheme, Path: rest}
+	if i := strings.Index(rest, ":"); i >= 0 {
+		local.Path, local.Name = rest[:i], rest[i+1:]
+	}
+	if local.Path == "" {
+		return nil, true, fmt.Errorf("invalid reference %s: missing path", s)
+	}
+	return local, true, nil
+}
+
+// IsLocalReference returns true if ref has one of the schemes of a
+// LocalReference.
+func IsLocalReference(ref Reference) bool {
+	_, ok, _ := ParseLocalReference(ref)
+	return ok
+}
+
+// Name returns the name the image ref refers to is recorded by, such as the
+// bundle path of a bundle: ref itself for an image in a registry, and the
+// name the image was selected by for a LocalReference. A local image must
+// be selected by a full image reference with a tag or digest, since its
+// path only exists on this filesystem.
+func Name(ref Reference) (string, error) {
+	local, ok, err := ParseLocalReference(ref)
+	if err != nil || !ok {
+		return ref.String(), err
+	}
+	if local.Name == "" {
+		return "", fmt.Errorf("local image %s has no name, select it by the image reference it was saved as", ref)
+	}
+	named, err := reference.ParseNormalizedNamed(local.Name)
+	if err != nil {
+		return "", fmt.Errorf("local image %s is not named by an image reference: %v", ref, err)
+	}
+	_, tagged := named.(reference.Tagged)
+	_, digested := named.(reference.Digested)
+	if !tagged && !digested {
+		return "", fmt.Errorf("local image %s is not named by an image reference with a tag or digest", ref)
+	}
+	return local.Name, nil
+}
+
+func (l *LocalReference) String() string {
+	if l.Name == "" {
+		return l.Scheme + ":" + l.Path
+	}
+	return l.Scheme + ":" + l.Path + ":" + l.Name
+}
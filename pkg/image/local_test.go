@@ -0,0 +1,290 @@
+package image_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
+)
+
+func TestParseLocalReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    *image.LocalReference
+		local   bool
+		wantErr bool
+	}{
+		{ref: "quay.io/example/bundle:v1"},
+		{ref: "localhost:5000/example/bundle@sha256:3816b6daf9b66d6ced6f0f966314e2d4f894982c6b1493061502f8c2bf86ac84"},
+		{ref: "bundle"},
+		{ref: "oci:/tmp/layout", local: true, want: &image.LocalReference{Scheme: image.OCILayoutScheme, Path: "/tmp/layout"}},
+		{ref: "oci:layout:v1", local: true, want: &image.LocalReference{Scheme: image.OCILayoutScheme, Path: "layout", Name: "v1"}},
+		{ref: "oci-archive:bundle.tar:quay.io/example/bundle:v1", local: true, want: &image.LocalReference{Scheme: image.OCIArchiveScheme, Path: "bundle.tar", Name: "quay.io/example/bundle:v1"}},
+		{ref: "docker-archive:/tmp/bundle.tar", local: true, want: &image.LocalReference{Scheme: image.DockerArchiveScheme, Path: "/tmp/bundle.tar"}},
+		{ref: "docker-archive:", local: true, wantErr: true},
+		{ref: "oci::v1", local: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, local, err := image.ParseLocalReference(image.SimpleReference(tt.ref))
+			require.Equal(t, tt.local, local)
+			require.Equal(t, tt.local, image.IsLocalReference(image.SimpleReference(tt.ref)))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			if got != nil {
+				require.Equal(t, tt.ref, got.String())
+			}
+		})
+	}
+}
+
+// localImage is the content of an image with a single layer.
+type localImage struct {
+	layer, config, manifest []byte
+}
+
+func newLocalImage(t *testing.T, labels map[string]string, files map[string]string) localImage {
+	var layer bytes.Buffer
+	tw := tar.NewWriter(&layer)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(data))}))
+		_, err := tw.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	config, err := json.Marshal(ocispec.Image{
+		Architecture: "amd64",
+		OS:           "linux",
+		Config:       ocispec.ImageConfig{Labels: labels},
+		RootFS:       ocispec.RootFS{Type: "layers", DiffIDs: []digest.Digest{digest.FromBytes(layer.Bytes())}},
+	})
+	require.NoError(t, err)
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    descriptor(ocispec.MediaTypeImageConfig, config),
+		Layers:    []ocispec.Descriptor{descriptor(ocispec.MediaTypeImageLayer, layer.Bytes())},
+	})
+	require.NoError(t, err)
+	return localImage{layer: layer.Bytes(), config: config, manifest: manifest}
+}
+
+func descriptor(mediaType string, data []byte) ocispec.Descriptor {
+	return ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(data), Size: int64(len(data))}
+}
+
+// writeFiles writes files to dir, each at its key.
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, data, 0644))
+	}
+}
+
+// writeArchive writes files to a gzipped tar archive at path.
+func writeArchive(t *testing.T, path string, files map[string][]byte) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(data))}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+}
+
+// layoutFiles returns the files of an OCI image layout of images, each named
+// by its key.
+func layoutFiles(t *testing.T, images map[string]localImage) map[string][]byte {
+	files := map[string][]byte{ocispec.ImageLayoutFile: []byte(`{"imageLayoutVersion":"1.0.0"}`)}
+	index := ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}}
+	for name, img := range images {
+		for _, blob := range [][]byte{img.layer, img.config, img.manifest} {
+			dgst := digest.FromBytes(blob)
+			files[filepath.Join("blobs", dgst.Algorithm().String(), dgst.Hex())] = blob
+		}
+		desc := descriptor(ocispec.MediaTypeImageManifest, img.manifest)
+		desc.Annotations = map[string]string{ocispec.AnnotationRefName: name}
+		index.Manifests = append(index.Manifests, desc)
+	}
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+	files["index.json"] = data
+	return files
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "quay.io/example/bundle:v1", want: "quay.io/example/bundle:v1"},
+		{ref: "oci-archive:bundle.tar:quay.io/example/bundle:v1", want: "quay.io/example/bundle:v1"},
+		{ref: "oci:layout:quay.io/example/bundle@sha256:3816b6daf9b66d6ced6f0f966314e2d4f894982c6b1493061502f8c2bf86ac84", want: "quay.io/example/bundle@sha256:3816b6daf9b66d6ced6f0f966314e2d4f894982c6b1493061502f8c2bf86ac84"},
+		{ref: "docker-archive:/tmp/bundle.tar", wantErr: true},
+		{ref: "oci:layout:v1", wantErr: true},
+		{ref: "oci:layout:Bundle:v1", wantErr: true},
+		{ref: "docker-archive:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := image.Name(image.SimpleReference(tt.ref))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLocalReferences(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-images-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	v1 := newLocalImage(t, map[string]string{"version": "v1"}, map[string]string{"manifests/version.txt": "v1"})
+	v2 := newLocalImage(t, map[string]string{"version": "v2"}, map[string]string{"manifests/version.txt": "v2"})
+
+	layout := filepath.Join(dir, "layout")
+	writeFiles(t, layout, layoutFiles(t, map[string]localImage{"v1": v1, "v2": v2}))
+	single := filepath.Join(dir, "single")
+	writeFiles(t, single, layoutFiles(t, map[string]localImage{"v1": v1}))
+	ociArchive := filepath.Join(dir, "oci.tar.gz")
+	writeArchive(t, ociArchive, layoutFiles(t, map[string]localImage{"v1": v1, "v2": v2}))
+	incomplete := layoutFiles(t, map[string]localImage{"v1": v1})
+	delete(incomplete, filepath.Join("blobs", "sha256", digest.FromBytes(v1.layer).Hex()))
+	incompleteArchive := filepath.Join(dir, "incomplete.tar.gz")
+	writeArchive(t, incompleteArchive, incomplete)
+
+	// Layouts are checked against the digests and sizes they claim.
+	tampered := layoutFiles(t, map[string]localImage{"v1": v1})
+	layerPath := filepath.Join("blobs", "sha256", digest.FromBytes(v1.layer).Hex())
+	tampered[layerPath] = bytes.Repeat([]byte{0}, len(v1.layer))
+	tamperedLayout := filepath.Join(dir, "tampered")
+	writeFiles(t, tamperedLayout, tampered)
+	truncated := layoutFiles(t, map[string]localImage{"v1": v1})
+	truncated[layerPath] = v1.layer[:len(v1.layer)-1]
+	truncatedLayout := filepath.Join(dir, "truncated")
+	writeFiles(t, truncatedLayout, truncated)
+	escaping := layoutFiles(t, map[string]localImage{"v1": v1})
+	escapingIndex, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageManifest, Digest: "sha256:../../../single/index.json", Size: int64(len(escaping["index.json"]))}},
+	})
+	require.NoError(t, err)
+	escaping["index.json"] = escapingIndex
+	escapingLayout := filepath.Join(dir, "escaping")
+	writeFiles(t, escapingLayout, escaping)
+
+	manifest, err := json.Marshal([]map[string]interface{}{
+		{"Config": "v1.json", "RepoTags": []string{"quay.io/example/bundle:v1"}, "Layers": []string{"v1/layer.tar"}},
+		{"Config": "v2.json", "RepoTags": []string{"bundle:v2"}, "Layers": []string{"v2/layer.tar"}},
+	})
+	require.NoError(t, err)
+	dockerArchive := filepath.Join(dir, "docker.tar.gz")
+	writeArchive(t, dockerArchive, map[string][]byte{
+		"manifest.json": manifest,
+		"v1.json":       v1.config,
+		"v1/layer.tar":  v1.layer,
+		"v2.json":       v2.config,
+		"v2/layer.tar":  v2.layer,
+	})
+
+	registries := map[string]func(t *testing.T) image.Registry{
+		"containerd": func(t *testing.T) image.Registry {
+			cacheDir, err := ioutil.TempDir("", "local-images-cache-")
+			require.NoError(t, err)
+			r, err := containerdregistry.NewRegistry(
+				containerdregistry.WithLog(logrus.New().WithField("test", t.Name())),
+				containerdregistry.WithCacheDir(cacheDir),
+			)
+			require.NoError(t, err)
+			return r
+		},
+		// Container tools can't pull local images, so the exec registry
+		// handles them without running any.
+		"exec": func(t *testing.T) image.Registry {
+			r, err := execregistry.NewRegistry(containertools.DockerTool, logrus.New().WithField("test", t.Name()))
+			require.NoError(t, err)
+			return r
+		},
+	}
+	tests := []struct {
+		ref     string
+		version string
+	}{
+		{ref: "oci:" + layout + ":v2", version: "v2"},
+		{ref: "oci:" + single, version: "v1"},
+		{ref: "oci:" + layout},
+		{ref: "oci:" + layout + ":v3"},
+		{ref: "oci:" + filepath.Join(dir, "missing")},
+		{ref: "oci:" + tamperedLayout},
+		{ref: "oci:" + truncatedLayout},
+		{ref: "oci:" + escapingLayout},
+		{ref: "oci-archive:" + ociArchive + ":v1", version: "v1"},
+		{ref: "oci-archive:" + ociArchive},
+		{ref: "oci-archive:" + incompleteArchive},
+		{ref: "docker-archive:" + dockerArchive + ":quay.io/example/bundle:v1", version: "v1"},
+		{ref: "docker-archive:" + dockerArchive + ":docker.io/library/bundle:v2", version: "v2"},
+		{ref: "docker-archive:" + dockerArchive},
+		{ref: "docker-archive:" + ociArchive + ":v1"},
+	}
+	for name, newRegistry := range registries {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.ref[len(dir):], func(t *testing.T) {
+					r := newRegistry(t)
+					defer func() { require.NoError(t, r.Destroy()) }()
+
+					ctx := context.Background()
+					ref := image.SimpleReference(tt.ref)
+					err := r.Pull(ctx, ref)
+					if tt.version == "" {
+						require.Error(t, err)
+						return
+					}
+					require.NoError(t, err)
+
+					labels, err := r.Labels(ctx, ref)
+					require.NoError(t, err)
+					require.Equal(t, map[string]string{"version": tt.version}, labels)
+
+					unpacked, err := ioutil.TempDir("", "local-images-unpacked-")
+					require.NoError(t, err)
+					defer os.RemoveAll(unpacked)
+					require.NoError(t, r.Unpack(ctx, ref, unpacked))
+					data, err := ioutil.ReadFile(filepath.Join(unpacked, "manifests", "version.txt"))
+					require.NoError(t, err)
+					require.Equal(t, tt.version, string(data))
+				})
+			}
+		})
+	}
+}
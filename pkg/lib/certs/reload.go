@@ -0,0 +1,212 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileReloader calls load whenever any of a set of files changes. Files are
+// checked when they are used, so changes are picked up by the next TLS
+// handshake without watching the filesystem. Kubernetes updates mounted
+// secrets by swapping symlinks, which changes the stamps of the files they
+// resolve to.
+type fileReloader struct {
+	files  []string
+	load   func() error
+	logger logrus.FieldLogger
+
+	mu     sync.Mutex
+	stamps []fileStamp
+}
+
+func newFileReloader(logger logrus.FieldLogger, load func() error, files ...string) (*fileReloader, error) {
+	r := &fileReloader{files: files, load: load, logger: logger}
+	stamps, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := load(); err != nil {
+		return nil, err
+	}
+	r.stamps = stamps
+	return r, nil
+}
+
+func (r *fileReloader) stat() ([]fileStamp, error) {
+	var stamps []fileStamp
+	for _, f := range r.files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, fileStamp{modTime: info.ModTime(), size: info.Size()})
+	}
+	return stamps, nil
+}
+
+// reload calls load if the files have changed since they were last loaded.
+// Failures are logged and the previously loaded contents are kept, since
+// files are often updated one at a time.
+func (r *fileReloader) reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stamps, err := r.stat()
+	if err != nil {
+		r.logger.WithError(err).Warn("unable to check certificate files for changes")
+		return
+	}
+	changed := false
+	for i := range stamps {
+		if stamps[i] != r.stamps[i] {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := r.load(); err != nil {
+		r.logger.WithError(err).WithField("files", r.files).Warn("unable to reload certificate files, keeping previous contents")
+		return
+	}
+	r.stamps = stamps
+	r.logger.WithField("files", r.files).Info("reloaded certificate files")
+}
+
+// KeyPairReloader provides a certificate and key read from files, reloading
+// them when either file changes.
+type KeyPairReloader struct {
+	reloader          *fileReloader
+	certFile, keyFile string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+func NewKeyPairReloader(certFile, keyFile string, logger logrus.FieldLogger) (*KeyPairReloader, error) {
+	kp := &KeyPairReloader{certFile: certFile, keyFile: keyFile}
+	r, err := newFileReloader(logger, kp.load, certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key pair %q, %q: %v", certFile, keyFile, err)
+	}
+	kp.reloader = r
+	return kp, nil
+}
+
+func (kp *KeyPairReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		return err
+	}
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	kp.cert = &cert
+	return nil
+}
+
+// Certificate returns the current certificate, reloading it first if its
+// files have changed.
+func (kp *KeyPairReloader) Certificate() *tls.Certificate {
+	kp.reloader.reload()
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.cert
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (kp *KeyPairReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return kp.Certificate(), nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+func (kp *KeyPairReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return kp.Certificate(), nil
+}
+
+// CertPoolReloader provides a pool of the certificates in a PEM file,
+// reloading it when the file changes. Unlike RootCAs, the pool does not
+// include the system roots.
+type CertPoolReloader struct {
+	reloader *fileReloader
+	caFile   string
+
+	mu   sync.RWMutex
+	pool *x509.CertPool
+}
+
+func NewCertPoolReloader(caFile string, logger logrus.FieldLogger) (*CertPoolReloader, error) {
+	cp := &CertPoolReloader{caFile: caFile}
+	r, err := newFileReloader(logger, cp.load, caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificates from %q: %v", caFile, err)
+	}
+	cp.reloader = r
+	return cp, nil
+}
+
+func (cp *CertPoolReloader) load() error {
+	data, err := ioutil.ReadFile(cp.caFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no certificates found in %s", cp.caFile)
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.pool = pool
+	return nil
+}
+
+// Pool returns the current pool, reloading it first if its file has changed.
+func (cp *CertPoolReloader) Pool() *x509.CertPool {
+	cp.reloader.reload()
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+	return cp.pool
+}
+
+// ServerTLSConfig returns a TLS config that serves the certificate and key in
+// certFile and keyFile. If clientCAFile is set, clients must present a
+// certificate signed by one of the certificates it contains. All files are
+// reloaded when they change.
+func ServerTLSConfig(certFile, keyFile, clientCAFile string, logger logrus.FieldLogger) (*tls.Config, error) {
+	kp, err := NewKeyPairReloader(certFile, keyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: kp.GetCertificate,
+	}
+	if clientCAFile == "" {
+		return config, nil
+	}
+
+	clientCAs, err := NewCertPoolReloader(clientCAFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	base := config.Clone()
+	base.ClientAuth = tls.RequireAndVerifyClientCert
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		c.ClientCAs = clientCAs.Pool()
+		return c, nil
+	}
+	return config, nil
+}
@@ -0,0 +1,108 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSigned writes a new self-signed certificate and its key to
+// certFile and keyFile, returning the certificate.
+func writeSelfSigned(t *testing.T, name, certFile, keyFile string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	touch(t, certFile)
+	return cert
+}
+
+var touched time.Duration
+
+// touch moves the modification time of file forward, so rewrites are noticed
+// on filesystems with coarse timestamps.
+func touch(t *testing.T, file string) {
+	touched += time.Minute
+	later := time.Now().Add(touched)
+	require.NoError(t, os.Chtimes(file, later, later))
+}
+
+func leafName(t *testing.T, cert *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestKeyPairReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	_, err = NewKeyPairReloader(certFile, keyFile, logrus.New())
+	require.Error(t, err)
+
+	writeSelfSigned(t, "first", certFile, keyFile)
+	kp, err := NewKeyPairReloader(certFile, keyFile, logrus.New())
+	require.NoError(t, err)
+	require.Equal(t, "first", leafName(t, kp.Certificate()))
+
+	writeSelfSigned(t, "second", certFile, keyFile)
+	cert, err := kp.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "second", leafName(t, cert))
+
+	// A certificate that doesn't match its key is not loaded.
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("invalid"), 0600))
+	touch(t, keyFile)
+	require.Equal(t, "second", leafName(t, kp.Certificate()))
+}
+
+func TestCertPoolReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+
+	first := writeSelfSigned(t, "first", caFile, keyFile)
+	cp, err := NewCertPoolReloader(caFile, logrus.New())
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{first.RawSubject}, cp.Pool().Subjects())
+
+	second := writeSelfSigned(t, "second", caFile, keyFile)
+	require.Equal(t, [][]byte{second.RawSubject}, cp.Pool().Subjects())
+
+	require.NoError(t, ioutil.WriteFile(caFile, []byte("invalid"), 0600))
+	touch(t, caFile)
+	require.Equal(t, [][]byte{second.RawSubject}, cp.Pool().Subjects())
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/lint"
 )
 
 // ValidateConfig takes a directory containing the declarative config file(s)
@@ -27,3 +28,23 @@ func ValidateConfig(directory string) error {
 	}
 	return nil
 }
+
+// LintConfig validates the declarative config file(s) in a directory as
+// ValidateConfig does, and then runs the linter over the upgrade graph.
+// Inputs:
+// directory: the directory where declarative config file(s) exist
+// linter: the linter whose enabled rules are run
+// Outputs:
+// []lint.Finding: the findings of the linter, sorted by location
+// error: an error if the configs could not be loaded or are invalid
+func LintConfig(directory string, linter *lint.Linter) ([]lint.Finding, error) {
+	cfg, err := declcfg.LoadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	m, err := declcfg.ConvertToModel(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	return linter.Lint(m), nil
+}
@@ -17,10 +17,13 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/docker/distribution/configuration"
+	dcontext "github.com/docker/distribution/context"
 	"github.com/docker/distribution/registry"
+	"github.com/docker/distribution/registry/auth"
 	_ "github.com/docker/distribution/registry/storage/driver/filesystem" // Driver for persisting docker image data to the filesystem.
 	_ "github.com/docker/distribution/registry/storage/driver/inmemory"   // Driver for keeping docker image data in memory.
 	"github.com/phayes/freeport"
@@ -29,6 +32,58 @@ import (
 
 type ConfigOpt func(*configuration.Configuration)
 
+const basicAuthName = "opm-basic"
+
+var registerBasicAuth sync.Once
+
+// WithBasicAuth requires clients of the registry to authenticate as username
+// with password using HTTP basic authentication.
+func WithBasicAuth(username, password string) ConfigOpt {
+	registerBasicAuth.Do(func() {
+		if err := auth.Register(basicAuthName, auth.InitFunc(newBasicAccessController)); err != nil {
+			panic(err)
+		}
+	})
+	return func(config *configuration.Configuration) {
+		config.Auth = configuration.Auth{basicAuthName: configuration.Parameters{
+			"username": username,
+			"password": password,
+		}}
+	}
+}
+
+type basicAccessController struct {
+	username, password string
+}
+
+func newBasicAccessController(options map[string]interface{}) (auth.AccessController, error) {
+	username, _ := options["username"].(string)
+	password, _ := options["password"].(string)
+	return &basicAccessController{username: username, password: password}, nil
+}
+
+func (c *basicAccessController) Authorized(ctx context.Context, access ...auth.Access) (context.Context, error) {
+	req, err := dcontext.GetRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if username, password, ok := req.BasicAuth(); !ok || username != c.username || password != c.password {
+		return nil, basicChallenge{}
+	}
+	return auth.WithUser(ctx, auth.UserInfo{Name: c.username}), nil
+}
+
+// basicChallenge asks the client to authenticate with basic authentication.
+type basicChallenge struct{}
+
+func (basicChallenge) Error() string {
+	return "basic authentication required"
+}
+
+func (basicChallenge) SetHeaders(r *http.Request, w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="opm"`)
+}
+
 // RunDockerRegistry runs a docker registry on an available port and returns its host string if successful, otherwise it returns an error.
 // If the rootDir argument isn't empty, the registry is configured to use this as the root directory for persisting image data to the filesystem.
 // If the rootDir argument is empty, the registry is configured to keep image data in memory.
@@ -110,7 +165,8 @@ func RunDockerRegistry(ctx context.Context, rootDir string, configOpts ...Config
 		if err != nil {
 			return false, nil
 		}
-		if r.StatusCode == http.StatusOK {
+		// Registries requiring authentication are ready once they ask for it.
+		if r.StatusCode == http.StatusOK || r.StatusCode == http.StatusUnauthorized {
 			return true, nil
 		}
 		return false, nil
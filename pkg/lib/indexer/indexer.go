@@ -1,6 +1,7 @@
 package indexer
 
 import (
+	"archive/tar"
 	"context"
 	"fmt"
 	"io"
@@ -10,7 +11,9 @@ import (
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -19,10 +22,10 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
-	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
-	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	"github.com/operator-framework/operator-registry/pkg/lib/config"
 	"github.com/operator-framework/operator-registry/pkg/lib/registry"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 	pregistry "github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -32,6 +35,7 @@ const (
 	defaultImageTag           = "operator-registry-index:latest"
 	defaultDatabaseFolder     = "database"
 	defaultDatabaseFile       = "index.db"
+	defaultConfigsFolder      = "configs"
 	tmpDirPrefix              = "index_tmp_"
 	tmpBuildDirPrefix         = "index_build_tmp"
 	concurrencyLimitForExport = 10
@@ -62,21 +66,32 @@ type AddToIndexRequest struct {
 	Bundles           []string
 	Tag               string
 	Mode              pregistry.Mode
-	CaFile            string
-	SkipTLS           bool
-	Overwrite         bool
-	EnableAlpha       bool
+	registry.PullOptions
+	Overwrite   bool
+	EnableAlpha bool
+	PullWorkers int
+	// PinDigests pins the bundle images and their related images to digests,
+	// see registry.PinBundleImages.
+	PinDigests bool
 }
 
 // AddToIndex is an aggregate API used to generate a registry index image with additional bundles
-func (i ImageIndexer) AddToIndex(request AddToIndexRequest) error {
+func (i ImageIndexer) AddToIndex(request AddToIndexRequest) (err error) {
+	ctx, span := tracing.Start(context.TODO(), "indexer.AddToIndex",
+		tracing.Int("bundles", len(request.Bundles)),
+		tracing.String("from_index", request.FromIndex),
+		tracing.String("tag", request.Tag),
+	)
+	defer func() { span.EndWithError(err) }()
+
 	buildDir, outDockerfile, cleanup, err := buildContext(request.Generate, request.OutDockerfile)
 	defer cleanup()
 	if err != nil {
 		return err
 	}
 
-	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLS)
+	pull := request.PullOptions
+	databasePath, err := i.extractDatabase(ctx, buildDir, request.FromIndex, pull)
 	if err != nil {
 		return err
 	}
@@ -87,10 +102,13 @@ func (i ImageIndexer) AddToIndex(request AddToIndexRequest) error {
 		InputDatabase: databasePath,
 		Permissive:    request.Permissive,
 		Mode:          request.Mode,
-		SkipTLS:       request.SkipTLS,
 		ContainerTool: i.PullTool,
 		Overwrite:     request.Overwrite,
 		EnableAlpha:   request.EnableAlpha,
+		PullWorkers:   request.PullWorkers,
+		PinDigests:    request.PinDigests,
+		PullOptions:   request.PullOptions,
+		Context:       ctx,
 	}
 
 	// Add the bundles to the registry
@@ -112,7 +130,13 @@ func (i ImageIndexer) AddToIndex(request AddToIndexRequest) error {
 	}
 
 	// build the dockerfile
-	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	err = i.buildImage(ctx, buildImageOptions{
+		DockerfilePath:    outDockerfile,
+		DatabasePath:      databasePath,
+		BinarySourceImage: request.BinarySourceImage,
+		Tag:               request.Tag,
+		PullOptions:       pull,
+	})
 	if err != nil {
 		return err
 	}
@@ -129,8 +153,7 @@ type DeleteFromIndexRequest struct {
 	OutDockerfile     string
 	Tag               string
 	Operators         []string
-	SkipTLS           bool
-	CaFile            string
+	registry.PullOptions
 }
 
 // DeleteFromIndex is an aggregate API used to generate a registry index image
@@ -142,7 +165,8 @@ func (i ImageIndexer) DeleteFromIndex(request DeleteFromIndexRequest) error {
 		return err
 	}
 
-	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLS)
+	pull := request.PullOptions
+	databasePath, err := i.extractDatabase(context.TODO(), buildDir, request.FromIndex, pull)
 	if err != nil {
 		return err
 	}
@@ -172,7 +196,13 @@ func (i ImageIndexer) DeleteFromIndex(request DeleteFromIndexRequest) error {
 	}
 
 	// build the dockerfile
-	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	err = i.buildImage(context.TODO(), buildImageOptions{
+		DockerfilePath:    outDockerfile,
+		DatabasePath:      databasePath,
+		BinarySourceImage: request.BinarySourceImage,
+		Tag:               request.Tag,
+		PullOptions:       pull,
+	})
 	if err != nil {
 		return err
 	}
@@ -187,8 +217,7 @@ type PruneStrandedFromIndexRequest struct {
 	FromIndex         string
 	OutDockerfile     string
 	Tag               string
-	CaFile            string
-	SkipTLS           bool
+	registry.PullOptions
 }
 
 // PruneStrandedFromIndex is an aggregate API used to generate a registry index image
@@ -200,7 +229,8 @@ func (i ImageIndexer) PruneStrandedFromIndex(request PruneStrandedFromIndexReque
 		return err
 	}
 
-	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLS)
+	pull := request.PullOptions
+	databasePath, err := i.extractDatabase(context.TODO(), buildDir, request.FromIndex, pull)
 	if err != nil {
 		return err
 	}
@@ -228,7 +258,13 @@ func (i ImageIndexer) PruneStrandedFromIndex(request PruneStrandedFromIndexReque
 	}
 
 	// build the dockerfile
-	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	err = i.buildImage(context.TODO(), buildImageOptions{
+		DockerfilePath:    outDockerfile,
+		DatabasePath:      databasePath,
+		BinarySourceImage: request.BinarySourceImage,
+		Tag:               request.Tag,
+		PullOptions:       pull,
+	})
 	if err != nil {
 		return err
 	}
@@ -244,8 +280,7 @@ type PruneFromIndexRequest struct {
 	OutDockerfile     string
 	Tag               string
 	Packages          []string
-	CaFile            string
-	SkipTLS           bool
+	registry.PullOptions
 }
 
 func (i ImageIndexer) PruneFromIndex(request PruneFromIndexRequest) error {
@@ -255,7 +290,8 @@ func (i ImageIndexer) PruneFromIndex(request PruneFromIndexRequest) error {
 		return err
 	}
 
-	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLS)
+	pull := request.PullOptions
+	databasePath, err := i.extractDatabase(context.TODO(), buildDir, request.FromIndex, pull)
 	if err != nil {
 		return err
 	}
@@ -285,7 +321,13 @@ func (i ImageIndexer) PruneFromIndex(request PruneFromIndexRequest) error {
 	}
 
 	// build the dockerfile
-	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	err = i.buildImage(context.TODO(), buildImageOptions{
+		DockerfilePath:    outDockerfile,
+		DatabasePath:      databasePath,
+		BinarySourceImage: request.BinarySourceImage,
+		Tag:               request.Tag,
+		PullOptions:       pull,
+	})
 	if err != nil {
 		return err
 	}
@@ -293,15 +335,21 @@ func (i ImageIndexer) PruneFromIndex(request PruneFromIndexRequest) error {
 	return nil
 }
 
-// ExtractDatabase sets a temp directory for unpacking an image
+// ExtractDatabase sets a temp directory for unpacking an image. The image is
+// pulled with the default registry credentials; the requests of the indexer
+// carry their own auth file.
 func (i ImageIndexer) ExtractDatabase(buildDir, fromIndex, caFile string, skipTLS bool) (string, error) {
+	return i.extractDatabase(context.TODO(), buildDir, fromIndex, registry.PullOptions{CaFile: caFile, SkipTLS: skipTLS})
+}
+
+func (i ImageIndexer) extractDatabase(ctx context.Context, buildDir, fromIndex string, pull registry.PullOptions) (string, error) {
 	tmpDir, err := ioutil.TempDir("./", tmpDirPrefix)
 	if err != nil {
 		return "", err
 	}
 	defer os.RemoveAll(tmpDir)
 
-	databaseFile, err := i.getDatabaseFile(tmpDir, fromIndex, caFile, skipTLS)
+	databaseFile, err := i.getDatabaseFile(ctx, tmpDir, fromIndex, pull)
 	if err != nil {
 		return "", err
 	}
@@ -309,30 +357,40 @@ func (i ImageIndexer) ExtractDatabase(buildDir, fromIndex, caFile string, skipTL
 	return copyDatabaseTo(databaseFile, filepath.Join(buildDir, defaultDatabaseFolder))
 }
 
-func (i ImageIndexer) getDatabaseFile(workingDir, fromIndex, caFile string, skipTLS bool) (string, error) {
+func (i ImageIndexer) getDatabaseFile(ctx context.Context, workingDir, fromIndex string, pull registry.PullOptions) (string, error) {
 	if fromIndex == "" {
 		return path.Join(workingDir, defaultDatabaseFile), nil
 	}
+	return i.unpackIndex(ctx, workingDir, fromIndex, pull, containertools.DbLocationLabel)
+}
+
+// ExtractCatalogRequest defines the parameters to send to the ExtractCatalog API
+type ExtractCatalogRequest struct {
+	// WorkingDir is the directory the index image is unpacked into.
+	WorkingDir string
+	Index      string
+	registry.PullOptions
+}
+
+// ExtractCatalog pulls an index image and unpacks it into the working
+// directory of the request, returning the path of the catalog it serves:
+// either its database or its directory of declarative configs.
+func (i ImageIndexer) ExtractCatalog(request ExtractCatalogRequest) (string, error) {
+	return i.unpackIndex(context.TODO(), request.WorkingDir, request.Index, request.PullOptions, containertools.DbLocationLabel, containertools.ConfigsLocationLabel)
+}
+
+// unpackIndex pulls an index image and unpacks it into workingDir, returning
+// the path held by the first of locationLabels the image has.
+func (i ImageIndexer) unpackIndex(ctx context.Context, workingDir, fromIndex string, pull registry.PullOptions, locationLabels ...string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "indexer.unpackIndex", tracing.String("image", fromIndex))
+	defer func() { span.EndWithError(err) }()
 
 	// Pull the fromIndex
 	i.Logger.Infof("Pulling previous image %s to get metadata", fromIndex)
 
-	var reg image.Registry
-	var rerr error
-	switch i.PullTool {
-	case containertools.NoneTool:
-		rootCAs, err := certs.RootCAs(caFile)
-		if err != nil {
-			return "", fmt.Errorf("failed to get RootCAs: %v", err)
-		}
-		reg, rerr = containerdregistry.NewRegistry(containerdregistry.SkipTLS(skipTLS), containerdregistry.WithLog(i.Logger), containerdregistry.WithRootCAs(rootCAs))
-	case containertools.PodmanTool:
-		fallthrough
-	case containertools.DockerTool:
-		reg, rerr = execregistry.NewRegistry(i.PullTool, i.Logger, containertools.SkipTLS(skipTLS))
-	}
-	if rerr != nil {
-		return "", rerr
+	reg, err := pull.NewRegistry(i.PullTool, i.Logger)
+	if err != nil {
+		return "", err
 	}
 	defer func() {
 		if err := reg.Destroy(); err != nil {
@@ -342,26 +400,32 @@ func (i ImageIndexer) getDatabaseFile(workingDir, fromIndex, caFile string, skip
 
 	imageRef := image.SimpleReference(fromIndex)
 
-	if err := reg.Pull(context.TODO(), imageRef); err != nil {
+	if err := reg.Pull(ctx, imageRef); err != nil {
 		return "", err
 	}
 
-	// Get the old index image's dbLocationLabel to find this path
-	labels, err := reg.Labels(context.TODO(), imageRef)
+	// Get the old index image's location label to find this path
+	labels, err := reg.Labels(ctx, imageRef)
 	if err != nil {
 		return "", err
 	}
 
-	dbLocation, ok := labels[containertools.DbLocationLabel]
-	if !ok {
-		return "", fmt.Errorf("index image %s missing label %s", fromIndex, containertools.DbLocationLabel)
+	var location string
+	for _, label := range locationLabels {
+		if l, ok := labels[label]; ok {
+			location = l
+			break
+		}
+	}
+	if location == "" {
+		return "", fmt.Errorf("index image %s missing label %s", fromIndex, strings.Join(locationLabels, " or "))
 	}
 
-	if err := reg.Unpack(context.TODO(), imageRef, workingDir); err != nil {
+	if err := reg.Unpack(ctx, imageRef, workingDir); err != nil {
 		return "", err
 	}
 
-	return path.Join(workingDir, dbLocation), nil
+	return containertools.JoinLocation(workingDir, location)
 }
 
 func copyDatabaseTo(databaseFile, targetDir string) (string, error) {
@@ -436,18 +500,206 @@ func buildContext(generate bool, requestedDockerfile string) (buildDir, outDocke
 	return
 }
 
-func build(dockerfilePath, imageTag string, commandRunner containertools.CommandRunner, logger *logrus.Entry) error {
+func build(ctx context.Context, dockerfilePath, imageTag string, commandRunner containertools.CommandRunner, logger *logrus.Entry) (err error) {
 	if imageTag == "" {
 		imageTag = defaultImageTag
 	}
 
+	_, span := tracing.Start(ctx, "indexer.build", tracing.String("tag", imageTag))
+	defer func() { span.EndWithError(err) }()
+
 	logger.Debugf("building container image: %s", imageTag)
 
-	err := commandRunner.Build(dockerfilePath, imageTag)
+	err = commandRunner.Build(dockerfilePath, imageTag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// buildImageOptions describes an index image built by buildImage.
+type buildImageOptions struct {
+	// DockerfilePath is the Dockerfile built by the build tool of the indexer.
+	DockerfilePath string
+	// DatabasePath is the database added to the binary image when the build tool is none.
+	DatabasePath string
+
+	BinarySourceImage string
+	Tag               string
+	registry.PullOptions
+}
+
+// buildImage builds the index image with the build tool of the indexer. If the build tool is none, the image is
+// built without a container tool by adding the database as a layer on top of the binary image, and is pushed to
+// the registry of its tag, since there is no local image storage to keep it in.
+func (i ImageIndexer) buildImage(ctx context.Context, opts buildImageOptions) error {
+	if i.BuildTool != containertools.NoneTool {
+		return build(ctx, opts.DockerfilePath, opts.Tag, i.CommandRunner, i.Logger)
+	}
+
+	layer, err := fileLayer(opts.DatabasePath, containertools.DefaultDbLocation)
+	if err != nil {
+		return err
+	}
+	defer layer.Close()
+
+	return i.packImage(ctx, opts, layer,
+		containerdregistry.WithLabels(map[string]string{containertools.DbLocationLabel: containertools.DefaultDbLocation}),
+		containerdregistry.WithExposedPorts("50051/tcp"),
+		containerdregistry.WithEntrypoint("/bin/opm"),
+		containerdregistry.WithCmd("registry", "serve", "--database", containertools.DefaultDbLocation),
+	)
+}
+
+// packImage adds a layer on top of the binary image and pushes the result to the registry of the tag.
+func (i ImageIndexer) packImage(ctx context.Context, opts buildImageOptions, layer io.Reader, packOpts ...containerdregistry.PackOption) (err error) {
+	binarySourceImage, imageTag := opts.BinarySourceImage, opts.Tag
+	if imageTag == "" {
+		return fmt.Errorf("a tag is required to push the index image when the build tool is none")
+	}
+	if binarySourceImage == "" {
+		binarySourceImage = containertools.DefaultBinarySourceImage
+	}
+
+	ctx, span := tracing.Start(ctx, "indexer.packImage", tracing.String("base", binarySourceImage), tracing.String("tag", imageTag))
+	defer func() { span.EndWithError(err) }()
+
+	cacheDir, err := ioutil.TempDir("", tmpBuildDirPrefix)
+	if err != nil {
+		return err
+	}
+	reg, err := opts.NewContainerdRegistry(i.Logger, containerdregistry.WithCacheDir(cacheDir))
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if err := reg.Destroy(); err != nil {
+			i.Logger.WithError(err).Warn("error destroying local cache")
+		}
+	}()
+
+	base := image.SimpleReference(binarySourceImage)
+	i.Logger.Infof("pulling binary image %s", base)
+	if err := reg.Pull(ctx, base); err != nil {
+		return err
+	}
+
+	ref := image.SimpleReference(imageTag)
+	i.Logger.Debugf("packing container image: %s", ref)
+	if err := reg.Pack(ctx, base, ref, layer, packOpts...); err != nil {
+		return err
+	}
+
+	i.Logger.Infof("pushing index image %s", ref)
+	return reg.Push(ctx, ref)
+}
+
+// fileLayer returns an uncompressed tar stream that contains the file at src at the absolute path dst, along with
+// its parent directories.
+func fileLayer(src, dst string) (io.ReadCloser, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer f.Close()
+		tw := tar.NewWriter(pw)
+		if err := writeParentDirs(tw, dst, info.ModTime()); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     strings.TrimPrefix(dst, "/"),
+			Mode:     int64(info.Mode().Perm()),
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(tw, f); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(tw.Close())
+	}()
+	return pr, nil
+}
+
+// dirLayer returns an uncompressed tar stream that contains the regular files and directories under src at the
+// absolute path dst, along with the parent directories of dst.
+func dirLayer(src, dst string) (io.ReadCloser, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", src)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		if err := writeParentDirs(tw, dst, info.ModTime()); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			name := path.Join(strings.TrimPrefix(dst, "/"), filepath.ToSlash(rel))
+			switch {
+			case info.IsDir():
+				return tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: int64(info.Mode().Perm()), ModTime: info.ModTime()})
+			case !info.Mode().IsRegular():
+				return nil
+			}
+			if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+				return err
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(tw.Close())
+	}()
+	return pr, nil
+}
 
+// writeParentDirs writes the parent directories of the absolute path dst to tw.
+func writeParentDirs(tw *tar.Writer, dst string, modTime time.Time) error {
+	var dirs []string
+	for dir := path.Dir(strings.TrimPrefix(dst, "/")); dir != "."; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0755, ModTime: modTime}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -477,13 +729,15 @@ type ExportFromIndexRequest struct {
 	Packages      []string
 	DownloadPath  string
 	ContainerTool containertools.ContainerTool
-	CaFile        string
-	SkipTLS       bool
+	registry.PullOptions
 }
 
 // ExportFromIndex is an aggregate API used to specify operators from
 // an index image
-func (i ImageIndexer) ExportFromIndex(request ExportFromIndexRequest) error {
+func (i ImageIndexer) ExportFromIndex(request ExportFromIndexRequest) (err error) {
+	ctx, span := tracing.Start(context.TODO(), "indexer.ExportFromIndex", tracing.String("index", request.Index))
+	defer func() { span.EndWithError(err) }()
+
 	// set a temp directory
 	workingDir, err := ioutil.TempDir("./", tmpDirPrefix)
 	if err != nil {
@@ -492,7 +746,7 @@ func (i ImageIndexer) ExportFromIndex(request ExportFromIndexRequest) error {
 	defer os.RemoveAll(workingDir)
 
 	// extract the index database to the file
-	databaseFile, err := i.getDatabaseFile(workingDir, request.Index, request.CaFile, request.SkipTLS)
+	databaseFile, err := i.getDatabaseFile(ctx, workingDir, request.Index, request.PullOptions)
 	if err != nil {
 		return err
 	}
@@ -507,13 +761,13 @@ func (i ImageIndexer) ExportFromIndex(request ExportFromIndexRequest) error {
 
 	// fetch all packages from the index image if packages is empty
 	if len(request.Packages) == 0 {
-		request.Packages, err = dbQuerier.ListPackages(context.TODO())
+		request.Packages, err = dbQuerier.ListPackages(ctx)
 		if err != nil {
 			return err
 		}
 	}
 
-	bundles, err := getBundlesToExport(dbQuerier, request.Packages)
+	bundles, err := getBundlesToExport(ctx, dbQuerier, request.Packages)
 	if err != nil {
 		return err
 	}
@@ -562,7 +816,7 @@ func (i ImageIndexer) ExportFromIndex(request ExportFromIndexRequest) error {
 	}
 
 	for _, packageName := range request.Packages {
-		err := generatePackageYaml(dbQuerier, packageName, filepath.Join(request.DownloadPath, packageName))
+		err := generatePackageYaml(ctx, dbQuerier, packageName, filepath.Join(request.DownloadPath, packageName))
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -574,11 +828,11 @@ type bundleDirPrefix struct {
 	pkgName, bundleVersion string
 }
 
-func getBundlesToExport(dbQuerier pregistry.Query, packages []string) (map[string]bundleDirPrefix, error) {
+func getBundlesToExport(ctx context.Context, dbQuerier pregistry.Query, packages []string) (map[string]bundleDirPrefix, error) {
 	bundleMap := make(map[string]bundleDirPrefix)
 
 	for _, packageName := range packages {
-		bundlesForPackage, err := dbQuerier.GetBundlesForPackage(context.TODO(), packageName)
+		bundlesForPackage, err := dbQuerier.GetBundlesForPackage(ctx, packageName)
 		if err != nil {
 			return nil, err
 		}
@@ -590,22 +844,22 @@ func getBundlesToExport(dbQuerier pregistry.Query, packages []string) (map[strin
 	return bundleMap, nil
 }
 
-func generatePackageYaml(dbQuerier pregistry.Query, packageName, downloadPath string) error {
+func generatePackageYaml(ctx context.Context, dbQuerier pregistry.Query, packageName, downloadPath string) error {
 	var errs []error
 
-	defaultChannel, err := dbQuerier.GetDefaultChannelForPackage(context.TODO(), packageName)
+	defaultChannel, err := dbQuerier.GetDefaultChannelForPackage(ctx, packageName)
 	if err != nil {
 		return err
 	}
 
-	channelList, err := dbQuerier.ListChannels(context.TODO(), packageName)
+	channelList, err := dbQuerier.ListChannels(ctx, packageName)
 	if err != nil {
 		return err
 	}
 
 	channels := []pregistry.PackageChannel{}
 	for _, ch := range channelList {
-		csvName, err := dbQuerier.GetCurrentCSVNameForChannel(context.TODO(), packageName, ch)
+		csvName, err := dbQuerier.GetCurrentCSVNameForChannel(ctx, packageName, ch)
 		if err != nil {
 			err = fmt.Errorf("error exporting bundle from image: %s", err)
 			errs = append(errs, err)
@@ -647,8 +901,7 @@ type DeprecateFromIndexRequest struct {
 	OutDockerfile     string
 	Bundles           []string
 	Tag               string
-	CaFile            string
-	SkipTLS           bool
+	registry.PullOptions
 }
 
 // DeprecateFromIndex takes a DeprecateFromIndexRequest and deprecates the requested
@@ -660,7 +913,8 @@ func (i ImageIndexer) DeprecateFromIndex(request DeprecateFromIndexRequest) erro
 		return err
 	}
 
-	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLS)
+	pull := request.PullOptions
+	databasePath, err := i.extractDatabase(context.TODO(), buildDir, request.FromIndex, pull)
 	if err != nil {
 		return err
 	}
@@ -690,10 +944,116 @@ func (i ImageIndexer) DeprecateFromIndex(request DeprecateFromIndexRequest) erro
 	}
 
 	// build the dockerfile with requested tooling
-	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	err = i.buildImage(context.TODO(), buildImageOptions{
+		DockerfilePath:    outDockerfile,
+		DatabasePath:      databasePath,
+		BinarySourceImage: request.BinarySourceImage,
+		Tag:               request.Tag,
+		PullOptions:       pull,
+	})
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// BuildConfigsIndexRequest defines the parameters to send to the BuildConfigsIndex API
+type BuildConfigsIndexRequest struct {
+	Generate          bool
+	BinarySourceImage string
+	ConfigsDir        string
+	OutDockerfile     string
+	Tag               string
+	registry.PullOptions
+}
+
+// BuildConfigsIndex is an aggregate API used to generate an index image that serves the
+// declarative configs of a directory. The configs are validated before they are added to the image.
+func (i ImageIndexer) BuildConfigsIndex(request BuildConfigsIndexRequest) error {
+	if err := config.ValidateConfig(request.ConfigsDir); err != nil {
+		return fmt.Errorf("invalid declarative configs: %v", err)
+	}
+
+	buildDir, outDockerfile, cleanup, err := buildContext(request.Generate, request.OutDockerfile)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	// the generated dockerfile is built from the working directory, so the configs are copied
+	// into the build directory unless they are only needed by the dockerfile
+	configsDir := request.ConfigsDir
+	if !request.Generate && i.BuildTool != containertools.NoneTool {
+		configsDir = filepath.Join(buildDir, defaultConfigsFolder)
+		if err := copyDir(request.ConfigsDir, configsDir); err != nil {
+			return err
+		}
+	}
+
+	// generate the dockerfile
+	dockerfile := i.DockerfileGenerator.GenerateConfigsDockerfile(request.BinarySourceImage, configsDir)
+	err = write(dockerfile, outDockerfile, i.Logger)
+	if err != nil {
+		return err
+	}
+
+	if request.Generate {
+		return nil
+	}
+
+	if i.BuildTool != containertools.NoneTool {
+		return build(context.TODO(), outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	}
+
+	layer, err := dirLayer(request.ConfigsDir, containertools.DefaultConfigsLocation)
+	if err != nil {
+		return err
+	}
+	defer layer.Close()
+
+	opts := buildImageOptions{
+		DockerfilePath:    outDockerfile,
+		BinarySourceImage: request.BinarySourceImage,
+		Tag:               request.Tag,
+		PullOptions:       request.PullOptions,
+	}
+	return i.packImage(context.TODO(), opts, layer,
+		containerdregistry.WithLabels(map[string]string{containertools.ConfigsLocationLabel: containertools.DefaultConfigsLocation}),
+		containerdregistry.WithExposedPorts("50051/tcp"),
+		containerdregistry.WithEntrypoint("/bin/opm"),
+		containerdregistry.WithCmd("alpha", "serve", containertools.DefaultConfigsLocation),
+	)
+}
+
+// copyDir copies the regular files and directories under src to dst.
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+		from, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer from.Close()
+		to, err := os.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer to.Close()
+		_, err = io.Copy(to, from)
+		return err
+	})
+}
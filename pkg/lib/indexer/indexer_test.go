@@ -1,14 +1,24 @@
 package indexer
 
 import (
+	"bytes"
+	"context"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
 
 	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
 
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 	pregistry "github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -29,7 +39,7 @@ func TestGetBundlesToExport(t *testing.T) {
 		t.Fatalf("creating querier: %s", err)
 	}
 
-	bundleMap, err := getBundlesToExport(dbQuerier, []string{"etcd"})
+	bundleMap, err := getBundlesToExport(context.Background(), dbQuerier, []string{"etcd"})
 	if err != nil {
 		t.Fatalf("exporting bundles from db: %s", err)
 	}
@@ -58,7 +68,7 @@ func TestGeneratePackageYaml(t *testing.T) {
 		t.Fatalf("creating querier: %s", err)
 	}
 
-	err = generatePackageYaml(dbQuerier, "etcd", ".")
+	err = generatePackageYaml(context.Background(), dbQuerier, "etcd", ".")
 	if err != nil {
 		t.Fatalf("writing package.yaml: %s", err)
 	}
@@ -83,3 +93,171 @@ func TestGeneratePackageYaml(t *testing.T) {
 
 	_ = os.RemoveAll("./package.yaml")
 }
+
+func TestBuildImageWithoutContainerTool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	if err != nil {
+		t.Fatalf("running registry: %s", err)
+	}
+	rootCAs, err := certs.RootCAs(cafile)
+	if err != nil {
+		t.Fatalf("loading root CAs: %s", err)
+	}
+	newRegistry := func() *containerdregistry.Registry {
+		cacheDir, err := ioutil.TempDir("", "indexer-test-")
+		if err != nil {
+			t.Fatalf("creating cache dir: %s", err)
+		}
+		reg, err := containerdregistry.NewRegistry(containerdregistry.WithCacheDir(cacheDir), containerdregistry.WithRootCAs(rootCAs))
+		if err != nil {
+			t.Fatalf("creating registry: %s", err)
+		}
+		return reg
+	}
+
+	// Push an empty binary image to build on.
+	binaryImage := image.SimpleReference(host + "/test/opm:latest")
+	reg := newRegistry()
+	defer reg.Destroy()
+	if err := reg.Pack(ctx, nil, binaryImage, &bytes.Buffer{}); err != nil {
+		t.Fatalf("packing binary image: %s", err)
+	}
+	if err := reg.Push(ctx, binaryImage); err != nil {
+		t.Fatalf("pushing binary image: %s", err)
+	}
+
+	indexer := ImageIndexer{
+		BuildTool: containertools.NoneTool,
+		PullTool:  containertools.NoneTool,
+		Logger:    logrus.NewEntry(logrus.New()),
+	}
+	if err := indexer.buildImage(context.Background(), buildImageOptions{DatabasePath: "./testdata/bundles.db", BinarySourceImage: binaryImage.String(), PullOptions: registry.PullOptions{CaFile: cafile}}); err == nil {
+		t.Fatalf("expected an error building without a tag")
+	}
+	tag := host + "/test/index:v1"
+	if err := indexer.buildImage(context.Background(), buildImageOptions{DatabasePath: "./testdata/bundles.db", BinarySourceImage: binaryImage.String(), Tag: tag, PullOptions: registry.PullOptions{CaFile: cafile}}); err != nil {
+		t.Fatalf("building index image: %s", err)
+	}
+
+	reg = newRegistry()
+	defer reg.Destroy()
+	ref := image.SimpleReference(tag)
+	if err := reg.Pull(ctx, ref); err != nil {
+		t.Fatalf("pulling index image: %s", err)
+	}
+	labels, err := reg.Labels(ctx, ref)
+	if err != nil {
+		t.Fatalf("getting labels: %s", err)
+	}
+	if labels[containertools.DbLocationLabel] != containertools.DefaultDbLocation {
+		t.Fatalf("expected label %s=%s, got labels %v", containertools.DbLocationLabel, containertools.DefaultDbLocation, labels)
+	}
+
+	dir, err := ioutil.TempDir("", "indexer-test-unpacked-")
+	if err != nil {
+		t.Fatalf("creating unpack dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := reg.Unpack(ctx, ref, dir); err != nil {
+		t.Fatalf("unpacking index image: %s", err)
+	}
+	expected, _ := ioutil.ReadFile("./testdata/bundles.db")
+	actual, err := ioutil.ReadFile(filepath.Join(dir, containertools.DefaultDbLocation))
+	if err != nil {
+		t.Fatalf("reading unpacked database: %s", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("unpacked database does not match")
+	}
+}
+
+func TestBuildConfigsIndexWithoutContainerTool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	if err != nil {
+		t.Fatalf("running registry: %s", err)
+	}
+	rootCAs, err := certs.RootCAs(cafile)
+	if err != nil {
+		t.Fatalf("loading root CAs: %s", err)
+	}
+	newRegistry := func() *containerdregistry.Registry {
+		cacheDir, err := ioutil.TempDir("", "indexer-test-")
+		if err != nil {
+			t.Fatalf("creating cache dir: %s", err)
+		}
+		reg, err := containerdregistry.NewRegistry(containerdregistry.WithCacheDir(cacheDir), containerdregistry.WithRootCAs(rootCAs))
+		if err != nil {
+			t.Fatalf("creating registry: %s", err)
+		}
+		return reg
+	}
+
+	// Push an empty binary image to build on.
+	binaryImage := image.SimpleReference(host + "/test/opm:latest")
+	reg := newRegistry()
+	defer reg.Destroy()
+	if err := reg.Pack(ctx, nil, binaryImage, &bytes.Buffer{}); err != nil {
+		t.Fatalf("packing binary image: %s", err)
+	}
+	if err := reg.Push(ctx, binaryImage); err != nil {
+		t.Fatalf("pushing binary image: %s", err)
+	}
+
+	indexer := ImageIndexer{
+		DockerfileGenerator: containertools.NewDockerfileGenerator(logrus.NewEntry(logrus.New())),
+		BuildTool:           containertools.NoneTool,
+		Logger:              logrus.NewEntry(logrus.New()),
+	}
+	request := BuildConfigsIndexRequest{
+		BinarySourceImage: binaryImage.String(),
+		ConfigsDir:        "./testdata/bundles.db",
+		Tag:               host + "/test/configs:v1",
+		PullOptions: registry.PullOptions{
+			CaFile: cafile,
+		},
+	}
+	if err := indexer.BuildConfigsIndex(request); err == nil {
+		t.Fatalf("expected an error building from an invalid configs directory")
+	}
+	request.ConfigsDir = "./testdata/configs"
+	if err := indexer.BuildConfigsIndex(request); err != nil {
+		t.Fatalf("building index image: %s", err)
+	}
+
+	reg = newRegistry()
+	defer reg.Destroy()
+	ref := image.SimpleReference(request.Tag)
+	if err := reg.Pull(ctx, ref); err != nil {
+		t.Fatalf("pulling index image: %s", err)
+	}
+	labels, err := reg.Labels(ctx, ref)
+	if err != nil {
+		t.Fatalf("getting labels: %s", err)
+	}
+	if labels[containertools.ConfigsLocationLabel] != containertools.DefaultConfigsLocation {
+		t.Fatalf("expected label %s=%s, got labels %v", containertools.ConfigsLocationLabel, containertools.DefaultConfigsLocation, labels)
+	}
+
+	dir, err := ioutil.TempDir("", "indexer-test-unpacked-")
+	if err != nil {
+		t.Fatalf("creating unpack dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := reg.Unpack(ctx, ref, dir); err != nil {
+		t.Fatalf("unpacking index image: %s", err)
+	}
+	expected, _ := ioutil.ReadFile("./testdata/configs/foo/index.yaml")
+	actual, err := ioutil.ReadFile(filepath.Join(dir, containertools.DefaultConfigsLocation, "foo", "index.yaml"))
+	if err != nil {
+		t.Fatalf("reading unpacked configs: %s", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("unpacked configs do not match")
+	}
+}
@@ -114,3 +114,18 @@ func NewIndexDeprecator(buildTool, pullTool containertools.ContainerTool, logger
 		Logger:              logger,
 	}
 }
+
+// IndexConfigsBuilder builds index images that serve declarative configs
+type IndexConfigsBuilder interface {
+	BuildConfigsIndex(BuildConfigsIndexRequest) error
+}
+
+// NewIndexConfigsBuilder is a constructor that returns an IndexConfigsBuilder
+func NewIndexConfigsBuilder(buildTool containertools.ContainerTool, logger *logrus.Entry) IndexConfigsBuilder {
+	return ImageIndexer{
+		DockerfileGenerator: containertools.NewDockerfileGenerator(logger),
+		CommandRunner:       containertools.NewCommandRunner(buildTool, logger),
+		BuildTool:           buildTool,
+		Logger:              logger,
+	}
+}
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+)
+
+// PullOptions configures the registries that images are pulled with.
+type PullOptions struct {
+	// CaFile holds root certificates to trust in addition to those of the
+	// system.
+	CaFile string
+	// AuthFile is a registry credentials file, either a docker config.json
+	// or a containers auth.json; the docker config is used if it is empty.
+	AuthFile string
+	SkipTLS  bool
+	// VerificationPolicy is the path of a policy that pulled images must
+	// comply with, see containerdregistry.LoadVerificationPolicy.
+	VerificationPolicy string
+	// SharedCacheDir is a content cache shared with other pulls, if any, see
+	// containerdregistry.WithSharedCache.
+	SharedCacheDir string
+}
+
+// NewContainerdRegistry returns a containerd registry that pulls images as
+// configured by o. Options are applied after those of o.
+func (o PullOptions) NewContainerdRegistry(logger *logrus.Entry, opts ...containerdregistry.RegistryOption) (*containerdregistry.Registry, error) {
+	rootCAs, err := certs.RootCAs(o.CaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RootCAs: %v", err)
+	}
+	return containerdregistry.NewRegistry(append([]containerdregistry.RegistryOption{
+		containerdregistry.SkipTLS(o.SkipTLS),
+		containerdregistry.WithLog(logger),
+		containerdregistry.WithRootCAs(rootCAs),
+		containerdregistry.WithAuthFile(o.AuthFile),
+		containerdregistry.WithVerificationPolicyFile(o.VerificationPolicy),
+		containerdregistry.WithSharedCache(o.SharedCacheDir),
+	}, opts...)...)
+}
+
+// NewRegistry returns a registry that pulls images with tool as configured
+// by o. Container tools can't verify images, so a verification policy
+// requires the none tool. Options only apply to the containerd registry of
+// the none tool.
+func (o PullOptions) NewRegistry(tool containertools.ContainerTool, logger *logrus.Entry, opts ...containerdregistry.RegistryOption) (image.Registry, error) {
+	switch tool {
+	case containertools.NoneTool:
+		return o.NewContainerdRegistry(logger, opts...)
+	case containertools.PodmanTool, containertools.DockerTool:
+		if o.VerificationPolicy != "" {
+			return nil, fmt.Errorf("images pulled with %s can't be verified against a verification policy", tool)
+		}
+		return execregistry.NewRegistry(tool, logger, containertools.SkipTLS(o.SkipTLS), containertools.WithAuthFile(o.AuthFile))
+	default:
+		return nil, fmt.Errorf("unsupported container tool %q", tool)
+	}
+}
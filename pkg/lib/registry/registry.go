@@ -5,15 +5,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
-	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
-	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
-	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -24,17 +24,36 @@ type RegistryUpdater struct {
 
 type AddToRegistryRequest struct {
 	Permissive    bool
-	SkipTLS       bool
-	CaFile        string
 	InputDatabase string
 	Bundles       []string
 	Mode          registry.Mode
 	ContainerTool containertools.ContainerTool
 	Overwrite     bool
 	EnableAlpha   bool
+	// PullWorkers is the number of bundle images pulled and unpacked
+	// concurrently. DefaultPullWorkers is used if it is not positive.
+	PullWorkers int
+	// PinDigests pins the bundle images and their related images to digests,
+	// see registry.PinBundleImages.
+	PinDigests bool
+	PullOptions
+	// Context is the context the bundles are added under, e.g. to trace them
+	// as part of the operation that adds them. context.TODO is used if nil.
+	Context context.Context
 }
 
-func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
+// DefaultPullWorkers is the number of bundle images pulled and unpacked
+// concurrently unless a request says otherwise.
+const DefaultPullWorkers = 4
+
+func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) (err error) {
+	ctx := request.Context
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	ctx, span := tracing.Start(ctx, "registry.AddToRegistry", tracing.Int("bundles", len(request.Bundles)))
+	defer func() { span.EndWithError(err) }()
+
 	db, err := sqlite.Open(request.InputDatabase)
 	if err != nil {
 		return err
@@ -46,7 +65,7 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 		return err
 	}
 
-	if err := dbLoader.Migrate(context.TODO()); err != nil {
+	if err := dbLoader.Migrate(ctx); err != nil {
 		return err
 	}
 
@@ -56,24 +75,9 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 	}
 	dbQuerier := sqlite.NewSQLLiteQuerierFromDb(db)
 
-	// add custom ca certs to resolver
-
-	var reg image.Registry
-	var rerr error
-	switch request.ContainerTool {
-	case containertools.NoneTool:
-		rootCAs, err := certs.RootCAs(request.CaFile)
-		if err != nil {
-			return fmt.Errorf("failed to get RootCAs: %v", err)
-		}
-		reg, rerr = containerdregistry.NewRegistry(containerdregistry.SkipTLS(request.SkipTLS), containerdregistry.WithRootCAs(rootCAs))
-	case containertools.PodmanTool:
-		fallthrough
-	case containertools.DockerTool:
-		reg, rerr = execregistry.NewRegistry(request.ContainerTool, r.Logger, containertools.SkipTLS(request.SkipTLS))
-	}
-	if rerr != nil {
-		return rerr
+	reg, err := request.NewRegistry(request.ContainerTool, r.Logger)
+	if err != nil {
+		return err
 	}
 	defer func() {
 		if err := reg.Destroy(); err != nil {
@@ -81,12 +85,20 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 		}
 	}()
 
+	var resolver registry.DigestResolver
+	if request.PinDigests {
+		var ok bool
+		if resolver, ok = reg.(registry.DigestResolver); !ok {
+			return fmt.Errorf("images pulled with %s can't be pinned to digests", request.ContainerTool)
+		}
+	}
+
 	simpleRefs := make([]image.Reference, 0)
 	for _, ref := range request.Bundles {
 		simpleRefs = append(simpleRefs, image.SimpleReference(ref))
 	}
 
-	if err := populate(context.TODO(), dbLoader, graphLoader, dbQuerier, reg, simpleRefs, request.Mode, request.Overwrite); err != nil {
+	if err := populate(ctx, dbLoader, graphLoader, dbQuerier, reg, simpleRefs, request.Mode, request.Overwrite, request.PullWorkers, resolver); err != nil {
 		r.Logger.Debugf("unable to populate database: %s", err)
 
 		if !request.Permissive {
@@ -100,6 +112,9 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 }
 
 func unpackImage(ctx context.Context, reg image.Registry, ref image.Reference) (image.Reference, string, func(), error) {
+	ctx, span := tracing.Start(ctx, "registry.unpackImage", tracing.String("image", ref.String()))
+	defer span.End()
+
 	var errs []error
 	workingDir, err := ioutil.TempDir("./", "bundle_tmp")
 	if err != nil {
@@ -121,26 +136,94 @@ func unpackImage(ctx context.Context, reg image.Registry, ref image.Reference) (
 	}
 
 	if len(errs) > 0 {
-		return nil, "", cleanup, utilerrors.NewAggregate(errs)
+		err := utilerrors.NewAggregate(errs)
+		span.EndWithError(err)
+		return nil, "", cleanup, err
 	}
 	return ref, workingDir, cleanup, nil
 }
 
-func populate(ctx context.Context, loader registry.Load, graphLoader registry.GraphLoader, querier registry.Query, reg image.Registry, refs []image.Reference, mode registry.Mode, overwrite bool) error {
-	unpackedImageMap := make(map[image.Reference]string, 0)
-	for _, ref := range refs {
-		to, from, cleanup, err := unpackImage(ctx, reg, ref)
-		if err != nil {
-			return err
+type unpackedImage struct {
+	ref     image.Reference
+	dir     string
+	cleanup func()
+	err     error
+}
+
+// unpackImages pulls and unpacks refs with up to workers images in flight at
+// once. The unpacked images are returned in the order of refs, and so are the
+// errors of the images that failed, each naming its image. The returned
+// cleanup removes all the unpacked images and must be called even on error.
+func unpackImages(ctx context.Context, reg image.Registry, refs []image.Reference, workers int) ([]unpackedImage, func(), error) {
+	if workers <= 0 {
+		workers = DefaultPullWorkers
+	}
+	if workers > len(refs) {
+		workers = len(refs)
+	}
+
+	unpacked := make([]unpackedImage, len(refs))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				to, from, cleanup, err := unpackImage(ctx, reg, refs[i])
+				unpacked[i] = unpackedImage{ref: to, dir: from, cleanup: cleanup, err: err}
+			}
+		}()
+	}
+	for i := range refs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	cleanup := func() {
+		for _, u := range unpacked {
+			if u.cleanup != nil {
+				u.cleanup()
+			}
 		}
-		unpackedImageMap[to] = from
-		defer cleanup()
 	}
 
+	var errs []error
+	for i, u := range unpacked {
+		if u.err != nil {
+			errs = append(errs, fmt.Errorf("error unpacking %s: %v", refs[i], u.err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, cleanup, utilerrors.NewAggregate(errs)
+	}
+	return unpacked, cleanup, nil
+}
+
+func populate(ctx context.Context, loader registry.Load, graphLoader registry.GraphLoader, querier registry.Query, reg image.Registry, refs []image.Reference, mode registry.Mode, overwrite bool, workers int, resolver registry.DigestResolver) error {
+	unpacked, cleanup, err := unpackImages(ctx, reg, refs, workers)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	unpackedImageMap := make(map[image.Reference]string, 0)
+	for _, u := range unpacked {
+		unpackedImageMap[u.ref] = u.dir
+	}
+
+	// Bundles are loaded in the order they were requested in, followed by
+	// the bundles re-added to overwrite their package.
+	order := append([]image.Reference{}, refs...)
 	overwriteImageMap := make(map[string]map[image.Reference]string, 0)
 	if overwrite {
 		// find all bundles that are attempting to overwrite
-		for to, from := range unpackedImageMap {
+		for _, u := range unpacked {
+			to, from := u.ref, u.dir
+			if _, ok := unpackedImageMap[to]; !ok {
+				// already queued to be re-added along with its package
+				continue
+			}
 			img, err := registry.NewImageInput(to, from)
 			if err != nil {
 				return err
@@ -160,43 +243,39 @@ func populate(ctx context.Context, loader registry.Load, graphLoader registry.Gr
 			if err != nil {
 				return err
 			}
-			type unpackedImage struct {
-				to      image.Reference
-				from    string
-				cleanup func()
-				err     error
-			}
-			unpacked := make(chan unpackedImage)
-			for bundle := range bundles {
-				// parallelize image pulls
-				go func(bundle registry.BundleKey, img *registry.ImageInput) {
-					if bundle.CsvName != img.Bundle.Name {
-						to, from, cleanup, err := unpackImage(ctx, reg, image.SimpleReference(bundle.BundlePath))
-						unpacked <- unpackedImage{to: to, from: from, cleanup: cleanup, err: err}
-					} else {
-						unpacked <- unpackedImage{to: to, from: from, cleanup: func() { return }, err: nil}
-					}
-				}(bundle, img)
-			}
 			if _, ok := overwriteImageMap[img.Bundle.Package]; !ok {
 				overwriteImageMap[img.Bundle.Package] = make(map[image.Reference]string, 0)
 			}
-			for i := 0; i < len(bundles); i++ {
-				unpack := <-unpacked
-				if unpack.err != nil {
-					return unpack.err
-				}
-				overwriteImageMap[img.Bundle.Package][unpack.to] = unpack.from
-				if _, ok := unpackedImageMap[unpack.to]; ok {
-					delete(unpackedImageMap, unpack.to)
+			overwriteImageMap[img.Bundle.Package][to] = from
+			delete(unpackedImageMap, to)
+
+			var readd []image.Reference
+			for bundle := range bundles {
+				if bundle.CsvName != img.Bundle.Name {
+					readd = append(readd, image.SimpleReference(bundle.BundlePath))
 				}
-				defer unpack.cleanup()
+			}
+			sort.Slice(readd, func(i, j int) bool { return readd[i].String() < readd[j].String() })
+			order = append(order, readd...)
+
+			reunpacked, cleanup, err := unpackImages(ctx, reg, readd, workers)
+			defer cleanup()
+			if err != nil {
+				return err
+			}
+			for _, r := range reunpacked {
+				overwriteImageMap[img.Bundle.Package][r.ref] = r.dir
+				delete(unpackedImageMap, r.ref)
 			}
 		}
 	}
 
-	populator := registry.NewDirectoryPopulator(loader, graphLoader, querier, unpackedImageMap, overwriteImageMap, overwrite)
-	return populator.Populate(mode)
+	opts := []registry.DirectoryPopulatorOption{registry.LoadInOrder(order)}
+	if resolver != nil {
+		opts = append(opts, registry.PinDigests(resolver))
+	}
+	populator := registry.NewDirectoryPopulator(loader, graphLoader, querier, unpackedImageMap, overwriteImageMap, overwrite, opts...)
+	return populator.PopulateContext(ctx, mode)
 }
 
 type DeleteFromRegistryRequest struct {
@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// fakeRegistry unpacks each image as a single file holding its reference,
+// recording how many pulls are in flight at once.
+type fakeRegistry struct {
+	fail map[string]bool
+
+	// barrier, if set, holds pulls until that many are in flight at once,
+	// so that the most concurrent pulls are observed regardless of timing.
+	barrier int
+	full    chan struct{}
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func newBarrierRegistry(barrier int) *fakeRegistry {
+	return &fakeRegistry{barrier: barrier, full: make(chan struct{})}
+}
+
+func (r *fakeRegistry) Pull(ctx context.Context, ref image.Reference) error {
+	r.mu.Lock()
+	r.inFlight++
+	if r.inFlight > r.maxInFlight {
+		r.maxInFlight = r.inFlight
+		if r.maxInFlight == r.barrier {
+			close(r.full)
+		}
+	}
+	r.mu.Unlock()
+
+	if r.barrier > 0 {
+		select {
+		case <-r.full:
+		case <-time.After(5 * time.Second):
+			return fmt.Errorf("fewer than %d pulls in flight", r.barrier)
+		}
+	}
+
+	r.mu.Lock()
+	r.inFlight--
+	r.mu.Unlock()
+	if r.fail[ref.String()] {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *fakeRegistry) Unpack(ctx context.Context, ref image.Reference, dir string) error {
+	if r.fail[ref.String()] {
+		return errors.New("not pulled")
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "ref"), []byte(ref.String()), 0644)
+}
+
+func (r *fakeRegistry) Labels(ctx context.Context, ref image.Reference) (map[string]string, error) {
+	return nil, nil
+}
+
+func (r *fakeRegistry) Destroy() error {
+	return nil
+}
+
+func bundleRefs(n int) []image.Reference {
+	var refs []image.Reference
+	for i := 0; i < n; i++ {
+		refs = append(refs, image.SimpleReference(fmt.Sprintf("quay.io/test/bundle:v%d", i)))
+	}
+	return refs
+}
+
+func TestUnpackImages(t *testing.T) {
+	refs := bundleRefs(10)
+	reg := newBarrierRegistry(3)
+	unpacked, cleanup, err := unpackImages(context.TODO(), reg, refs, 3)
+	defer cleanup()
+	require.NoError(t, err)
+	require.LessOrEqual(t, reg.maxInFlight, 3)
+
+	// Images are returned in the order they were requested in.
+	require.Len(t, unpacked, len(refs))
+	for i, u := range unpacked {
+		require.Equal(t, refs[i], u.ref)
+		data, err := ioutil.ReadFile(filepath.Join(u.dir, "ref"))
+		require.NoError(t, err)
+		require.Equal(t, refs[i].String(), string(data))
+	}
+
+	cleanup()
+	for _, u := range unpacked {
+		require.NoDirExists(t, u.dir)
+	}
+}
+
+func TestUnpackImagesDefaultWorkers(t *testing.T) {
+	reg := newBarrierRegistry(DefaultPullWorkers)
+	_, cleanup, err := unpackImages(context.TODO(), reg, bundleRefs(2*DefaultPullWorkers), 0)
+	defer cleanup()
+	require.NoError(t, err)
+	require.LessOrEqual(t, reg.maxInFlight, DefaultPullWorkers)
+}
+
+func TestUnpackImagesErrors(t *testing.T) {
+	refs := bundleRefs(6)
+	reg := &fakeRegistry{fail: map[string]bool{refs[4].String(): true, refs[1].String(): true}}
+	unpacked, cleanup, err := unpackImages(context.TODO(), reg, refs, 4)
+	defer cleanup()
+	require.Nil(t, unpacked)
+	require.Error(t, err)
+
+	// Every failed image is reported, in the order the images were requested in.
+	msg := err.Error()
+	first := strings.Index(msg, "error unpacking "+refs[1].String())
+	second := strings.Index(msg, "error unpacking "+refs[4].String())
+	require.True(t, first >= 0 && second > first, msg)
+	require.NotContains(t, msg, refs[0].String())
+}
@@ -0,0 +1,66 @@
+package serverflags
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/server"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+const metricsAddressFlag = "metrics-address"
+
+// AddMetricsFlags adds the flags that configure the metrics of a registry
+// server.
+func AddMetricsFlags(flags *pflag.FlagSet) {
+	flags.String(metricsAddressFlag, "", "address to serve Prometheus metrics on at /metrics, e.g. :9090; metrics are not served if empty")
+}
+
+// ServeMetrics serves the metrics of querier on the address set by the flags
+// added by AddMetricsFlags, if any, and returns the gRPC server options that
+// record request metrics. Metrics are served until ctx is done.
+func ServeMetrics(ctx context.Context, flags *pflag.FlagSet, querier registry.GRPCQuery, logger logrus.FieldLogger, opts ...server.MetricsOption) ([]grpc.ServerOption, error) {
+	address, err := flags.GetString(metricsAddressFlag)
+	if err != nil {
+		return nil, err
+	}
+	if address == "" {
+		return nil, nil
+	}
+
+	metrics := server.NewMetrics(querier, logger, opts...)
+	if err := metrics.Serve(ctx, address, logger); err != nil {
+		return nil, fmt.Errorf("failed to serve metrics: %v", err)
+	}
+	return metrics.ServerOptions(), nil
+}
+
+// SQLiteMetricsOptions returns the metrics options of a registry served from
+// the sqlite database db that took loadDuration to load. Failing to read the
+// migration version of db is logged rather than returned, since it only
+// leaves that metric unset.
+func SQLiteMetricsOptions(ctx context.Context, db *sql.DB, loadDuration time.Duration, logger logrus.FieldLogger) []server.MetricsOption {
+	opts := []server.MetricsOption{server.WithLoadDuration(loadDuration)}
+	migrator, err := sqlite.NewSQLLiteMigrator(db)
+	if err != nil {
+		logger.WithError(err).Warn("couldn't read db migration version")
+		return opts
+	}
+	versioner, ok := migrator.(sqlite.MigrationVersioner)
+	if !ok {
+		return opts
+	}
+	version, err := versioner.Version(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("couldn't read db migration version")
+		return opts
+	}
+	return append(opts, server.WithMigrationVersion(version))
+}
@@ -0,0 +1,35 @@
+package serverflags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsetFlags(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddTLSFlags(flags)
+	AddMetricsFlags(flags)
+	require.NoError(t, flags.Parse(nil))
+	logger := logrus.NewEntry(logrus.New())
+
+	opts, err := TLSServerOptions(flags, logger)
+	require.NoError(t, err)
+	require.Empty(t, opts)
+
+	opts, err = ServeMetrics(context.Background(), flags, nil, logger)
+	require.NoError(t, err)
+	require.Empty(t, opts)
+}
+
+func TestTLSServerOptionsRequiresKeyPair(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddTLSFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--tls-cert", "cert.pem"}))
+
+	_, err := TLSServerOptions(flags, logrus.NewEntry(logrus.New()))
+	require.Error(t, err)
+}
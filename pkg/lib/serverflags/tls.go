@@ -0,0 +1,49 @@
+// Package serverflags provides the command line flags shared by the commands
+// that serve the registry API.
+package serverflags
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+
+	"github.com/operator-framework/operator-registry/pkg/server"
+)
+
+const (
+	tlsCertFlag     = "tls-cert"
+	tlsKeyFlag      = "tls-key"
+	tlsClientCAFlag = "tls-client-ca"
+)
+
+// AddTLSFlags adds the flags that configure the TLS of a registry server.
+func AddTLSFlags(flags *pflag.FlagSet) {
+	flags.String(tlsCertFlag, "", "path to a PEM certificate to serve TLS with, reloaded when it changes")
+	flags.String(tlsKeyFlag, "", "path to the PEM private key of the TLS certificate, reloaded when it changes")
+	flags.String(tlsClientCAFlag, "", "path to PEM certificates that client certificates must be signed by, reloaded when they change; requires --tls-cert and --tls-key")
+}
+
+// TLSServerOptions returns the gRPC server options that serve TLS as
+// configured by the flags added by AddTLSFlags. No options are returned if
+// the flags are not set, in which case the server is insecure.
+func TLSServerOptions(flags *pflag.FlagSet, logger logrus.FieldLogger) ([]grpc.ServerOption, error) {
+	tlsCert, err := flags.GetString(tlsCertFlag)
+	if err != nil {
+		return nil, err
+	}
+	tlsKey, err := flags.GetString(tlsKeyFlag)
+	if err != nil {
+		return nil, err
+	}
+	tlsClientCA, err := flags.GetString(tlsClientCAFlag)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := server.TLSServerOptions(tlsCert, tlsKey, tlsClientCA, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %v", err)
+	}
+	return opts, nil
+}
@@ -0,0 +1,219 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// serviceName identifies opm as the source of the exported spans.
+	serviceName = "opm"
+	// scopeName identifies the instrumentation that recorded the spans.
+	scopeName = "github.com/operator-framework/operator-registry"
+
+	// DefaultOTLPEndpoint is the address of a collector running locally with
+	// the default OTLP/HTTP receiver.
+	DefaultOTLPEndpoint = "http://localhost:4318"
+
+	// otlpTracesPath is the path of the OTLP/HTTP traces endpoint.
+	otlpTracesPath = "/v1/traces"
+)
+
+// The types below are the JSON encoding of an OTLP ExportTraceServiceRequest,
+// as accepted by OTLP/HTTP collectors. Trace and span ids are hex encoded and
+// 64 bit integers are strings, as the OTLP JSON mapping requires.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// otlpSpanKindInternal is SPAN_KIND_INTERNAL; all spans recorded by opm
+	// describe work done in process.
+	otlpSpanKindInternal = 1
+	// otlpStatusError is STATUS_CODE_ERROR.
+	otlpStatusError = 2
+)
+
+func otlpAttributeValue(v interface{}) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpValue{IntValue: &s}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+func otlpAttributes(attrs []Attribute) []otlpKeyValue {
+	var kvs []otlpKeyValue
+	for _, a := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: otlpAttributeValue(a.Value)})
+	}
+	return kvs
+}
+
+// newOTLPRequest encodes spans as a single OTLP export request.
+func newOTLPRequest(spans []SpanData) otlpRequest {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: scopeName}, Spans: []otlpSpan{}}
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: otlpStatusError, Message: s.Error}
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes([]Attribute{String("service.name", serviceName)})},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+}
+
+// FileExporter writes all the spans of a run to a file as one OTLP JSON
+// export request when it is shut down. The file can be sent as-is to the
+// traces endpoint of any OTLP/HTTP collector.
+type FileExporter struct {
+	path string
+
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func NewFileExporter(path string) *FileExporter {
+	return &FileExporter{path: path}
+}
+
+func (e *FileExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *FileExporter) Shutdown(_ context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	data, err := json.MarshalIndent(newOTLPRequest(e.spans), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(e.path, data, 0644); err != nil {
+		return fmt.Errorf("error writing trace file: %v", err)
+	}
+	return nil
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector over OTLP/HTTP with
+// JSON encoding.
+type OTLPExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewOTLPExporter returns an exporter that sends spans to the collector at
+// endpoint, e.g. DefaultOTLPEndpoint. The traces path is added to endpoint
+// unless it is already present.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url += otlpTracesPath
+	}
+	return &OTLPExporter{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	data, err := json.Marshal(newOTLPRequest(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending spans to %s: %v", e.url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("error sending spans to %s: %s: %s", e.url, res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,312 @@
+// Package tracing records spans of the work done by opm and exports them in
+// the OpenTelemetry protocol (OTLP) format, either to a collector or to a
+// file.
+//
+// Tracing is disabled until Init is called. While disabled, Start returns a
+// nil span whose methods do nothing, so instrumented code costs next to
+// nothing when no trace is requested.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// batchSize is the number of ended spans that are buffered before they
+	// are passed to the exporters.
+	batchSize = 256
+	// queueSize is the number of ended spans that may wait to be batched.
+	// Spans ended while the queue is full are dropped, so that a slow
+	// exporter never blocks the command being traced.
+	queueSize = 4 * batchSize
+)
+
+// Attribute is a key and value describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData is the record of an ended span.
+type SpanData struct {
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	// Error is the message of the error the span ended with, if any.
+	Error string
+}
+
+// Exporter sends ended spans to a tracing backend.
+type Exporter interface {
+	// ExportSpans exports a batch of ended spans.
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	// Shutdown flushes anything the exporter buffers. No spans are exported
+	// after it is called.
+	Shutdown(ctx context.Context) error
+}
+
+// Span records the timing of an operation. A nil span is valid and does
+// nothing, which is what Start returns while tracing is disabled.
+type Span struct {
+	p *provider
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// End ends the span. Calls after the first have no effect.
+func (s *Span) End() {
+	s.EndWithError(nil)
+}
+
+// EndWithError ends the span, marking it as failed if err is not nil.
+func (s *Span) EndWithError(err error) {
+	if s == nil {
+		return
+	}
+	if data, ok := s.finish(err); ok {
+		s.p.record(data)
+	}
+}
+
+// finish ends the span and returns its record, or false if it had already
+// ended.
+func (s *Span) finish(err error) (SpanData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return SpanData{}, false
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	if err != nil {
+		s.data.Error = err.Error()
+	}
+	return s.data, true
+}
+
+type spanKey struct{}
+
+// Start starts a span named name as a child of the span in ctx, or of the
+// root span started by Init if ctx has none, and returns a context carrying
+// the new span. The span must be ended by the caller.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	p := current()
+	if p == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil || parent.p != p {
+		parent = p.root
+	}
+	s := p.newSpan(name, parent, attrs)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type provider struct {
+	exporters []Exporter
+	logger    logrus.FieldLogger
+	root      *Span
+
+	queue   chan SpanData
+	stop    chan stopRequest
+	stopped chan struct{}
+	dropped uint64
+}
+
+var (
+	mu      sync.RWMutex
+	enabled *provider
+)
+
+func current() *provider {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled
+}
+
+// Init enables tracing, exporting spans to the given exporters, and starts a
+// root span named name. Spans started from contexts without a span are
+// children of the root span, so that the whole run of a command is one
+// trace. Shutdown must be called to end the root span and flush the
+// exporters.
+func Init(name string, logger logrus.FieldLogger, exporters ...Exporter) {
+	p := &provider{
+		exporters: exporters,
+		logger:    logger,
+		queue:     make(chan SpanData, queueSize),
+		stop:      make(chan stopRequest),
+		stopped:   make(chan struct{}),
+	}
+	p.root = p.newSpan(name, nil, nil)
+	go p.run()
+
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = p
+}
+
+// Root returns the root span started by Init, or nil if tracing is disabled.
+func Root() *Span {
+	p := current()
+	if p == nil {
+		return nil
+	}
+	return p.root
+}
+
+// Shutdown ends the root span, exports all queued spans and disables
+// tracing. It does nothing if tracing is disabled.
+func Shutdown(ctx context.Context, err error) error {
+	mu.Lock()
+	p := enabled
+	enabled = nil
+	mu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	// The root span is handed over with the stop request rather than
+	// queued, so that it is exported even if the queue is full.
+	root, _ := p.root.finish(err)
+	select {
+	case p.stop <- stopRequest{ctx: ctx, root: root}:
+		<-p.stopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if dropped := atomic.LoadUint64(&p.dropped); dropped > 0 {
+		p.logger.Warnf("dropped %d trace spans while the export queue was full", dropped)
+	}
+	var shutdownErr error
+	for _, e := range p.exporters {
+		if err := e.Shutdown(ctx); err != nil && shutdownErr == nil {
+			shutdownErr = err
+		}
+	}
+	return shutdownErr
+}
+
+func (p *provider) newSpan(name string, parent *Span, attrs []Attribute) *Span {
+	s := &Span{
+		p: p,
+		data: SpanData{
+			Name:       name,
+			SpanID:     newID(8),
+			Start:      time.Now(),
+			Attributes: attrs,
+		},
+	}
+	if parent == nil {
+		s.data.TraceID = newID(16)
+	} else {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentSpanID = parent.data.SpanID
+	}
+	return s
+}
+
+// stopRequest asks run to export the remaining spans and the root span with
+// ctx, and to return.
+type stopRequest struct {
+	ctx  context.Context
+	root SpanData
+}
+
+// record queues an ended span for export, dropping it if the queue is full.
+func (p *provider) record(data SpanData) {
+	select {
+	case p.queue <- data:
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+	}
+}
+
+// run batches the queued spans and passes them to the exporters until
+// Shutdown is called, then exports what remains in the queue.
+func (p *provider) run() {
+	defer close(p.stopped)
+	var batch []SpanData
+	for {
+		select {
+		case data := <-p.queue:
+			batch = append(batch, data)
+			if len(batch) >= batchSize {
+				p.export(context.Background(), batch)
+				batch = nil
+			}
+		case req := <-p.stop:
+			for {
+				select {
+				case data := <-p.queue:
+					batch = append(batch, data)
+				default:
+					p.export(req.ctx, append(batch, req.root))
+					return
+				}
+			}
+		}
+	}
+}
+
+// export passes a batch of spans to the exporters. Export failures are
+// logged rather than returned, since tracing must not fail the command
+// being traced.
+func (p *provider) export(ctx context.Context, batch []SpanData) {
+	if len(batch) == 0 {
+		return
+	}
+	for _, e := range p.exporters {
+		if err := e.ExportSpans(ctx, batch); err != nil {
+			p.logger.WithError(err).Warn("unable to export trace spans")
+		}
+	}
+}
+
+func newID(size int) string {
+	id := make([]byte, size)
+	// crypto/rand only fails if the system has no source of randomness, in
+	// which case an all-zero id merely makes the trace harder to read.
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,188 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryExporter struct {
+	mu       sync.Mutex
+	spans    []SpanData
+	shutdown bool
+}
+
+func (e *memoryExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(_ context.Context) error {
+	e.shutdown = true
+	return nil
+}
+
+func (e *memoryExporter) byName() map[string]SpanData {
+	spans := map[string]SpanData{}
+	for _, s := range e.spans {
+		spans[s.Name] = s
+	}
+	return spans
+}
+
+func TestDisabled(t *testing.T) {
+	ctx := context.TODO()
+	got, span := Start(ctx, "noop", String("key", "value"))
+	require.Nil(t, span)
+	require.Equal(t, ctx, got)
+	span.SetAttributes(Int("n", 1))
+	span.EndWithError(errors.New("ignored"))
+	require.Nil(t, Root())
+	require.NoError(t, Shutdown(context.TODO(), nil))
+}
+
+func TestSpans(t *testing.T) {
+	e := &memoryExporter{}
+	Init("opm index add", logrus.New(), e)
+
+	ctx, parent := Start(context.TODO(), "parent", String("image", "quay.io/foo/bar:v1"))
+	_, child := Start(ctx, "child")
+	child.SetAttributes(Int("layers", 3))
+	child.EndWithError(errors.New("pull failed"))
+	child.End()
+	parent.End()
+	_, orphan := Start(context.TODO(), "orphan")
+	orphan.End()
+
+	require.NoError(t, Shutdown(context.TODO(), nil))
+	require.True(t, e.shutdown)
+	require.Len(t, e.spans, 4)
+
+	spans := e.byName()
+	root := spans["opm index add"]
+	require.Empty(t, root.ParentSpanID)
+	require.Len(t, root.TraceID, 32)
+	require.Len(t, root.SpanID, 16)
+	for _, name := range []string{"parent", "child", "orphan"} {
+		require.Equal(t, root.TraceID, spans[name].TraceID, name)
+	}
+	require.Equal(t, root.SpanID, spans["parent"].ParentSpanID)
+	require.Equal(t, spans["parent"].SpanID, spans["child"].ParentSpanID)
+	require.Equal(t, root.SpanID, spans["orphan"].ParentSpanID)
+
+	require.Equal(t, "pull failed", spans["child"].Error)
+	require.Equal(t, []Attribute{{Key: "layers", Value: int64(3)}}, spans["child"].Attributes)
+	require.False(t, spans["child"].End.Before(spans["child"].Start))
+	require.False(t, root.End.Before(spans["parent"].End))
+
+	// Tracing is disabled again once shut down.
+	_, span := Start(context.TODO(), "after")
+	require.Nil(t, span)
+}
+
+// blockingExporter blocks exports until release is closed.
+type blockingExporter struct {
+	memoryExporter
+	release chan struct{}
+}
+
+func (e *blockingExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	<-e.release
+	return e.memoryExporter.ExportSpans(ctx, spans)
+}
+
+func TestSlowExporterDropsSpans(t *testing.T) {
+	e := &blockingExporter{release: make(chan struct{})}
+	Init("opm index add", logrus.New(), e)
+
+	// Ending spans must not wait for the blocked exporter, so this only
+	// returns if the spans that don't fit in the queue are dropped.
+	total := 2*batchSize + queueSize
+	for i := 0; i < total; i++ {
+		_, span := Start(context.TODO(), "span")
+		span.End()
+	}
+	require.NotZero(t, atomic.LoadUint64(&current().dropped))
+
+	close(e.release)
+	require.NoError(t, Shutdown(context.TODO(), nil))
+	require.True(t, e.shutdown)
+	require.Less(t, len(e.spans), total+1)
+	require.Contains(t, e.byName(), "opm index add")
+}
+
+func TestFileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trace.json")
+
+	Init("opm", logrus.New(), NewFileExporter(path))
+	_, span := Start(context.TODO(), "sqlite.AddOperatorBundle", String("bundle", "etcdoperator.v0.9.2"), Int("size", 42), Bool("ok", true))
+	span.End()
+	require.NoError(t, Shutdown(context.TODO(), errors.New("command failed")))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var req otlpRequest
+	require.NoError(t, json.Unmarshal(data, &req))
+	require.Len(t, req.ResourceSpans, 1)
+	require.Equal(t, "service.name", req.ResourceSpans[0].Resource.Attributes[0].Key)
+	require.Equal(t, serviceName, *req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	bundle, root := spans[0], spans[1]
+	require.Equal(t, "sqlite.AddOperatorBundle", bundle.Name)
+	require.Equal(t, root.SpanID, bundle.ParentSpanID)
+	require.Equal(t, otlpSpanKindInternal, bundle.Kind)
+	require.NotEmpty(t, bundle.StartTimeUnixNano)
+	require.Equal(t, "etcdoperator.v0.9.2", *bundle.Attributes[0].Value.StringValue)
+	require.Equal(t, "42", *bundle.Attributes[1].Value.IntValue)
+	require.True(t, *bundle.Attributes[2].Value.BoolValue)
+	require.Zero(t, bundle.Status.Code)
+
+	require.Equal(t, "opm", root.Name)
+	require.Equal(t, otlpStatus{Code: otlpStatusError, Message: "command failed"}, root.Status)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var received []otlpRequest
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, otlpTracesPath, r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var req otlpRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		received = append(received, req)
+	}))
+	defer srv.Close()
+
+	e := NewOTLPExporter(srv.URL + "/")
+	spans := []SpanData{{Name: "containerdregistry.Pull", TraceID: newID(16), SpanID: newID(8)}}
+	require.NoError(t, e.ExportSpans(context.TODO(), spans))
+	require.Len(t, received, 1)
+	require.Equal(t, "containerdregistry.Pull", received[0].ResourceSpans[0].ScopeSpans[0].Spans[0].Name)
+
+	require.Equal(t, srv.URL+otlpTracesPath, NewOTLPExporter(srv.URL+otlpTracesPath).url)
+
+	fail = true
+	require.Error(t, e.ExportSpans(context.TODO(), spans))
+}
@@ -0,0 +1,163 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+// catalog is the content of an index to mirror.
+type catalog struct {
+	model model.Model
+	// bundleImages returns the images a bundle of the model uses, including
+	// the bundle image itself.
+	bundleImages func(b *model.Bundle) ([]string, error)
+}
+
+// loadConfigsCatalog loads a directory of declarative configs. The images of
+// a bundle are its image and its related images.
+func loadConfigsCatalog(dir string) (*catalog, error) {
+	cfg, err := declcfg.LoadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading declarative configs from %s: %v", dir, err)
+	}
+	m, err := declcfg.ConvertToModel(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error converting declarative configs to model: %v", err)
+	}
+	return &catalog{
+		model: m,
+		bundleImages: func(b *model.Bundle) ([]string, error) {
+			images := []string{b.Image}
+			for _, i := range b.RelatedImages {
+				images = append(images, i.Image)
+			}
+			return images, nil
+		},
+	}, nil
+}
+
+// loadDatabaseCatalog loads the database queried by querier. The images of a
+// bundle are those the database relates to it, which include its operator
+// images as well as its related images.
+func loadDatabaseCatalog(ctx context.Context, querier *sqlite.SQLQuerier) (*catalog, error) {
+	m, err := sqlite.ToModel(ctx, querier)
+	if err != nil {
+		return nil, fmt.Errorf("error converting database to model: %v", err)
+	}
+	return &catalog{
+		model: m,
+		bundleImages: func(b *model.Bundle) ([]string, error) {
+			images, err := querier.GetImagesForBundle(ctx, b.Name)
+			if err != nil {
+				return nil, err
+			}
+			return append(images, b.Image), nil
+		},
+	}, nil
+}
+
+// filter removes the packages not in packages and the channels not in
+// channels from the catalog. An empty list selects everything. Packages left
+// without channels are removed, and packages whose default channel is removed
+// default to the first of their remaining channels.
+func (c *catalog) filter(packages, channels []string) error {
+	selected := func(names []string, name string) bool {
+		if len(names) == 0 {
+			return true
+		}
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, name := range packages {
+		if _, ok := c.model[name]; !ok {
+			return fmt.Errorf("package %q not found in catalog", name)
+		}
+	}
+	for name, pkg := range c.model {
+		if !selected(packages, name) {
+			delete(c.model, name)
+			continue
+		}
+		for ch := range pkg.Channels {
+			if !selected(channels, ch) {
+				delete(pkg.Channels, ch)
+			}
+		}
+		if len(pkg.Channels) == 0 {
+			delete(c.model, name)
+			continue
+		}
+		if _, ok := pkg.Channels[pkg.DefaultChannel.Name]; !ok {
+			names := make([]string, 0, len(pkg.Channels))
+			for ch := range pkg.Channels {
+				names = append(names, ch)
+			}
+			sort.Strings(names)
+			pkg.DefaultChannel = pkg.Channels[names[0]]
+		}
+	}
+	if len(c.model) == 0 {
+		return fmt.Errorf("no channels of the catalog match the filter")
+	}
+	return nil
+}
+
+// images returns the images of the bundles of the catalog.
+func (c *catalog) images() ([]string, error) {
+	set := map[string]struct{}{}
+	for _, pkg := range c.model {
+		for _, ch := range pkg.Channels {
+			for _, b := range ch.Bundles {
+				images, err := c.bundleImages(b)
+				if err != nil {
+					return nil, fmt.Errorf("error listing images of bundle %s: %v", b.Name, err)
+				}
+				for _, i := range images {
+					if i != "" {
+						set[i] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	images := make([]string, 0, len(set))
+	for i := range set {
+		images = append(images, i)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// rewrite replaces the bundle images and related images of the catalog by
+// their mirrors, as returned by mirrorOf.
+func (c *catalog) rewrite(mirrorOf func(string) (string, bool)) {
+	for _, pkg := range c.model {
+		for _, ch := range pkg.Channels {
+			for _, b := range ch.Bundles {
+				if m, ok := mirrorOf(b.Image); ok {
+					b.Image = m
+				}
+				for i := range b.RelatedImages {
+					if m, ok := mirrorOf(b.RelatedImages[i].Image); ok {
+						b.RelatedImages[i].Image = m
+					}
+				}
+			}
+		}
+	}
+}
+
+// write writes the catalog as declarative configs to dir.
+func (c *catalog) write(dir string) error {
+	return declcfg.WriteDir(declcfg.ConvertFromModel(c.model), dir)
+}
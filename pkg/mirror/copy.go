@@ -0,0 +1,94 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+)
+
+// DefaultCopyWorkers is the number of images a ContainerdMirrorer copies at
+// once by default.
+const DefaultCopyWorkers = 4
+
+// ContainerdMirrorer is an ImageMirrorer that copies images itself, with a
+// containerd registry, rather than with external tools. Images the
+// destination already has are skipped, so that mirroring again after an
+// interrupted run only copies what's missing.
+type ContainerdMirrorer struct {
+	Registry *containerdregistry.Registry
+	// Layout, if set, receives the images instead of the registries of their
+	// destinations, each named after its destination.
+	Layout *containerdregistry.OCILayout
+	// Workers is the number of images copied at once. DefaultCopyWorkers is
+	// used if it is not positive.
+	Workers int
+	Logger  *logrus.Entry
+}
+
+var _ ImageMirrorer = &ContainerdMirrorer{}
+
+// Mirror copies each source image of mapping to its destination. Every image
+// is attempted, and the errors of those that couldn't be copied are returned
+// together.
+func (m *ContainerdMirrorer) Mirror(mapping map[string]string) error {
+	ctx := context.Background()
+	logger := m.Logger
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	workers := m.Workers
+	if workers <= 0 {
+		workers = DefaultCopyWorkers
+	}
+
+	sources := make([]string, 0, len(mapping))
+	for src := range mapping {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	todo := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range todo {
+				dst := mapping[src]
+				log := logger.WithField("src", src).WithField("dst", dst)
+				log.Info("mirroring image")
+				if err := m.copy(ctx, src, dst); err != nil {
+					log.WithError(err).Warn("failed to mirror image")
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("error mirroring %s to %s: %v", src, dst, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, src := range sources {
+		todo <- src
+	}
+	close(todo)
+	wg.Wait()
+
+	return errors.NewAggregate(errs)
+}
+
+func (m *ContainerdMirrorer) copy(ctx context.Context, src, dst string) error {
+	if m.Layout != nil {
+		return m.Registry.CopyToLayout(ctx, image.SimpleReference(src), m.Layout, dst)
+	}
+	return m.Registry.Copy(ctx, image.SimpleReference(src), image.SimpleReference(dst))
+}
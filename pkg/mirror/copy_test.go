@@ -0,0 +1,91 @@
+package mirror
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+)
+
+func TestContainerdMirrorer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+	rootCAs, err := certs.RootCAs(cafile)
+	require.NoError(t, err)
+	newRegistry := func() *containerdregistry.Registry {
+		cacheDir, err := ioutil.TempDir("", "mirror-cache-")
+		require.NoError(t, err)
+		r, err := containerdregistry.NewRegistry(
+			containerdregistry.WithLog(logrus.NewEntry(logrus.New())),
+			containerdregistry.WithCacheDir(cacheDir),
+			containerdregistry.WithRootCAs(rootCAs),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, r.Destroy()) })
+		return r
+	}
+
+	// Images in different repositories need different blobs, since pushes of
+	// the same blob to another repository are skipped.
+	r := newRegistry()
+	var sources []string
+	for _, name := range []string{"operator", "bundle"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "name.txt", Mode: 0644, Size: int64(len(name))}))
+		_, err := tw.Write([]byte(name))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+
+		ref := image.SimpleReference(host + "/test/" + name + ":v1")
+		require.NoError(t, r.Pack(ctx, nil, ref, &buf))
+		require.NoError(t, r.Push(ctx, ref))
+		sources = append(sources, ref.String())
+	}
+
+	mapping := map[string]string{
+		sources[0]:                host + "/mirror/operator:v1",
+		sources[1]:                host + "/mirror/bundle:v1",
+		host + "/test/missing:v1": host + "/mirror/missing:v1",
+	}
+	m := &ContainerdMirrorer{Registry: newRegistry(), Workers: 2}
+	err = m.Mirror(mapping)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), host+"/test/missing:v1")
+	require.NotContains(t, err.Error(), sources[0])
+	require.NotContains(t, err.Error(), sources[1])
+	for _, src := range sources {
+		want, err := r.ResolveDigest(ctx, image.SimpleReference(src))
+		require.NoError(t, err)
+		got, err := newRegistry().ResolveDigest(ctx, image.SimpleReference(mapping[src]))
+		require.NoError(t, err)
+		require.Equal(t, want.String()[len(host+"/test/"):], got.String()[len(host+"/mirror/"):])
+	}
+
+	dir, err := ioutil.TempDir("", "mirror-layout-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	layout, err := containerdregistry.NewOCILayout(dir)
+	require.NoError(t, err)
+	delete(mapping, host+"/test/missing:v1")
+	m = &ContainerdMirrorer{Registry: newRegistry(), Layout: layout}
+	require.NoError(t, m.Mirror(mapping))
+	for _, dst := range mapping {
+		_, ok, err := layout.Get(dst)
+		require.NoError(t, err)
+		require.True(t, ok, dst)
+	}
+}
@@ -0,0 +1,138 @@
+package mirror
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// mappingFile is the name of the file listing the images to mirror, in
+	// the SOURCE=DESTINATION format of `oc image mirror -f`.
+	mappingFile = "mapping.txt"
+	// icspFile is the name of the ImageContentSourcePolicy redirecting pulls
+	// of the mirrored images to their mirrors.
+	icspFile = "imageContentSourcePolicy.yaml"
+	// catalogDir is the name of the directory the catalog rewritten to
+	// reference the mirrored images is written to.
+	catalogDir = "catalog"
+)
+
+type imageContentSourcePolicy struct {
+	APIVersion string                       `json:"apiVersion"`
+	Kind       string                       `json:"kind"`
+	Metadata   icspMetadata                 `json:"metadata"`
+	Spec       imageContentSourcePolicySpec `json:"spec"`
+}
+
+type icspMetadata struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type imageContentSourcePolicySpec struct {
+	RepositoryDigestMirrors []repositoryDigestMirrors `json:"repositoryDigestMirrors"`
+}
+
+type repositoryDigestMirrors struct {
+	Source  string   `json:"source"`
+	Mirrors []string `json:"mirrors"`
+}
+
+// writeMappingFile writes mapping to dir in the format of `oc image mirror`.
+func writeMappingFile(dir string, mapping map[string]string) error {
+	sources := make([]string, 0, len(mapping))
+	for src := range mapping {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	var buf bytes.Buffer
+	for _, src := range sources {
+		fmt.Fprintf(&buf, "%s=%s\n", src, mapping[src])
+	}
+	return ioutil.WriteFile(filepath.Join(dir, mappingFile), buf.Bytes(), 0644)
+}
+
+// writeImageContentSourcePolicy writes an ImageContentSourcePolicy named
+// after source to dir, mirroring each repository of mapping to the
+// repository its images are mirrored to.
+func writeImageContentSourcePolicy(dir, source string, mapping map[string]string) error {
+	mirrors := map[string]map[string]struct{}{}
+	for src, dst := range mapping {
+		srcRepo, err := repository(src)
+		if err != nil {
+			return err
+		}
+		dstRepo, err := repository(dst)
+		if err != nil {
+			return err
+		}
+		if mirrors[srcRepo] == nil {
+			mirrors[srcRepo] = map[string]struct{}{}
+		}
+		mirrors[srcRepo][dstRepo] = struct{}{}
+	}
+
+	icsp := imageContentSourcePolicy{
+		APIVersion: "operator.openshift.io/v1alpha1",
+		Kind:       "ImageContentSourcePolicy",
+		Metadata: icspMetadata{
+			Name:   icspName(source),
+			Labels: map[string]string{"operators.openshift.org/catalog": "true"},
+		},
+	}
+	for src, dsts := range mirrors {
+		rdm := repositoryDigestMirrors{Source: src}
+		for dst := range dsts {
+			rdm.Mirrors = append(rdm.Mirrors, dst)
+		}
+		sort.Strings(rdm.Mirrors)
+		icsp.Spec.RepositoryDigestMirrors = append(icsp.Spec.RepositoryDigestMirrors, rdm)
+	}
+	sort.Slice(icsp.Spec.RepositoryDigestMirrors, func(i, j int) bool {
+		return icsp.Spec.RepositoryDigestMirrors[i].Source < icsp.Spec.RepositoryDigestMirrors[j].Source
+	})
+
+	data, err := yaml.Marshal(icsp)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, icspFile), data, 0644)
+}
+
+func repository(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("couldn't parse image %s: %v", ref, err)
+	}
+	return named.Name(), nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// icspName returns a valid object name derived from the source of the
+// catalog, either an image reference or a directory.
+func icspName(source string) string {
+	name := filepath.Base(strings.TrimRight(source, string(os.PathSeparator)))
+	if named, err := reference.ParseNormalizedNamed(source); err == nil {
+		name = reference.Path(named)
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		name = "catalog"
+	}
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-")
+	}
+	return name
+}
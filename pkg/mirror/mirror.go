@@ -3,6 +3,8 @@ package mirror
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/docker/distribution/reference"
@@ -15,7 +17,8 @@ type Mirrorer interface {
 	Mirror() (map[string]string, error)
 }
 
-// DatabaseExtractor knows how to pull an index image and extract its database
+// DatabaseExtractor knows how to pull an index image and extract its database,
+// or the directory of declarative configs it serves
 type DatabaseExtractor interface {
 	Extract(from string) (string, error)
 }
@@ -43,6 +46,10 @@ type IndexImageMirrorer struct {
 
 	// options
 	Source, Dest string
+	ManifestDir  string
+	Packages     []string
+	Channels     []string
+	Rewrite      bool
 }
 
 var _ Mirrorer = &IndexImageMirrorer{}
@@ -56,38 +63,85 @@ func NewIndexImageMirror(options ...ImageIndexMirrorOption) (*IndexImageMirrorer
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
+	if config.ManifestsOnly {
+		config.ImageMirrorer = nil
+	}
 	return &IndexImageMirrorer{
 		ImageMirrorer:     config.ImageMirrorer,
 		DatabaseExtractor: config.DatabaseExtractor,
 		Source:            config.Source,
 		Dest:              config.Dest,
+		ManifestDir:       config.ManifestDir,
+		Packages:          config.Packages,
+		Channels:          config.Channels,
+		Rewrite:           config.Rewrite,
 	}, nil
 }
 
+// Mirror mirrors the images of the catalog of the source index to the
+// destination registry, and returns the mapping of each image to its mirror.
+// The source is either an index image, whose catalog is extracted by the
+// database extractor, or a local directory of declarative configs.
+//
+// If a manifest dir is set, a mapping file for `oc image mirror` and an
+// ImageContentSourcePolicy for the mirrored images are written to it, as well
+// as the catalog itself, rewritten to reference the mirrored images, if
+// Rewrite is set.
 func (b *IndexImageMirrorer) Mirror() (map[string]string, error) {
-	dbPath, err := b.DatabaseExtractor.Extract(b.Source)
-	if err != nil {
-		return nil, err
-	}
+	ctx := context.TODO()
 
-	db, err := sqlite.Open(dbPath)
-	if err != nil {
-		return nil, err
+	catalogPath := b.Source
+	if info, err := os.Stat(b.Source); err != nil || !info.IsDir() {
+		if catalogPath, err = b.DatabaseExtractor.Extract(b.Source); err != nil {
+			return nil, err
+		}
 	}
-	defer db.Close()
 
-	migrator, err := sqlite.NewSQLLiteMigrator(db)
-	if err != nil {
-		return nil, err
-	}
-	if err := migrator.Migrate(context.TODO()); err != nil {
+	var cat *catalog
+	var images []string
+	if info, err := os.Stat(catalogPath); err != nil {
 		return nil, err
+	} else if info.IsDir() {
+		if cat, err = loadConfigsCatalog(catalogPath); err != nil {
+			return nil, err
+		}
+	} else {
+		db, err := sqlite.Open(catalogPath)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+
+		migrator, err := sqlite.NewSQLLiteMigrator(db)
+		if err != nil {
+			return nil, err
+		}
+		if err := migrator.Migrate(ctx); err != nil {
+			return nil, err
+		}
+
+		querier := sqlite.NewSQLLiteQuerierFromDb(db)
+		if !b.filtered() && !b.Rewrite {
+			// Every image the database knows of, including those of
+			// bundles no channel leads to.
+			if images, err = querier.ListImages(ctx); err != nil {
+				return nil, err
+			}
+		} else if cat, err = loadDatabaseCatalog(ctx, querier); err != nil {
+			return nil, err
+		}
 	}
 
-	querier := sqlite.NewSQLLiteQuerierFromDb(db)
-	images, err := querier.ListImages(context.TODO())
-	if err != nil {
-		return nil, err
+	if cat != nil {
+		if b.filtered() {
+			if err := cat.filter(b.Packages, b.Channels); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if images, err = cat.images(); err != nil {
+			return nil, err
+		}
 	}
 
 	mapping := map[string]string{}
@@ -103,9 +157,53 @@ func (b *IndexImageMirrorer) Mirror() (map[string]string, error) {
 		mapping[ref.String()] = b.Dest + strings.TrimPrefix(ref.String(), domain)
 	}
 
-	if err := b.ImageMirrorer.Mirror(mapping); err != nil {
-		errs = append(errs, fmt.Errorf("mirroring failed: %s", err.Error()))
+	if b.ManifestDir != "" {
+		if err := b.writeManifests(cat, mapping); err != nil {
+			return mapping, err
+		}
+	}
+
+	if b.ImageMirrorer != nil {
+		if err := b.ImageMirrorer.Mirror(mapping); err != nil {
+			errs = append(errs, fmt.Errorf("mirroring failed: %s", err.Error()))
+		}
 	}
 
 	return mapping, errors.NewAggregate(errs)
 }
+
+func (b *IndexImageMirrorer) filtered() bool {
+	return len(b.Packages) > 0 || len(b.Channels) > 0
+}
+
+func (b *IndexImageMirrorer) writeManifests(cat *catalog, mapping map[string]string) error {
+	if err := os.MkdirAll(b.ManifestDir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := writeMappingFile(b.ManifestDir, mapping); err != nil {
+		return fmt.Errorf("error writing mapping file: %v", err)
+	}
+	if err := writeImageContentSourcePolicy(b.ManifestDir, b.Source, mapping); err != nil {
+		return fmt.Errorf("error writing ImageContentSourcePolicy: %v", err)
+	}
+	if !b.Rewrite {
+		return nil
+	}
+
+	cat.rewrite(func(img string) (string, bool) {
+		ref, err := reference.ParseNormalizedNamed(img)
+		if err != nil {
+			return "", false
+		}
+		m, ok := mapping[ref.String()]
+		return m, ok
+	})
+	dir := filepath.Join(b.ManifestDir, catalogDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := cat.write(dir); err != nil {
+		return fmt.Errorf("error writing rewritten catalog: %v", err)
+	}
+	return nil
+}
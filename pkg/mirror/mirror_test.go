@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/require"
 
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
@@ -103,3 +107,205 @@ func TestIndexImageMirrorer_Mirror(t *testing.T) {
 		})
 	}
 }
+
+func TestNewIndexImageMirrorManifestDir(t *testing.T) {
+	opts := []ImageIndexMirrorOption{
+		WithExtractor(DatabaseExtractorFunc(func(from string) (string, error) { return from, nil })),
+		WithMirrorer(ImageMirrorerFunc(func(map[string]string) error { return nil })),
+		WithSource("quay.io/test/index:v1"),
+		WithDest("localhost:5000"),
+	}
+
+	// Manifests are only written when a directory is set.
+	m, err := NewIndexImageMirror(opts...)
+	require.NoError(t, err)
+	require.Empty(t, m.ManifestDir)
+
+	// Rewriting the catalog writes it with the manifests.
+	_, err = NewIndexImageMirror(append(opts, WithRewrite(true))...)
+	require.Error(t, err)
+	_, err = NewIndexImageMirror(append(opts, WithRewrite(true), WithManifestDir("manifests"))...)
+	require.NoError(t, err)
+}
+
+const testConfigs = `{"schema": "olm.package", "name": "foo", "defaultChannel": "beta"}
+{"schema": "olm.channel", "name": "stable", "package": "foo", "entries": [{"name": "foo.v1"}, {"name": "foo.v2", "replaces": "foo.v1"}]}
+{"schema": "olm.channel", "name": "beta", "package": "foo", "entries": [{"name": "foo.v3"}]}
+{"schema": "olm.bundle", "name": "foo.v1", "package": "foo", "image": "quay.io/test/foo-bundle:v1",
+ "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "1.0.0"}}],
+ "relatedImages": [{"name": "operator", "image": "quay.io/test/foo@sha256:1111111111111111111111111111111111111111111111111111111111111111"}]}
+{"schema": "olm.bundle", "name": "foo.v2", "package": "foo", "image": "quay.io/test/foo-bundle:v2",
+ "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "2.0.0"}}],
+ "relatedImages": [{"name": "operator", "image": "quay.io/test/foo@sha256:2222222222222222222222222222222222222222222222222222222222222222"}]}
+{"schema": "olm.bundle", "name": "foo.v3", "package": "foo", "image": "quay.io/test/foo-bundle:v3",
+ "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "3.0.0"}}]}
+{"schema": "olm.package", "name": "bar", "defaultChannel": "stable"}
+{"schema": "olm.channel", "name": "stable", "package": "bar", "entries": [{"name": "bar.v1"}]}
+{"schema": "olm.bundle", "name": "bar.v1", "package": "bar", "image": "docker.io/test/bar-bundle:v1",
+ "properties": [{"type": "olm.package", "value": {"packageName": "bar", "version": "1.0.0"}}]}
+`
+
+func TestIndexImageMirrorer_MirrorConfigs(t *testing.T) {
+	configsDir, err := ioutil.TempDir("", "mirror-configs-")
+	require.NoError(t, err)
+	defer os.RemoveAll(configsDir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(configsDir, "index.json"), []byte(testConfigs), 0644))
+
+	tests := []struct {
+		name     string
+		packages []string
+		channels []string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "all",
+			want: map[string]string{
+				"quay.io/test/foo-bundle:v1": "localhost:5000/test/foo-bundle:v1",
+				"quay.io/test/foo-bundle:v2": "localhost:5000/test/foo-bundle:v2",
+				"quay.io/test/foo-bundle:v3": "localhost:5000/test/foo-bundle:v3",
+				"quay.io/test/foo@sha256:1111111111111111111111111111111111111111111111111111111111111111": "localhost:5000/test/foo@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+				"quay.io/test/foo@sha256:2222222222222222222222222222222222222222222222222222222222222222": "localhost:5000/test/foo@sha256:2222222222222222222222222222222222222222222222222222222222222222",
+				"docker.io/test/bar-bundle:v1": "localhost:5000/test/bar-bundle:v1",
+			},
+		},
+		{
+			name:     "package",
+			packages: []string{"bar"},
+			want: map[string]string{
+				"docker.io/test/bar-bundle:v1": "localhost:5000/test/bar-bundle:v1",
+			},
+		},
+		{
+			name:     "channel",
+			packages: []string{"foo"},
+			channels: []string{"beta"},
+			want: map[string]string{
+				"quay.io/test/foo-bundle:v3": "localhost:5000/test/foo-bundle:v3",
+			},
+		},
+		{
+			name:     "unknown package",
+			packages: []string{"baz"},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown channel",
+			channels: []string{"alpha"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestDir, err := ioutil.TempDir("", "mirror-manifests-")
+			require.NoError(t, err)
+			defer os.RemoveAll(manifestDir)
+
+			var mirrored map[string]string
+			m, err := NewIndexImageMirror(
+				WithSource(configsDir),
+				WithDest("localhost:5000"),
+				WithManifestDir(manifestDir),
+				WithPackages(tt.packages...),
+				WithChannels(tt.channels...),
+				WithRewrite(true),
+				WithMirrorer(ImageMirrorerFunc(func(mapping map[string]string) error {
+					mirrored = mapping
+					return nil
+				})),
+				WithExtractor(DatabaseExtractorFunc(func(from string) (string, error) {
+					return "", fmt.Errorf("%s is a directory, not an image", from)
+				})),
+			)
+			require.NoError(t, err)
+
+			got, err := m.Mirror()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.want, mirrored)
+
+			// The mapping file lists each image and its mirror.
+			data, err := ioutil.ReadFile(filepath.Join(manifestDir, "mapping.txt"))
+			require.NoError(t, err)
+			var lines []string
+			for src, dst := range tt.want {
+				lines = append(lines, src+"="+dst)
+			}
+			require.ElementsMatch(t, lines, strings.Split(strings.TrimSpace(string(data)), "\n"))
+
+			// The ImageContentSourcePolicy mirrors each repository.
+			data, err = ioutil.ReadFile(filepath.Join(manifestDir, "imageContentSourcePolicy.yaml"))
+			require.NoError(t, err)
+			var icsp imageContentSourcePolicy
+			require.NoError(t, yaml.Unmarshal(data, &icsp))
+			require.Equal(t, "ImageContentSourcePolicy", icsp.Kind)
+			require.Equal(t, icspName(configsDir), icsp.Metadata.Name)
+			repos := map[string][]string{}
+			for src, dst := range tt.want {
+				srcRepo, err := repository(src)
+				require.NoError(t, err)
+				dstRepo, err := repository(dst)
+				require.NoError(t, err)
+				repos[srcRepo] = []string{dstRepo}
+			}
+			require.Len(t, icsp.Spec.RepositoryDigestMirrors, len(repos))
+			for _, rdm := range icsp.Spec.RepositoryDigestMirrors {
+				require.Equal(t, repos[rdm.Source], rdm.Mirrors)
+			}
+
+			// The rewritten catalog only references mirrored images.
+			cat, err := loadConfigsCatalog(filepath.Join(manifestDir, "catalog"))
+			require.NoError(t, err)
+			images, err := cat.images()
+			require.NoError(t, err)
+			var mirrors []string
+			for _, dst := range tt.want {
+				mirrors = append(mirrors, dst)
+			}
+			require.ElementsMatch(t, mirrors, images)
+		})
+	}
+}
+
+func TestIndexImageMirrorer_MirrorDatabaseChannel(t *testing.T) {
+	_, path, cleanup := CreateTestDb(t)
+	defer cleanup()
+
+	manifestDir, err := ioutil.TempDir("", "mirror-manifests-")
+	require.NoError(t, err)
+	defer os.RemoveAll(manifestDir)
+
+	m, err := NewIndexImageMirror(
+		WithSource("quay.io/test/index:v1"),
+		WithDest("localhost"),
+		WithManifestDir(manifestDir),
+		WithPackages("etcd"),
+		WithChannels("alpha"),
+		WithRewrite(true),
+		WithManifestsOnly(true),
+		WithExtractor(DatabaseExtractorFunc(func(from string) (string, error) {
+			return path, nil
+		})),
+	)
+	require.NoError(t, err)
+	got, err := m.Mirror()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"quay.io/coreos/etcd-operator@sha256:bd944a211eaf8f31da5e6d69e8541e7cada8f16a9f7a5a570b22478997819943": "localhost/coreos/etcd-operator@sha256:bd944a211eaf8f31da5e6d69e8541e7cada8f16a9f7a5a570b22478997819943",
+		"quay.io/coreos/etcd-operator@sha256:c0301e4686c3ed4206e370b42de5a3bd2229b9fb4906cf85f3f30650424abec2": "localhost/coreos/etcd-operator@sha256:c0301e4686c3ed4206e370b42de5a3bd2229b9fb4906cf85f3f30650424abec2",
+		"quay.io/coreos/etcd-operator@sha256:db563baa8194fcfe39d1df744ed70024b0f1f9e9b55b5923c2f3a413c44dc6b8": "localhost/coreos/etcd-operator@sha256:db563baa8194fcfe39d1df744ed70024b0f1f9e9b55b5923c2f3a413c44dc6b8",
+		"quay.io/coreos/etcd@sha256:49d3d4a81e0d030d3f689e7167f23e120abf955f7d08dbedf3ea246485acee9f":          "localhost/coreos/etcd@sha256:49d3d4a81e0d030d3f689e7167f23e120abf955f7d08dbedf3ea246485acee9f",
+		"quay.io/coreos/etcd@sha256:3816b6daf9b66d6ced6f0f966314e2d4f894982c6b1493061502f8c2bf86ac84":          "localhost/coreos/etcd@sha256:3816b6daf9b66d6ced6f0f966314e2d4f894982c6b1493061502f8c2bf86ac84",
+	}, got)
+
+	// The database catalog is rewritten as declarative configs.
+	cat, err := loadConfigsCatalog(filepath.Join(manifestDir, "catalog"))
+	require.NoError(t, err)
+	require.Len(t, cat.model, 1)
+	require.Contains(t, cat.model["etcd"].Channels, "alpha")
+	require.Len(t, cat.model["etcd"].Channels, 1)
+}
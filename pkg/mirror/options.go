@@ -9,13 +9,26 @@ type IndexImageMirrorerOptions struct {
 	DatabaseExtractor DatabaseExtractor
 
 	Source, Dest string
-	ManifestDir  string
+	// ManifestDir, if set, is the directory that a mapping file and an
+	// ImageContentSourcePolicy for the mirrored images are written to.
+	ManifestDir string
+
+	// Packages and Channels, if set, restrict mirroring to the bundles of
+	// these packages and channels.
+	Packages []string
+	Channels []string
+
+	// Rewrite writes the catalog, rewritten to reference the mirrored images,
+	// to the manifest dir.
+	Rewrite bool
+	// ManifestsOnly only writes the manifests, without mirroring images.
+	ManifestsOnly bool
 }
 
 func (o *IndexImageMirrorerOptions) Validate() error {
 	// TODO: better validation
 
-	if o.ImageMirrorer == nil {
+	if o.ImageMirrorer == nil && !o.ManifestsOnly {
 		return fmt.Errorf("can't mirror without a mirrorer configured")
 	}
 	if o.DatabaseExtractor == nil {
@@ -29,7 +42,7 @@ func (o *IndexImageMirrorerOptions) Validate() error {
 		return fmt.Errorf("destination registry required")
 	}
 
-	if o.ManifestDir == "" {
+	if o.ManifestDir == "" && (o.Rewrite || o.ManifestsOnly) {
 		return fmt.Errorf("must have directory to write manifests to")
 	}
 
@@ -37,9 +50,6 @@ func (o *IndexImageMirrorerOptions) Validate() error {
 }
 
 func (o *IndexImageMirrorerOptions) Complete() error {
-	if o.ManifestDir == "" {
-		o.ManifestDir = "./manifests"
-	}
 	return nil
 }
 
@@ -69,15 +79,25 @@ func (c *IndexImageMirrorerOptions) ToOption() ImageIndexMirrorOption {
 		if c.ManifestDir != "" {
 			o.ManifestDir = c.ManifestDir
 		}
+		if len(c.Packages) > 0 {
+			o.Packages = c.Packages
+		}
+		if len(c.Channels) > 0 {
+			o.Channels = c.Channels
+		}
+		if c.Rewrite {
+			o.Rewrite = c.Rewrite
+		}
+		if c.ManifestsOnly {
+			o.ManifestsOnly = c.ManifestsOnly
+		}
 	}
 }
 
 type ImageIndexMirrorOption func(*IndexImageMirrorerOptions)
 
 func DefaultImageIndexMirrorerOptions() *IndexImageMirrorerOptions {
-	return &IndexImageMirrorerOptions{
-		ManifestDir: "./manifests",
-	}
+	return &IndexImageMirrorerOptions{}
 }
 
 func WithMirrorer(i ImageMirrorer) ImageIndexMirrorOption {
@@ -109,3 +129,31 @@ func WithManifestDir(d string) ImageIndexMirrorOption {
 		o.ManifestDir = d
 	}
 }
+
+func WithPackages(packages ...string) ImageIndexMirrorOption {
+	return func(o *IndexImageMirrorerOptions) {
+		o.Packages = packages
+	}
+}
+
+func WithChannels(channels ...string) ImageIndexMirrorOption {
+	return func(o *IndexImageMirrorerOptions) {
+		o.Channels = channels
+	}
+}
+
+// WithRewrite makes the mirrorer write the catalog, rewritten to reference
+// the mirrored images, to the manifest dir.
+func WithRewrite(rewrite bool) ImageIndexMirrorOption {
+	return func(o *IndexImageMirrorerOptions) {
+		o.Rewrite = rewrite
+	}
+}
+
+// WithManifestsOnly makes the mirrorer only write the manifests, leaving the
+// images to be mirrored with the mapping file.
+func WithManifestsOnly(manifestsOnly bool) ImageIndexMirrorOption {
+	return func(o *IndexImageMirrorerOptions) {
+		o.ManifestsOnly = manifestsOnly
+	}
+}
@@ -345,6 +345,7 @@ func (b *Bundle) cache() error {
 	if !b.cacheStale {
 		return nil
 	}
+	b.csv, b.v1crds, b.v1beta1crds = nil, nil, nil
 	for _, o := range b.Objects {
 		if o.GroupVersionKind().Kind == "ClusterServiceVersion" {
 			csv := &ClusterServiceVersion{}
@@ -100,6 +100,10 @@ func (EmptyQuery) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
 	return nil, errors.New("empty querier: cannot list bundles")
 }
 
+func (EmptyQuery) ListFilteredBundles(ctx context.Context, filter BundleFilter) ([]*api.Bundle, error) {
+	return nil, errors.New("empty querier: cannot list bundles")
+}
+
 func (EmptyQuery) GetDependenciesForBundle(ctx context.Context, name, version, path string) (dependencies []*api.Dependency, err error) {
 	return nil, errors.New("empty querier: cannot get dependencies for bundle")
 }
@@ -113,6 +117,7 @@ func (EmptyQuery) ListRegistryBundles(ctx context.Context) ([]*Bundle, error) {
 }
 
 var _ Query = &EmptyQuery{}
+var _ FilteredBundleLister = &EmptyQuery{}
 
 func NewEmptyQuerier() *EmptyQuery {
 	return &EmptyQuery{}
@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// BundleFilter selects the bundles returned by ListFilteredBundles. Unset
+// fields match every bundle.
+type BundleFilter struct {
+	PackageName string
+	ChannelName string
+
+	// Properties must each be matched by a property of the bundle.
+	Properties []PropertyFilter
+
+	// ProvidedAPI must be provided by the bundle. The plural is ignored.
+	ProvidedAPI *APIKey
+
+	// FieldMask lists the fields of api.Bundle, by their protobuf names, that
+	// are returned. All fields are returned if it is empty.
+	FieldMask []string
+}
+
+// ListFilteredBundles lists the bundles of store that match filter. Stores
+// that implement FilteredBundleLister filter the bundles themselves; the
+// bundles of other stores are all listed, and then filtered and masked.
+func ListFilteredBundles(ctx context.Context, store GRPCQuery, filter BundleFilter) ([]*api.Bundle, error) {
+	if l, ok := store.(FilteredBundleLister); ok {
+		return l.ListFilteredBundles(ctx, filter)
+	}
+	bundles, err := store.ListBundles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []*api.Bundle
+	for _, b := range bundles {
+		if !filter.Matches(b) {
+			continue
+		}
+		filter.Mask(b)
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// PropertyFilter matches properties of a type and, if Value is set, with an
+// equivalent JSON value.
+type PropertyFilter struct {
+	Type  string
+	Value string
+}
+
+// NewBundleFilter converts a ListBundlesRequest to a BundleFilter.
+func NewBundleFilter(req *api.ListBundlesRequest) (BundleFilter, error) {
+	f := BundleFilter{
+		PackageName: req.GetPkgName(),
+		ChannelName: req.GetChannelName(),
+		FieldMask:   req.GetFieldMask(),
+	}
+	for _, p := range req.GetProperties() {
+		f.Properties = append(f.Properties, PropertyFilter{Type: p.GetType(), Value: p.GetValue()})
+	}
+	if gvk := req.GetProvidedApi(); gvk != nil {
+		f.ProvidedAPI = &APIKey{Group: gvk.GetGroup(), Version: gvk.GetVersion(), Kind: gvk.GetKind(), Plural: gvk.GetPlural()}
+	}
+	return f, f.Validate()
+}
+
+// Validate checks that the properties and fields named by the filter exist.
+func (f BundleFilter) Validate() error {
+	for _, p := range f.Properties {
+		if p.Type == "" {
+			return fmt.Errorf("property filter must have a type")
+		}
+		if p.Value != "" && !json.Valid([]byte(p.Value)) {
+			return fmt.Errorf("value of property filter for type %q is not valid JSON", p.Type)
+		}
+	}
+	fields := (&api.Bundle{}).ProtoReflect().Descriptor().Fields()
+	for _, name := range f.FieldMask {
+		if fields.ByName(protoreflect.Name(name)) == nil {
+			return fmt.Errorf("unknown bundle field %q in field mask", name)
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the filter matches every bundle and returns all of
+// their fields.
+func (f BundleFilter) IsEmpty() bool {
+	return f.PackageName == "" && f.ChannelName == "" && len(f.Properties) == 0 && f.ProvidedAPI == nil && len(f.FieldMask) == 0
+}
+
+// Matches reports whether a bundle is selected by the filter.
+func (f BundleFilter) Matches(b *api.Bundle) bool {
+	if f.PackageName != "" && b.GetPackageName() != f.PackageName {
+		return false
+	}
+	if f.ChannelName != "" && b.GetChannelName() != f.ChannelName {
+		return false
+	}
+	for _, pf := range f.Properties {
+		if !pf.matchesAny(b.GetProperties()) {
+			return false
+		}
+	}
+	if f.ProvidedAPI != nil {
+		provided := false
+		for _, gvk := range b.GetProvidedApis() {
+			if gvk.GetGroup() == f.ProvidedAPI.Group && gvk.GetVersion() == f.ProvidedAPI.Version && gvk.GetKind() == f.ProvidedAPI.Kind {
+				provided = true
+				break
+			}
+		}
+		if !provided {
+			return false
+		}
+	}
+	return true
+}
+
+// Includes reports whether a field of api.Bundle is returned.
+func (f BundleFilter) Includes(field string) bool {
+	if len(f.FieldMask) == 0 {
+		return true
+	}
+	for _, name := range f.FieldMask {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask clears the fields of b that are not in the field mask.
+func (f BundleFilter) Mask(b *api.Bundle) {
+	if len(f.FieldMask) == 0 {
+		return
+	}
+	m := b.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); !f.Includes(string(fd.Name())) {
+			m.Clear(fd)
+		}
+	}
+}
+
+func (pf PropertyFilter) matchesAny(props []*api.Property) bool {
+	for _, p := range props {
+		if p.GetType() != pf.Type {
+			continue
+		}
+		if pf.Value == "" || jsonEqual(p.GetValue(), pf.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonEqual(a, b string) bool {
+	var av, bv interface{}
+	if err := json.Unmarshal([]byte(a), &av); err != nil {
+		return a == b
+	}
+	if err := json.Unmarshal([]byte(b), &bv); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
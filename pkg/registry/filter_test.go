@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+func TestNewBundleFilter(t *testing.T) {
+	f, err := NewBundleFilter(&api.ListBundlesRequest{
+		PkgName:     "etcd",
+		ChannelName: "alpha",
+		Properties:  []*api.PropertyFilter{{Type: "olm.label", Value: `{"label":"foo"}`}},
+		ProvidedApi: &api.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"},
+		FieldMask:   []string{"csvName"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, BundleFilter{
+		PackageName: "etcd",
+		ChannelName: "alpha",
+		Properties:  []PropertyFilter{{Type: "olm.label", Value: `{"label":"foo"}`}},
+		ProvidedAPI: &APIKey{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"},
+		FieldMask:   []string{"csvName"},
+	}, f)
+	require.False(t, f.IsEmpty())
+
+	f, err = NewBundleFilter(&api.ListBundlesRequest{})
+	require.NoError(t, err)
+	require.True(t, f.IsEmpty())
+
+	for _, req := range []*api.ListBundlesRequest{
+		{Properties: []*api.PropertyFilter{{Value: `{}`}}},
+		{Properties: []*api.PropertyFilter{{Type: "olm.label", Value: `{`}}},
+		{FieldMask: []string{"csv_json"}},
+	} {
+		_, err := NewBundleFilter(req)
+		require.Error(t, err, "request %v", req)
+	}
+}
+
+func TestBundleFilterMatches(t *testing.T) {
+	b := &api.Bundle{
+		CsvName:     "etcdoperator.v0.9.2",
+		PackageName: "etcd",
+		ChannelName: "alpha",
+		ProvidedApis: []*api.GroupVersionKind{
+			{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster", Plural: "etcdclusters"},
+		},
+		Properties: []*api.Property{
+			{Type: "olm.package", Value: `{"packageName":"etcd","version":"0.9.2"}`},
+			{Type: "olm.label", Value: `{"label":"foo"}`},
+		},
+	}
+	for _, tt := range []struct {
+		name    string
+		filter  BundleFilter
+		matches bool
+	}{
+		{name: "Empty", matches: true},
+		{name: "Package", filter: BundleFilter{PackageName: "etcd"}, matches: true},
+		{name: "OtherPackage", filter: BundleFilter{PackageName: "prometheus"}},
+		{name: "Channel", filter: BundleFilter{PackageName: "etcd", ChannelName: "alpha"}, matches: true},
+		{name: "OtherChannel", filter: BundleFilter{PackageName: "etcd", ChannelName: "stable"}},
+		{name: "PropertyType", filter: BundleFilter{Properties: []PropertyFilter{{Type: "olm.label"}}}, matches: true},
+		{name: "MissingPropertyType", filter: BundleFilter{Properties: []PropertyFilter{{Type: "olm.deprecated"}}}},
+		{
+			name:    "EquivalentPropertyValue",
+			filter:  BundleFilter{Properties: []PropertyFilter{{Type: "olm.package", Value: `{"version": "0.9.2", "packageName": "etcd"}`}}},
+			matches: true,
+		},
+		{name: "OtherPropertyValue", filter: BundleFilter{Properties: []PropertyFilter{{Type: "olm.label", Value: `{"label":"bar"}`}}}},
+		{
+			name:   "AllProperties",
+			filter: BundleFilter{Properties: []PropertyFilter{{Type: "olm.label"}, {Type: "olm.deprecated"}}},
+		},
+		{
+			name:    "ProvidedAPI",
+			filter:  BundleFilter{ProvidedAPI: &APIKey{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}},
+			matches: true,
+		},
+		{
+			name:   "OtherProvidedAPI",
+			filter: BundleFilter{ProvidedAPI: &APIKey{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdBackup"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.matches, tt.filter.Matches(b))
+		})
+	}
+}
+
+func TestBundleFilterMask(t *testing.T) {
+	b := &api.Bundle{
+		CsvName:     "etcdoperator.v0.9.2",
+		PackageName: "etcd",
+		CsvJson:     "{}",
+		Object:      []string{"{}"},
+		Properties:  []*api.Property{{Type: "olm.label", Value: `{"label":"foo"}`}},
+	}
+
+	BundleFilter{}.Mask(b)
+	require.Equal(t, "{}", b.CsvJson)
+
+	f := BundleFilter{FieldMask: []string{"csvName", "properties"}}
+	require.True(t, f.Includes("csvName"))
+	require.False(t, f.Includes("csvJson"))
+	f.Mask(b)
+	require.Equal(t, "etcdoperator.v0.9.2", b.CsvName)
+	require.Len(t, b.Properties, 1)
+	require.Empty(t, b.PackageName)
+	require.Empty(t, b.CsvJson)
+	require.Empty(t, b.Object)
+}
+
+// bundleListQuery only implements ListBundles, so it is filtered by
+// ListFilteredBundles.
+type bundleListQuery struct {
+	GRPCQuery
+	bundles []*api.Bundle
+}
+
+func (q bundleListQuery) ListBundles(context.Context) ([]*api.Bundle, error) {
+	return q.bundles, nil
+}
+
+func TestListFilteredBundles(t *testing.T) {
+	store := bundleListQuery{bundles: []*api.Bundle{
+		{CsvName: "etcdoperator.v0.9.2", PackageName: "etcd", CsvJson: "{}"},
+		{CsvName: "prometheusoperator.0.22.2", PackageName: "prometheus", CsvJson: "{}"},
+	}}
+	bundles, err := ListFilteredBundles(context.TODO(), store, BundleFilter{PackageName: "etcd", FieldMask: []string{"csvName"}})
+	require.NoError(t, err)
+	require.Len(t, bundles, 1)
+	require.Equal(t, "etcdoperator.v0.9.2", bundles[0].CsvName)
+	require.Empty(t, bundles[0].PackageName)
+	require.Empty(t, bundles[0].CsvJson)
+}
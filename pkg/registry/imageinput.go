@@ -109,8 +109,11 @@ func (i *ImageInput) getBundleFromManifests() error {
 		return fmt.Errorf("no bundle objects found")
 	}
 
-	// set the bundleimage on the bundle
-	bundle.BundleImage = i.to.String()
+	// set the bundleimage on the bundle; local images are recorded by their
+	// name rather than by their path
+	if bundle.BundleImage, err = image.Name(i.to); err != nil {
+		return err
+	}
 	// set the dependencies on the bundle
 	bundle.Dependencies = i.dependenciesFile.GetDependencies()
 
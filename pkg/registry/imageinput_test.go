@@ -0,0 +1,33 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+func TestNewImageInputBundleImage(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "quay.io/operatorhubio/etcd:v0.9.2", want: "quay.io/operatorhubio/etcd:v0.9.2"},
+		{ref: "oci-archive:etcd.tar:quay.io/operatorhubio/etcd:v0.9.2", want: "quay.io/operatorhubio/etcd:v0.9.2"},
+		{ref: "docker-archive:etcd.tar", wantErr: true},
+		{ref: "oci:etcd:v0.9.2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			input, err := NewImageInput(image.SimpleReference(tt.ref), "../../bundles/etcd.0.9.2")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, input.Bundle.BundleImage)
+		})
+	}
+}
@@ -17,6 +17,14 @@ type Load interface {
 	ClearNonHeadBundles() error
 }
 
+// ContextLoad is a Load that can run its operations under a context, e.g. to
+// trace them as part of the operation that loads the bundles.
+type ContextLoad interface {
+	Load
+	// WithContext returns a Load whose operations run under ctx.
+	WithContext(ctx context.Context) Load
+}
+
 type GRPCQuery interface {
 	// List all available package names in the index
 	ListPackages(ctx context.Context) ([]string, error)
@@ -49,6 +57,13 @@ type GRPCQuery interface {
 	GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error)
 }
 
+// FilteredBundleLister is implemented by GRPCQuery stores that can narrow
+// the bundles they read by a filter. See ListFilteredBundles.
+type FilteredBundleLister interface {
+	// List the bundles in the index that match a filter
+	ListFilteredBundles(ctx context.Context, filter BundleFilter) (bundles []*api.Bundle, err error)
+}
+
 type Query interface {
 	GRPCQuery
 
@@ -0,0 +1,24 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+func TestOrderedImageRefs(t *testing.T) {
+	a, b, c, d := image.SimpleReference("quay.io/test/a:v1"), image.SimpleReference("quay.io/test/b:v1"), image.SimpleReference("quay.io/test/c:v1"), image.SimpleReference("quay.io/test/d:v1")
+	images := map[image.Reference]string{a: "a", b: "b", c: "c", d: "d"}
+
+	// Without an order, images are ordered by reference.
+	p := NewDirectoryPopulator(nil, nil, nil, images, nil, false)
+	require.Equal(t, []image.Reference{a, b, c, d}, p.orderedImageRefs(images))
+
+	// Images keep the requested order, ignoring duplicates and images that
+	// are not being loaded, and those not requested follow by reference.
+	e := image.SimpleReference("quay.io/test/e:v1")
+	p = NewDirectoryPopulator(nil, nil, nil, images, nil, false, LoadInOrder([]image.Reference{c, e, a, c}))
+	require.Equal(t, []image.Reference{c, a, b, d}, p.orderedImageRefs(images))
+}
@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"context"
+	"sort"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// graphFieldMask lists the bundle fields used to build package graphs and
+// deprecations, so queriers can skip reading bundle manifests.
+var graphFieldMask = []string{"csvName", "packageName", "channelName", "version", "bundlePath", "replaces", "skips", "skipRange", "properties"}
+
+// PackageGraphFilter returns the filter that selects the bundles needed by
+// NewPackageGraph.
+func PackageGraphFilter(pkgName string) BundleFilter {
+	return BundleFilter{PackageName: pkgName, FieldMask: graphFieldMask}
+}
+
+// DeprecationsFilter returns the filter that selects the bundles needed by
+// NewDeprecations. All packages are selected if pkgName is empty.
+func DeprecationsFilter(pkgName string) BundleFilter {
+	return BundleFilter{
+		PackageName: pkgName,
+		Properties:  []PropertyFilter{{Type: DeprecatedType}},
+		FieldMask:   graphFieldMask,
+	}
+}
+
+// GetPackageGraph returns the upgrade graph of every channel of a package in
+// store. A PackageNotFoundErr is returned if the package is not in store.
+func GetPackageGraph(ctx context.Context, store GRPCQuery, pkgName string) (*api.PackageGraph, error) {
+	manifest, err := store.GetPackage(ctx, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := ListFilteredBundles(ctx, store, PackageGraphFilter(pkgName))
+	if err != nil {
+		return nil, err
+	}
+	return NewPackageGraph(manifest, bundles), nil
+}
+
+// GetDeprecations lists the deprecated bundles in store, or in a package if
+// pkgName is set.
+func GetDeprecations(ctx context.Context, store GRPCQuery, pkgName string) ([]*api.Deprecation, error) {
+	bundles, err := ListFilteredBundles(ctx, store, DeprecationsFilter(pkgName))
+	if err != nil {
+		return nil, err
+	}
+	return NewDeprecations(bundles), nil
+}
+
+// NewPackageGraph builds the upgrade graph of a package from its manifest
+// and the bundles in each of its channels.
+func NewPackageGraph(manifest *PackageManifest, bundles []*api.Bundle) *api.PackageGraph {
+	graph := &api.PackageGraph{
+		Name:               manifest.PackageName,
+		DefaultChannelName: manifest.DefaultChannelName,
+	}
+	channels := map[string]*api.ChannelGraph{}
+	for _, ch := range manifest.Channels {
+		channels[ch.Name] = &api.ChannelGraph{Name: ch.Name, Head: ch.CurrentCSVName}
+		graph.Channels = append(graph.Channels, channels[ch.Name])
+	}
+	for _, b := range bundles {
+		ch, ok := channels[b.ChannelName]
+		if !ok {
+			continue
+		}
+		ch.Nodes = append(ch.Nodes, &api.GraphNode{
+			CsvName:    b.CsvName,
+			Version:    b.Version,
+			BundlePath: b.BundlePath,
+			Replaces:   b.Replaces,
+			Skips:      b.Skips,
+			SkipRange:  b.SkipRange,
+			Deprecated: isDeprecated(b),
+		})
+	}
+
+	sort.Slice(graph.Channels, func(i, j int) bool {
+		return graph.Channels[i].Name < graph.Channels[j].Name
+	})
+	for _, ch := range graph.Channels {
+		sort.Slice(ch.Nodes, func(i, j int) bool {
+			return ch.Nodes[i].CsvName < ch.Nodes[j].CsvName
+		})
+	}
+	return graph
+}
+
+// NewDeprecations returns a deprecation for each deprecated bundle, merging
+// the entries of a bundle in several channels. Deprecations are ordered by
+// package and csv name.
+func NewDeprecations(bundles []*api.Bundle) []*api.Deprecation {
+	type key struct{ pkg, name string }
+	byKey := map[key]*api.Deprecation{}
+	var deprecations []*api.Deprecation
+	for _, b := range bundles {
+		if !isDeprecated(b) {
+			continue
+		}
+		k := key{b.PackageName, b.CsvName}
+		d, ok := byKey[k]
+		if !ok {
+			d = &api.Deprecation{
+				PackageName: b.PackageName,
+				CsvName:     b.CsvName,
+				Version:     b.Version,
+				BundlePath:  b.BundlePath,
+			}
+			byKey[k] = d
+			deprecations = append(deprecations, d)
+		}
+		d.Channels = append(d.Channels, b.ChannelName)
+	}
+
+	sort.Slice(deprecations, func(i, j int) bool {
+		if deprecations[i].PackageName != deprecations[j].PackageName {
+			return deprecations[i].PackageName < deprecations[j].PackageName
+		}
+		return deprecations[i].CsvName < deprecations[j].CsvName
+	})
+	for _, d := range deprecations {
+		sort.Strings(d.Channels)
+	}
+	return deprecations
+}
+
+func isDeprecated(b *api.Bundle) bool {
+	for _, p := range b.Properties {
+		if p.Type == DeprecatedType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// PinnedImageType is the type of the properties recording the references
+// images of a bundle had before they were pinned to digests.
+const PinnedImageType = "olm.image.pinned"
+
+// PinnedImageProperty records that the image Image, referenced by digest,
+// was referenced as Ref when the bundle was added.
+type PinnedImageProperty struct {
+	Image string `json:"image"`
+	Ref   string `json:"ref"`
+}
+
+// DigestResolver resolves image references to the digest they refer to.
+type DigestResolver interface {
+	// ResolveDigest returns a reference by digest to the image ref refers to.
+	ResolveDigest(ctx context.Context, ref image.Reference) (image.Reference, error)
+}
+
+// PinBundleImages rewrites the references to the bundle image, the related
+// images and the operator images of a bundle that aren't by digest into
+// references by digest, so that the content of the bundle can't change
+// afterwards. Each rewritten reference is recorded as a property of type
+// PinnedImageType.
+func PinBundleImages(ctx context.Context, b *Bundle, resolver DigestResolver) error {
+	pinned := map[string]string{}
+	pin := func(ref string) (string, error) {
+		if ref == "" {
+			return ref, nil
+		}
+		if p, ok := pinned[ref]; ok {
+			return p, nil
+		}
+		// Local references would parse as names of images in a registry.
+		if image.IsLocalReference(image.SimpleReference(ref)) {
+			return "", fmt.Errorf("can't pin local image %s to a digest", ref)
+		}
+		named, err := reference.ParseNormalizedNamed(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid image reference %q: %v", ref, err)
+		}
+		if _, ok := named.(reference.Digested); ok {
+			pinned[ref] = ref
+			return ref, nil
+		}
+		resolved, err := resolver.ResolveDigest(ctx, image.SimpleReference(ref))
+		if err != nil {
+			return "", fmt.Errorf("error resolving digest of %s: %v", ref, err)
+		}
+		value, err := json.Marshal(PinnedImageProperty{Image: resolved.String(), Ref: ref})
+		if err != nil {
+			return "", err
+		}
+		b.Properties = append(b.Properties, &Property{Type: PinnedImageType, Value: value})
+		pinned[ref] = resolved.String()
+		return resolved.String(), nil
+	}
+
+	var err error
+	if b.BundleImage, err = pin(b.BundleImage); err != nil {
+		return err
+	}
+
+	for _, obj := range b.Objects {
+		if obj.GetKind() != "ClusterServiceVersion" {
+			continue
+		}
+		if err := pinImageFields(obj.Object, pin, "spec", relatedImages); err != nil {
+			return err
+		}
+		deployments, found, err := unstructured.NestedSlice(obj.Object, "spec", "install", "spec", "deployments")
+		if err != nil {
+			return fmt.Errorf("error reading deployments of csv %s: %v", obj.GetName(), err)
+		}
+		if !found {
+			continue
+		}
+		for _, d := range deployments {
+			deployment, ok := d.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, field := range []string{"containers", "initContainers"} {
+				if err := pinImageFields(deployment, pin, "spec", "template", "spec", field); err != nil {
+					return err
+				}
+			}
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, deployments, "spec", "install", "spec", "deployments"); err != nil {
+			return err
+		}
+	}
+	b.cacheStale = true
+	return nil
+}
+
+// pinImageFields pins the image field of each element of the list at fields
+// in obj.
+func pinImageFields(obj map[string]interface{}, pin func(string) (string, error), fields ...string) error {
+	list, found, err := unstructured.NestedSlice(obj, fields...)
+	if err != nil || !found {
+		return err
+	}
+	for _, e := range list {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ref, ok := m["image"].(string)
+		if !ok {
+			continue
+		}
+		if m["image"], err = pin(ref); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedSlice(obj, list, fields...)
+}
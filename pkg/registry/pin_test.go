@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+type fakeDigestResolver map[string]string
+
+func (f fakeDigestResolver) ResolveDigest(_ context.Context, ref image.Reference) (image.Reference, error) {
+	digested, ok := f[ref.String()]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", ref)
+	}
+	return image.SimpleReference(digested), nil
+}
+
+func TestPinBundleImages(t *testing.T) {
+	const (
+		operatorDigest = "quay.io/example/operator@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		bundleDigest   = "quay.io/example/bundle@sha256:2222222222222222222222222222222222222222222222222222222222222222"
+		proxyDigest    = "quay.io/example/proxy@sha256:3333333333333333333333333333333333333333333333333333333333333333"
+		pinnedDigest   = "quay.io/example/pinned@sha256:4444444444444444444444444444444444444444444444444444444444444444"
+	)
+	resolver := fakeDigestResolver{
+		"quay.io/example/operator:v1": operatorDigest,
+		"quay.io/example/bundle:v1":   bundleDigest,
+		"quay.io/example/proxy:v1":    proxyDigest,
+	}
+
+	csv := &unstructured.Unstructured{}
+	require.NoError(t, csv.UnmarshalJSON([]byte(`{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind": "ClusterServiceVersion",
+		"metadata": {"name": "example.v1.0.0"},
+		"spec": {
+			"version": "1.0.0",
+			"relatedImages": [
+				{"name": "operator", "image": "quay.io/example/operator:v1"},
+				{"name": "pinned", "image": "`+pinnedDigest+`"}
+			],
+			"install": {
+				"strategy": "deployment",
+				"spec": {
+					"deployments": [{
+						"name": "example-operator",
+						"spec": {"template": {"spec": {
+							"containers": [{"name": "operator", "image": "quay.io/example/operator:v1"}],
+							"initContainers": [{"name": "proxy", "image": "quay.io/example/proxy:v1"}]
+						}}}
+					}]
+				}
+			}
+		}
+	}`)))
+	b := NewBundle("example.v1.0.0", &Annotations{PackageName: "example", Channels: "stable"}, csv)
+	b.BundleImage = "quay.io/example/bundle:v1"
+	before, err := b.ClusterServiceVersion()
+	require.NoError(t, err)
+	require.NotNil(t, before)
+
+	require.NoError(t, PinBundleImages(context.Background(), b, resolver))
+
+	require.Equal(t, bundleDigest, b.BundleImage)
+	images, err := b.Images()
+	require.NoError(t, err)
+	require.Equal(t, map[string]struct{}{
+		bundleDigest:   {},
+		operatorDigest: {},
+		proxyDigest:    {},
+		pinnedDigest:   {},
+	}, images)
+
+	var pinned []PinnedImageProperty
+	for _, p := range b.Properties {
+		require.Equal(t, PinnedImageType, p.Type)
+		var v PinnedImageProperty
+		require.NoError(t, json.Unmarshal(p.Value, &v))
+		pinned = append(pinned, v)
+	}
+	require.ElementsMatch(t, []PinnedImageProperty{
+		{Image: bundleDigest, Ref: "quay.io/example/bundle:v1"},
+		{Image: operatorDigest, Ref: "quay.io/example/operator:v1"},
+		{Image: proxyDigest, Ref: "quay.io/example/proxy:v1"},
+	}, pinned)
+
+	// Pinning again changes nothing, since every image is already pinned.
+	require.NoError(t, PinBundleImages(context.Background(), b, fakeDigestResolver{}))
+	require.Len(t, b.Properties, 3)
+
+	// Images that can't be resolved fail the bundle.
+	b = NewBundle("example.v1.0.0", &Annotations{PackageName: "example", Channels: "stable"}, csv)
+	b.BundleImage = "quay.io/example/missing:v1"
+	require.Error(t, PinBundleImages(context.Background(), b, resolver))
+
+	// Local images aren't taken for images in a registry.
+	b = NewBundle("example.v1.0.0", &Annotations{PackageName: "example", Channels: "stable"}, csv)
+	b.BundleImage = "oci-archive:bundle.tar:quay.io/example/bundle:v1"
+	require.Error(t, PinBundleImages(context.Background(), b, fakeDigestResolver{b.BundleImage: bundleDigest}))
+}
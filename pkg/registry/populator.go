@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/blang/semver"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/operator-framework/operator-registry/pkg/image"
 	libsemver "github.com/operator-framework/operator-registry/pkg/lib/semver"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 type Dependencies struct {
@@ -31,10 +33,31 @@ type DirectoryPopulator struct {
 	imageDirMap     map[image.Reference]string
 	overwriteDirMap map[string]map[image.Reference]string
 	overwrite       bool
+	digestResolver  DigestResolver
+	order           []image.Reference
 }
 
-func NewDirectoryPopulator(loader Load, graphLoader GraphLoader, querier Query, imageDirMap map[image.Reference]string, overwriteDirMap map[string]map[image.Reference]string, overwrite bool) *DirectoryPopulator {
-	return &DirectoryPopulator{
+type DirectoryPopulatorOption func(*DirectoryPopulator)
+
+// PinDigests makes the populator pin the images of the bundles it loads to
+// digests resolved by resolver, see PinBundleImages.
+func PinDigests(resolver DigestResolver) DirectoryPopulatorOption {
+	return func(i *DirectoryPopulator) {
+		i.digestResolver = resolver
+	}
+}
+
+// LoadInOrder makes the populator load images in the order of refs, e.g. the
+// order the bundles were requested in. Images missing from refs are loaded
+// after those in refs, ordered by reference.
+func LoadInOrder(refs []image.Reference) DirectoryPopulatorOption {
+	return func(i *DirectoryPopulator) {
+		i.order = refs
+	}
+}
+
+func NewDirectoryPopulator(loader Load, graphLoader GraphLoader, querier Query, imageDirMap map[image.Reference]string, overwriteDirMap map[string]map[image.Reference]string, overwrite bool, opts ...DirectoryPopulatorOption) *DirectoryPopulator {
+	i := &DirectoryPopulator{
 		loader:          loader,
 		graphLoader:     graphLoader,
 		querier:         querier,
@@ -42,13 +65,29 @@ func NewDirectoryPopulator(loader Load, graphLoader GraphLoader, querier Query,
 		overwriteDirMap: overwriteDirMap,
 		overwrite:       overwrite,
 	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
 }
 
+// Populate loads the bundles of the populator into the registry.
 func (i *DirectoryPopulator) Populate(mode Mode) error {
+	return i.PopulateContext(context.Background(), mode)
+}
+
+// PopulateContext is Populate with a context, which traces the load and is
+// used to resolve digests when pinning bundle images.
+func (i *DirectoryPopulator) PopulateContext(ctx context.Context, mode Mode) (err error) {
+	ctx, span := tracing.Start(ctx, "registry.DirectoryPopulator.Populate", tracing.Int("bundles", len(i.imageDirMap)), tracing.Bool("overwrite", i.overwrite))
+	defer func() { span.EndWithError(err) }()
+
+	// Images are loaded in a fixed order, regardless of the order they were
+	// unpacked in, so that the same input always yields the same database.
 	var errs []error
 	imagesToAdd := make([]*ImageInput, 0)
-	for to, from := range i.imageDirMap {
-		imageInput, err := NewImageInput(to, from)
+	for _, to := range i.orderedImageRefs(i.imageDirMap) {
+		imageInput, err := i.newImageInput(ctx, to, i.imageDirMap[to])
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -58,9 +97,9 @@ func (i *DirectoryPopulator) Populate(mode Mode) error {
 	}
 
 	imagesToReAdd := make([]*ImageInput, 0)
-	for pkg := range i.overwriteDirMap {
-		for to, from := range i.overwriteDirMap[pkg] {
-			imageInput, err := NewImageInput(to, from)
+	for _, pkg := range sortedPackages(i.overwriteDirMap) {
+		for _, to := range i.orderedImageRefs(i.overwriteDirMap[pkg]) {
+			imageInput, err := i.newImageInput(ctx, to, i.overwriteDirMap[pkg][to])
 			if err != nil {
 				errs = append(errs, err)
 				continue
@@ -74,7 +113,7 @@ func (i *DirectoryPopulator) Populate(mode Mode) error {
 		return utilerrors.NewAggregate(errs)
 	}
 
-	err := i.loadManifests(imagesToAdd, imagesToReAdd, mode)
+	err = i.loadManifests(ctx, imagesToAdd, imagesToReAdd, mode)
 	if err != nil {
 		return err
 	}
@@ -82,6 +121,60 @@ func (i *DirectoryPopulator) Populate(mode Mode) error {
 	return nil
 }
 
+// newImageInput reads the bundle unpacked from image to in from, pinning its
+// images if the populator pins digests.
+func (i *DirectoryPopulator) newImageInput(ctx context.Context, to image.Reference, from string) (*ImageInput, error) {
+	// A local bundle is recorded by a name whose digest in its registry, if
+	// any, need not match the local image.
+	if i.digestResolver != nil && image.IsLocalReference(to) {
+		return nil, fmt.Errorf("can't pin images of bundle %s: local bundle images can't be pinned to a digest", to)
+	}
+	imageInput, err := NewImageInput(to, from)
+	if err != nil {
+		return nil, err
+	}
+	if i.digestResolver != nil {
+		if err := PinBundleImages(ctx, imageInput.Bundle, i.digestResolver); err != nil {
+			return nil, fmt.Errorf("error pinning images of bundle %s: %v", to, err)
+		}
+	}
+	return imageInput, nil
+}
+
+// orderedImageRefs returns the images in the order set by LoadInOrder,
+// followed by the remaining images ordered by reference.
+func (i *DirectoryPopulator) orderedImageRefs(images map[image.Reference]string) []image.Reference {
+	refs := make([]image.Reference, 0, len(images))
+	seen := make(map[image.Reference]struct{}, len(images))
+	for _, ref := range i.order {
+		if _, ok := images[ref]; !ok {
+			continue
+		}
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		refs = append(refs, ref)
+	}
+	var rest []image.Reference
+	for ref := range images {
+		if _, ok := seen[ref]; !ok {
+			rest = append(rest, ref)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i].String() < rest[j].String() })
+	return append(refs, rest...)
+}
+
+func sortedPackages(overwriteDirMap map[string]map[image.Reference]string) []string {
+	pkgs := make([]string, 0, len(overwriteDirMap))
+	for pkg := range overwriteDirMap {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
 func (i *DirectoryPopulator) globalSanityCheck(imagesToAdd []*ImageInput) error {
 	var errs []error
 	images := make(map[string]struct{})
@@ -150,7 +243,15 @@ func (i *DirectoryPopulator) globalSanityCheck(imagesToAdd []*ImageInput) error
 	return utilerrors.NewAggregate(errs)
 }
 
-func (i *DirectoryPopulator) loadManifests(imagesToAdd []*ImageInput, imagesToReAdd []*ImageInput, mode Mode) error {
+// load returns the loader of the populator, running under ctx if it can.
+func (i *DirectoryPopulator) load(ctx context.Context) Load {
+	if l, ok := i.loader.(ContextLoad); ok {
+		return l.WithContext(ctx)
+	}
+	return i.loader
+}
+
+func (i *DirectoryPopulator) loadManifests(ctx context.Context, imagesToAdd []*ImageInput, imagesToReAdd []*ImageInput, mode Mode) error {
 	// global sanity checks before insertion
 	if err := i.globalSanityCheck(imagesToAdd); err != nil {
 		return err
@@ -158,26 +259,26 @@ func (i *DirectoryPopulator) loadManifests(imagesToAdd []*ImageInput, imagesToRe
 
 	switch mode {
 	case ReplacesMode:
-		for pkg := range i.overwriteDirMap {
+		for _, pkg := range sortedPackages(i.overwriteDirMap) {
 			// TODO: If this succeeds but the add fails there will be a disconnect between
 			// the registry and the index. Loading the bundles in a single transactions as
 			// described above would allow us to do the removable in that same transaction
 			// and ensure that rollback is possible.
-			if err := i.loader.RemovePackage(pkg); err != nil {
+			if err := i.load(ctx).RemovePackage(pkg); err != nil {
 				return err
 			}
 		}
 
-		return i.loadManifestsReplaces(append(imagesToAdd, imagesToReAdd...))
+		return i.loadManifestsReplaces(ctx, append(imagesToAdd, imagesToReAdd...))
 	case SemVerMode:
 		for _, image := range imagesToAdd {
-			if err := i.loadManifestsSemver(image.Bundle, image.AnnotationsFile, false); err != nil {
+			if err := i.loadManifestsSemver(ctx, image.Bundle, image.AnnotationsFile, false); err != nil {
 				return err
 			}
 		}
 	case SkipPatchMode:
 		for _, image := range imagesToAdd {
-			if err := i.loadManifestsSemver(image.Bundle, image.AnnotationsFile, true); err != nil {
+			if err := i.loadManifestsSemver(ctx, image.Bundle, image.AnnotationsFile, true); err != nil {
 				return err
 			}
 		}
@@ -186,7 +287,7 @@ func (i *DirectoryPopulator) loadManifests(imagesToAdd []*ImageInput, imagesToRe
 	}
 
 	// Finally let's delete all the old bundles
-	if err := i.loader.ClearNonHeadBundles(); err != nil {
+	if err := i.load(ctx).ClearNonHeadBundles(); err != nil {
 		return fmt.Errorf("Error deleting previous bundles: %s", err)
 	}
 
@@ -206,24 +307,30 @@ func PackageFromContext(ctx context.Context) (string, bool) {
 	return pkg, ok
 }
 
-func (i *DirectoryPopulator) loadManifestsReplaces(images []*ImageInput) error {
+func (i *DirectoryPopulator) loadManifestsReplaces(ctx context.Context, images []*ImageInput) error {
+	loader := i.load(ctx)
 	packages := map[string][]*Bundle{}
+	var names []string
 	var errs []error
 	for _, img := range images {
 		// Add the bundle directly to the store
-		if err := i.loader.AddOperatorBundle(img.Bundle); err != nil {
+		if err := loader.AddOperatorBundle(img.Bundle); err != nil {
 			errs = append(errs, err)
 			continue
 		}
 
+		if _, ok := packages[img.Bundle.Package]; !ok {
+			names = append(names, img.Bundle.Package)
+		}
 		packages[img.Bundle.Package] = append(packages[img.Bundle.Package], img.Bundle)
 	}
+	sort.Strings(names)
 
 	// Regenerate the upgrade graphs for each package
-	for pkg, bundles := range packages {
+	for _, pkg := range names {
+		bundles := packages[pkg]
 		// Add any existing bundles into the mix
-		ctx := ContextWithPackage(context.TODO(), pkg)
-		existing, err := i.querier.ListRegistryBundles(ctx)
+		existing, err := i.querier.ListRegistryBundles(ContextWithPackage(ctx, pkg))
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -235,7 +342,7 @@ func (i *DirectoryPopulator) loadManifestsReplaces(images []*ImageInput) error {
 			continue
 		}
 
-		if err = i.loader.AddPackageChannels(*packageManifest); err != nil {
+		if err = loader.AddPackageChannels(*packageManifest); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -243,7 +350,7 @@ func (i *DirectoryPopulator) loadManifestsReplaces(images []*ImageInput) error {
 	return utilerrors.NewAggregate(errs)
 }
 
-func (i *DirectoryPopulator) loadManifestsSemver(bundle *Bundle, annotations *AnnotationsFile, skippatch bool) error {
+func (i *DirectoryPopulator) loadManifestsSemver(ctx context.Context, bundle *Bundle, annotations *AnnotationsFile, skippatch bool) error {
 	graph, err := i.graphLoader.Generate(bundle.Package)
 	if err != nil && !errors.Is(err, ErrPackageNotInDatabase) {
 		return err
@@ -256,7 +363,7 @@ func (i *DirectoryPopulator) loadManifestsSemver(bundle *Bundle, annotations *An
 		return err
 	}
 
-	if err := i.loader.AddBundleSemver(updatedGraph, bundle); err != nil {
+	if err := i.load(ctx).AddBundleSemver(updatedGraph, bundle); err != nil {
 		return fmt.Errorf("error loading bundle into db: %s", err)
 	}
 
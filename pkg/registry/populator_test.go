@@ -9,6 +9,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -2412,3 +2414,49 @@ func TestEnableAlpha(t *testing.T) {
 		})
 	}
 }
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestPopulateTracesLoader(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	load, err := sqlite.NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+	graphLoader, err := sqlite.NewSQLGraphLoaderFromDB(db)
+	require.NoError(t, err)
+
+	recorder := &spanRecorder{}
+	tracing.Init("test", logrus.New(), recorder)
+	refMap := map[image.Reference]string{image.SimpleReference("quay.io/test/etcd.0.9.0"): "../../bundles/etcd.0.9.0"}
+	populator := registry.NewDirectoryPopulator(load, graphLoader, sqlite.NewSQLLiteQuerierFromDb(db), refMap, nil, false)
+	require.NoError(t, populator.PopulateContext(context.Background(), registry.ReplacesMode))
+	require.NoError(t, tracing.Shutdown(context.Background(), nil))
+
+	// The SQL work of the loader is part of the populate span.
+	spans := map[string]tracing.SpanData{}
+	for _, s := range recorder.spans {
+		spans[s.Name] = s
+	}
+	populate, ok := spans["registry.DirectoryPopulator.Populate"]
+	require.True(t, ok)
+	for _, name := range []string{"sqlite.AddOperatorBundle", "sqlite.AddPackageChannels"} {
+		span, ok := spans[name]
+		require.True(t, ok, name)
+		require.Equal(t, populate.SpanID, span.ParentSpanID, name)
+	}
+}
@@ -5,20 +5,68 @@ import (
 	"fmt"
 	"sort"
 
+	"google.golang.org/protobuf/proto"
+
 	"github.com/operator-framework/operator-registry/internal/model"
 	"github.com/operator-framework/operator-registry/pkg/api"
 )
 
 type Querier struct {
 	pkgs model.Model
+
+	// apiBundles, if set, are served instead of bundles converted from pkgs.
+	apiBundles     []*api.Bundle
+	apiBundlesByID map[apiBundleID]*api.Bundle
+}
+
+type apiBundleID struct {
+	pkgName, channelName, csvName string
 }
 
 var _ GRPCQuery = &Querier{}
+var _ FilteredBundleLister = &Querier{}
+
+// QuerierOption configures a Querier.
+type QuerierOption func(*Querier)
+
+// WithAPIBundles makes the Querier serve the given bundles instead of
+// converting the bundles of its model on each query. The model must have been
+// converted from these bundles, e.g. by loading both from the same database,
+// so that the Querier returns exactly what the database would.
+func WithAPIBundles(bundles []*api.Bundle) QuerierOption {
+	return func(q *Querier) {
+		q.apiBundles = bundles
+		q.apiBundlesByID = make(map[apiBundleID]*api.Bundle, len(bundles))
+		for _, b := range bundles {
+			q.apiBundlesByID[apiBundleID{b.PackageName, b.ChannelName, b.CsvName}] = b
+		}
+	}
+}
 
-func NewQuerier(packages model.Model) *Querier {
-	return &Querier{
+func NewQuerier(packages model.Model, opts ...QuerierOption) *Querier {
+	q := &Querier{
 		pkgs: packages,
 	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// apiBundle returns the API representation of b, which callers may modify.
+func (q Querier) apiBundle(b model.Bundle) (*api.Bundle, error) {
+	if q.apiBundlesByID != nil {
+		apiBundle, ok := q.apiBundlesByID[apiBundleID{b.Package.Name, b.Channel.Name, b.Name}]
+		if !ok {
+			return nil, fmt.Errorf("bundle %q not found", b.Name)
+		}
+		return proto.Clone(apiBundle).(*api.Bundle), nil
+	}
+	apiBundle, err := api.ConvertModelBundleToAPIBundle(b)
+	if err != nil {
+		return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+	}
+	return apiBundle, nil
 }
 
 func (q Querier) ListPackages(_ context.Context) ([]string, error) {
@@ -32,13 +80,45 @@ func (q Querier) ListPackages(_ context.Context) ([]string, error) {
 func (q Querier) ListBundles(_ context.Context) ([]*api.Bundle, error) {
 	var bundles []*api.Bundle
 
+	if q.apiBundles != nil {
+		for _, b := range q.apiBundles {
+			bundles = append(bundles, proto.Clone(b).(*api.Bundle))
+		}
+		return bundles, nil
+	}
+	for _, pkg := range q.pkgs {
+		for _, ch := range pkg.Channels {
+			for _, b := range ch.Bundles {
+				apiBundle, err := q.apiBundle(*b)
+				if err != nil {
+					return nil, err
+				}
+				bundles = append(bundles, apiBundle)
+			}
+		}
+	}
+	return bundles, nil
+}
+
+func (q Querier) ListFilteredBundles(_ context.Context, filter BundleFilter) ([]*api.Bundle, error) {
+	var bundles []*api.Bundle
 	for _, pkg := range q.pkgs {
+		if filter.PackageName != "" && pkg.Name != filter.PackageName {
+			continue
+		}
 		for _, ch := range pkg.Channels {
+			if filter.ChannelName != "" && ch.Name != filter.ChannelName {
+				continue
+			}
 			for _, b := range ch.Bundles {
-				apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+				apiBundle, err := q.apiBundle(*b)
 				if err != nil {
-					return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+					return nil, err
+				}
+				if !filter.Matches(apiBundle) {
+					continue
 				}
+				filter.Mask(apiBundle)
 				bundles = append(bundles, apiBundle)
 			}
 		}
@@ -49,7 +129,7 @@ func (q Querier) ListBundles(_ context.Context) ([]*api.Bundle, error) {
 func (q Querier) GetPackage(_ context.Context, name string) (*PackageManifest, error) {
 	pkg, ok := q.pkgs[name]
 	if !ok {
-		return nil, fmt.Errorf("package %q not found", name)
+		return nil, PackageNotFoundErr{ErrorString: fmt.Sprintf("package %q not found", name)}
 	}
 
 	var channels []PackageChannel
@@ -83,9 +163,9 @@ func (q Querier) GetBundle(_ context.Context, pkgName, channelName, csvName stri
 	if !ok {
 		return nil, fmt.Errorf("package %q, channel %q, bundle %q not found", pkgName, channelName, csvName)
 	}
-	apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+	apiBundle, err := q.apiBundle(*b)
 	if err != nil {
-		return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+		return nil, err
 	}
 
 	// unset Replaces and Skips (sqlite query does not populate these fields)
@@ -107,9 +187,9 @@ func (q Querier) GetBundleForChannel(_ context.Context, pkgName string, channelN
 	if err != nil {
 		return nil, fmt.Errorf("package %q, channel %q has invalid head: %v", pkgName, channelName, err)
 	}
-	apiBundle, err := api.ConvertModelBundleToAPIBundle(*head)
+	apiBundle, err := q.apiBundle(*head)
 	if err != nil {
-		return nil, fmt.Errorf("convert bundle %q: %v", head.Name, err)
+		return nil, err
 	}
 
 	// unset Replaces and Skips (sqlite query does not populate these fields)
@@ -150,9 +230,9 @@ func (q Querier) GetBundleThatReplaces(_ context.Context, name, pkgName, channel
 	//       implementation to be non-deterministic as well.
 	for _, b := range ch.Bundles {
 		if bundleReplaces(*b, name) {
-			apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+			apiBundle, err := q.apiBundle(*b)
 			if err != nil {
-				return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+				return nil, err
 			}
 
 			// unset Replaces and Skips (sqlite query does not populate these fields)
@@ -320,3 +400,4 @@ func channelEntriesForBundle(b model.Bundle, ignoreChannel bool) []*ChannelEntry
 	}
 	return entries
 }
+
@@ -2,11 +2,15 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
 )
 
 var testModelQuerier = genTestModelQuerier()
@@ -173,6 +177,106 @@ func TestQuerier_ListPackages(t *testing.T) {
 	require.Equal(t, 2, len(packages))
 }
 
+func TestQuerier_WithAPIBundles(t *testing.T) {
+	bundles, err := testModelQuerier.ListBundles(context.TODO())
+	require.NoError(t, err)
+	for _, b := range bundles {
+		b.CsvJson = "served from " + b.CsvName
+	}
+	q := NewQuerier(testModelQuerier.pkgs, WithAPIBundles(bundles))
+
+	listed, err := q.ListBundles(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, listed, len(bundles))
+	for i := range listed {
+		require.Equal(t, bundles[i].CsvName, listed[i].CsvName)
+		require.Equal(t, "served from "+bundles[i].CsvName, listed[i].CsvJson)
+	}
+
+	b, err := q.GetBundleThatReplaces(context.TODO(), "etcdoperator.v0.9.0", "etcd", "singlenamespace-alpha")
+	require.NoError(t, err)
+	require.Equal(t, "served from etcdoperator.v0.9.2", b.CsvJson)
+	require.Empty(t, b.Replaces)
+
+	// Bundles returned by the querier are copies that callers may modify.
+	b.CsvJson = "modified"
+	b, err = q.GetBundle(context.TODO(), "etcd", "singlenamespace-alpha", "etcdoperator.v0.9.2")
+	require.NoError(t, err)
+	require.Equal(t, "served from etcdoperator.v0.9.2", b.CsvJson)
+	listed, err = q.ListBundles(context.TODO())
+	require.NoError(t, err)
+	for _, l := range listed {
+		if l.CsvName == "etcdoperator.v0.9.2" {
+			require.Equal(t, "etcdoperator.v0.9.0", l.Replaces)
+		}
+	}
+}
+
+func TestQuerier_GetPackageGraph(t *testing.T) {
+	graph, err := GetPackageGraph(context.TODO(), testModelQuerier, "etcd")
+	require.NoError(t, err)
+	require.Equal(t, "etcd", graph.Name)
+	require.Equal(t, "singlenamespace-alpha", graph.DefaultChannelName)
+
+	var channels []string
+	for _, ch := range graph.Channels {
+		channels = append(channels, ch.Name)
+	}
+	require.Equal(t, []string{"alpha", "clusterwide-alpha", "singlenamespace-alpha"}, channels)
+
+	ch := graph.Channels[1]
+	require.Equal(t, "etcdoperator.v0.9.4-clusterwide", ch.Head)
+	require.Len(t, ch.Nodes, 3)
+	require.True(t, proto.Equal(&api.GraphNode{
+		CsvName:    "etcdoperator.v0.9.2-clusterwide",
+		Version:    "0.9.2-clusterwide",
+		BundlePath: "quay.io/operatorhubio/etcd:v0.9.2-clusterwide",
+		Replaces:   "etcdoperator.v0.9.0",
+		Skips:      []string{"etcdoperator.v0.6.1", "etcdoperator.v0.9.0"},
+		SkipRange:  ">=0.9.0 <=0.9.1",
+	}, ch.Nodes[1]), "got %v", ch.Nodes[1])
+
+	_, err = GetPackageGraph(context.TODO(), testModelQuerier, "missing")
+	require.True(t, errors.As(err, &PackageNotFoundErr{}), "unexpected error: %v", err)
+}
+
+func TestQuerier_GetDeprecations(t *testing.T) {
+	deprecations, err := GetDeprecations(context.TODO(), testModelQuerier, "")
+	require.NoError(t, err)
+	require.Empty(t, deprecations)
+
+	q := genTestModelQuerier()
+	for _, ch := range q.pkgs["etcd"].Channels {
+		if b, ok := ch.Bundles["etcdoperator.v0.9.0"]; ok {
+			b.Properties = append(b.Properties, property.MustBuildDeprecated())
+		}
+	}
+	expected := []*api.Deprecation{{
+		PackageName: "etcd",
+		CsvName:     "etcdoperator.v0.9.0",
+		Version:     "0.9.0",
+		BundlePath:  "quay.io/operatorhubio/etcd:v0.9.0",
+		Channels:    []string{"clusterwide-alpha", "singlenamespace-alpha"},
+	}}
+	for _, pkgName := range []string{"", "etcd"} {
+		deprecations, err = GetDeprecations(context.TODO(), q, pkgName)
+		require.NoError(t, err)
+		require.Len(t, deprecations, 1)
+		require.True(t, proto.Equal(expected[0], deprecations[0]), "got %v", deprecations[0])
+	}
+	deprecations, err = GetDeprecations(context.TODO(), q, "cockroachdb")
+	require.NoError(t, err)
+	require.Empty(t, deprecations)
+
+	graph, err := GetPackageGraph(context.TODO(), q, "etcd")
+	require.NoError(t, err)
+	for _, ch := range graph.Channels {
+		for _, node := range ch.Nodes {
+			require.Equal(t, node.CsvName == "etcdoperator.v0.9.0", node.Deprecated)
+		}
+	}
+}
+
 func genTestModelQuerier() *Querier {
 	cfg, err := declcfg.LoadDir("testdata/validDeclCfg")
 	if err != nil {
@@ -81,6 +81,7 @@ func registryBundleToModelBundle(b *Bundle) (*model.Bundle, error) {
 		Image:         b.BundleImage,
 		Replaces:      replaces,
 		Skips:         skips,
+		SkipRange:     csv.GetSkipRange(),
 		Properties:    bundleProps,
 		RelatedImages: relatedImages,
 	}, nil
@@ -152,6 +153,11 @@ func convertRegistryBundleToModelProperties(b *Bundle) ([]property.Property, err
 				Type:  property.TypePackageRequired,
 				Value: json.RawMessage(p.Value),
 			})
+		case property.TypeLabel:
+			out = append(out, property.Property{
+				Type:  property.TypeLabelRequired,
+				Value: json.RawMessage(p.Value),
+			})
 		}
 	}
 
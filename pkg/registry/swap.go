@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// SwappableQuerier is a GRPCQuery that delegates to an underlying GRPCQuery
+// which can be atomically replaced while the querier is being served.
+//
+// Each call is answered entirely by the querier that was current when the
+// call started, so streams that are in flight during a swap finish against
+// the snapshot they started with.
+type SwappableQuerier struct {
+	mu         sync.RWMutex
+	store      GRPCQuery
+	generation int64
+}
+
+var _ GRPCQuery = &SwappableQuerier{}
+var _ FilteredBundleLister = &SwappableQuerier{}
+
+func NewSwappableQuerier(store GRPCQuery) *SwappableQuerier {
+	return &SwappableQuerier{store: store, generation: 1}
+}
+
+// Swap replaces the underlying querier and returns the new generation.
+func (q *SwappableQuerier) Swap(store GRPCQuery) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.store = store
+	q.generation++
+	return q.generation
+}
+
+// Generation returns the number of queriers that have been served, starting at 1.
+func (q *SwappableQuerier) Generation() int64 {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.generation
+}
+
+func (q *SwappableQuerier) current() GRPCQuery {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.store
+}
+
+func (q *SwappableQuerier) ListPackages(ctx context.Context) ([]string, error) {
+	return q.current().ListPackages(ctx)
+}
+
+func (q *SwappableQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
+	return q.current().ListBundles(ctx)
+}
+
+func (q *SwappableQuerier) ListFilteredBundles(ctx context.Context, filter BundleFilter) ([]*api.Bundle, error) {
+	return ListFilteredBundles(ctx, q.current(), filter)
+}
+
+func (q *SwappableQuerier) GetPackage(ctx context.Context, name string) (*PackageManifest, error) {
+	return q.current().GetPackage(ctx, name)
+}
+
+func (q *SwappableQuerier) GetBundle(ctx context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	return q.current().GetBundle(ctx, pkgName, channelName, csvName)
+}
+
+func (q *SwappableQuerier) GetBundleForChannel(ctx context.Context, pkgName string, channelName string) (*api.Bundle, error) {
+	return q.current().GetBundleForChannel(ctx, pkgName, channelName)
+}
+
+func (q *SwappableQuerier) GetChannelEntriesThatReplace(ctx context.Context, name string) ([]*ChannelEntry, error) {
+	return q.current().GetChannelEntriesThatReplace(ctx, name)
+}
+
+func (q *SwappableQuerier) GetBundleThatReplaces(ctx context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
+	return q.current().GetBundleThatReplaces(ctx, name, pkgName, channelName)
+}
+
+func (q *SwappableQuerier) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*ChannelEntry, error) {
+	return q.current().GetChannelEntriesThatProvide(ctx, group, version, kind)
+}
+
+func (q *SwappableQuerier) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*ChannelEntry, error) {
+	return q.current().GetLatestChannelEntriesThatProvide(ctx, group, version, kind)
+}
+
+func (q *SwappableQuerier) GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
+	return q.current().GetBundleThatProvides(ctx, group, version, kind)
+}
@@ -22,6 +22,15 @@ var (
 	ErrRemovingDefaultChannelDuringDeprecation = errors.New("Bundle deprecation causing default channel removal")
 )
 
+// PackageNotFoundErr is an error that describes a package that is not in the index
+type PackageNotFoundErr struct {
+	ErrorString string
+}
+
+func (e PackageNotFoundErr) Error() string {
+	return e.ErrorString
+}
+
 // BundleImageAlreadyAddedErr is an error that describes a bundle is already added
 type BundleImageAlreadyAddedErr struct {
 	ErrorString string
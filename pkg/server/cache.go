@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/encoding"
+	encodingproto "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func init() {
+	// Importing the gRPC proto codec registers it first, so it is replaced
+	// here by a codec that wraps it.
+	encoding.RegisterCodec(encodedMessageCodec{Codec: encoding.GetCodec(encodingproto.Name)})
+}
+
+// encodedMessage is a protobuf message that has already been serialized.
+// It is sent as it is by encodedMessageCodec, so it can be sent on any
+// number of streams without encoding the message again.
+type encodedMessage []byte
+
+// encodedMessageCodec is the gRPC proto codec, extended to send
+// encodedMessages without encoding them.
+type encodedMessageCodec struct {
+	encoding.Codec
+}
+
+func (c encodedMessageCodec) Marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(encodedMessage); ok {
+		return m, nil
+	}
+	return c.Codec.Marshal(v)
+}
+
+// bundleCache holds the serialized response of ListBundles.
+//
+// The response is computed once per generation of the store (see
+// generational); stores that do not report a generation are assumed to
+// never change and are only read once.
+type bundleCache struct {
+	store registry.GRPCQuery
+
+	mu         sync.Mutex
+	bundles    []encodedMessage
+	generation int64
+	valid      bool
+}
+
+func newBundleCache(store registry.GRPCQuery) *bundleCache {
+	return &bundleCache{store: store}
+}
+
+// list returns the serialized bundles of the store, reading and encoding
+// them if the store has changed since they were last read. Concurrent
+// callers wait for a single read of the store.
+func (c *bundleCache) list(ctx context.Context) ([]encodedMessage, error) {
+	var generation int64
+	if g, ok := c.store.(generational); ok {
+		generation = g.Generation()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.valid && c.generation == generation {
+		return c.bundles, nil
+	}
+
+	// The generation is read before the bundles, so if the store changes
+	// while they are read the stale bundles are replaced by the next call.
+	bundles, err := c.store.ListBundles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([]encodedMessage, 0, len(bundles))
+	for _, b := range bundles {
+		data, err := proto.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, data)
+	}
+	c.bundles, c.generation, c.valid = encoded, generation, true
+	return c.bundles, nil
+}
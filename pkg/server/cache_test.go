@@ -0,0 +1,243 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	encodingproto "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// fixtureQuerier returns a querier for a catalog with the given number of
+// packages, each with a channel of the given number of bundles whose CSVs
+// are roughly csvSize bytes.
+func fixtureQuerier(t testing.TB, packages, bundles, csvSize int) *registry.Querier {
+	var cfg declcfg.DeclarativeConfig
+	for p := 0; p < packages; p++ {
+		pkg := fmt.Sprintf("package-%d", p)
+		cfg.Packages = append(cfg.Packages, declcfg.Package{Schema: "olm.package", Name: pkg, DefaultChannel: "stable"})
+		replaces := ""
+		for b := 0; b < bundles; b++ {
+			name := fmt.Sprintf("%s.v%d.0.0", pkg, b)
+			csv := fmt.Sprintf(`{"kind":"ClusterServiceVersion","apiVersion":"operators.coreos.com/v1alpha1","metadata":{"name":%q},"spec":{"description":%q}}`,
+				name, strings.Repeat("x", csvSize))
+			cfg.Bundles = append(cfg.Bundles, declcfg.Bundle{
+				Schema:  "olm.bundle",
+				Name:    name,
+				Package: pkg,
+				Image:   fmt.Sprintf("quay.io/operators/%s:v%d.0.0", pkg, b),
+				Properties: []property.Property{
+					property.MustBuildPackage(pkg, fmt.Sprintf("%d.0.0", b)),
+					property.MustBuildChannel("stable", replaces),
+					property.MustBuildGVK("example.com", "v1", fmt.Sprintf("Kind%d", p)),
+					property.MustBuildBundleObjectData([]byte(csv)),
+				},
+				CsvJSON: csv,
+				Objects: []string{csv},
+			})
+			replaces = name
+		}
+	}
+	m, err := declcfg.ConvertToModel(cfg)
+	require.NoError(t, err)
+	return registry.NewQuerier(m)
+}
+
+// failingQuerier fails to list bundles while err is set.
+type failingQuerier struct {
+	*registry.SwappableQuerier
+	err error
+}
+
+func (q *failingQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.SwappableQuerier.ListBundles(ctx)
+}
+
+func (q *failingQuerier) ListFilteredBundles(ctx context.Context, filter registry.BundleFilter) ([]*api.Bundle, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.SwappableQuerier.ListFilteredBundles(ctx, filter)
+}
+
+func decodeBundles(t *testing.T, encoded []encodedMessage) []*api.Bundle {
+	var out []*api.Bundle
+	for _, data := range encoded {
+		b := &api.Bundle{}
+		require.NoError(t, proto.Unmarshal(data, b))
+		out = append(out, b)
+	}
+	return out
+}
+
+// requireBundlesEqual compares bundles regardless of order, since queriers
+// may list them in any order.
+func requireBundlesEqual(t *testing.T, expected, actual []*api.Bundle) {
+	require.Equal(t, len(expected), len(actual))
+	sort.Slice(expected, func(i, j int) bool { return bundleLess(expected[i], expected[j]) })
+	sort.Slice(actual, func(i, j int) bool { return bundleLess(actual[i], actual[j]) })
+	for i := range expected {
+		require.True(t, proto.Equal(expected[i], actual[i]), "bundle %d differs: expected %v, got %v", i, expected[i], actual[i])
+	}
+}
+
+func TestBundleCache(t *testing.T) {
+	ctx := context.TODO()
+	first, second := fixtureQuerier(t, 2, 2, 16), fixtureQuerier(t, 3, 1, 16)
+	store := &failingQuerier{SwappableQuerier: registry.NewSwappableQuerier(first)}
+	c := newBundleCache(store)
+
+	expected, err := first.ListBundles(ctx)
+	require.NoError(t, err)
+	encoded, err := c.list(ctx)
+	require.NoError(t, err)
+	requireBundlesEqual(t, expected, decodeBundles(t, encoded))
+
+	// The store is not read again while its generation is unchanged.
+	store.err = errors.New("unexpected read")
+	cached, err := c.list(ctx)
+	require.NoError(t, err)
+	require.Equal(t, encoded, cached)
+
+	// Errors are returned and not cached.
+	store.Swap(second)
+	_, err = c.list(ctx)
+	require.EqualError(t, err, "unexpected read")
+
+	store.err = nil
+	expected, err = second.ListBundles(ctx)
+	require.NoError(t, err)
+	encoded, err = c.list(ctx)
+	require.NoError(t, err)
+	requireBundlesEqual(t, expected, decodeBundles(t, encoded))
+}
+
+func TestListBundlesCachedStream(t *testing.T) {
+	store := fixtureQuerier(t, 3, 2, 16)
+	expected, err := store.ListBundles(context.TODO())
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	gs := grpc.NewServer()
+	api.RegisterRegistryServer(gs, NewRegistryServer(store, WithListBundlesCache(true)))
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	c := api.NewRegistryClient(conn)
+
+	// The cached bundles are decoded by a client the same way on every call.
+	for i := 0; i < 2; i++ {
+		stream, err := c.ListBundles(context.TODO(), &api.ListBundlesRequest{}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+		var actual []*api.Bundle
+		for {
+			b, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			actual = append(actual, b)
+		}
+		requireBundlesEqual(t, expected, actual)
+	}
+}
+
+// benchmarkStream is a ListBundles stream that encodes messages the way the
+// gRPC proto codec does and discards them.
+type benchmarkStream struct {
+	grpc.ServerStream
+	codec encoding.Codec
+	bytes int64
+}
+
+func (s *benchmarkStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *benchmarkStream) Send(b *api.Bundle) error {
+	return s.SendMsg(b)
+}
+
+func (s *benchmarkStream) SendMsg(m interface{}) error {
+	data, err := s.codec.Marshal(m)
+	s.bytes += int64(len(data))
+	return err
+}
+
+// BenchmarkListBundles measures the cost of answering ListBundles for a large
+// catalog with and without the response cache, both in the server alone and
+// end to end over a gRPC connection.
+func BenchmarkListBundles(b *testing.B) {
+	store := fixtureQuerier(b, 100, 20, 16*1024)
+
+	for _, cached := range []bool{false, true} {
+		name := "Uncached"
+		if cached {
+			name = "Cached"
+		}
+		s := NewRegistryServer(store, WithListBundlesCache(cached))
+
+		b.Run("Server/"+name, func(b *testing.B) {
+			stream := &benchmarkStream{codec: encoding.GetCodec(encodingproto.Name)}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := s.ListBundles(&api.ListBundlesRequest{}, stream); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.SetBytes(stream.bytes / int64(b.N))
+		})
+
+		b.Run("GRPC/"+name, func(b *testing.B) {
+			lis, err := net.Listen("tcp", "localhost:0")
+			require.NoError(b, err)
+			gs := grpc.NewServer()
+			api.RegisterRegistryServer(gs, s)
+			go gs.Serve(lis)
+			defer gs.Stop()
+
+			conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(64*1024*1024)))
+			require.NoError(b, err)
+			defer conn.Close()
+			c := api.NewRegistryClient(conn)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				stream, err := c.ListBundles(context.Background(), &api.ListBundlesRequest{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for {
+					if _, err := stream.Recv(); err == io.EOF {
+						break
+					} else if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
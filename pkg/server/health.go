@@ -2,20 +2,68 @@ package server
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
 )
 
+const (
+	// ReloadGenerationHeader is the response header in which the health
+	// server reports the generation of the catalog being served.
+	ReloadGenerationHeader = "reload-generation"
+	// ReloadTimeHeader is the response header in which the health server
+	// reports the time of the last successful catalog reload.
+	ReloadTimeHeader = "reload-time"
+	// ReloadErrorHeader is the response header in which the health server
+	// reports the error from the last failed catalog reload.
+	ReloadErrorHeader = "reload-error"
+)
+
 type HealthServer struct {
 	health.UnimplementedHealthServer
+	reload ReloadStatusReporter
 }
 
 var _ health.HealthServer = &HealthServer{}
 
-func NewHealthServer() *HealthServer {
-	return &HealthServer{health.UnimplementedHealthServer{}}
+type HealthServerOption func(*HealthServer)
+
+// WithReloadStatus configures the health server to report the reload
+// status of the catalog in the response headers of each check.
+func WithReloadStatus(r ReloadStatusReporter) HealthServerOption {
+	return func(s *HealthServer) {
+		s.reload = r
+	}
+}
+
+func NewHealthServer(opts ...HealthServerOption) *HealthServer {
+	s := &HealthServer{UnimplementedHealthServer: health.UnimplementedHealthServer{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *HealthServer) Check(ctx context.Context, req *health.HealthCheckRequest) (*health.HealthCheckResponse, error) {
+	if s.reload != nil {
+		status := s.reload.Status()
+		md := metadata.Pairs(
+			ReloadGenerationHeader, strconv.FormatInt(status.Generation, 10),
+			ReloadTimeHeader, status.LastReload.UTC().Format(time.RFC3339),
+		)
+		if status.LastError != nil {
+			// Header values cannot contain newlines, which multi-errors do.
+			md.Append(ReloadErrorHeader, strings.ReplaceAll(status.LastError.Error(), "\n", " "))
+		}
+		// Failing to set the header is not a reason to fail the check.
+		_ = grpc.SetHeader(ctx, md)
+	}
+	// A failed reload leaves the previous catalog in place, so the server
+	// is still serving.
 	return &health.HealthCheckResponse{Status: health.HealthCheckResponse_SERVING}, nil
 }
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+)
+
+// headerStream records the headers set by a handler.
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/grpc.health.v1.Health/Check" }
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+type staticReloadStatus ReloadStatus
+
+func (s staticReloadStatus) Status() ReloadStatus { return ReloadStatus(s) }
+
+func TestHealthServerCheck(t *testing.T) {
+	reloaded := time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("test", 3600))
+	tests := []struct {
+		name   string
+		opts   []HealthServerOption
+		header metadata.MD
+	}{
+		{
+			name: "NoReloadStatus",
+		},
+		{
+			name: "Reloaded",
+			opts: []HealthServerOption{WithReloadStatus(staticReloadStatus{Generation: 3, LastReload: reloaded})},
+			header: metadata.Pairs(
+				ReloadGenerationHeader, "3",
+				ReloadTimeHeader, "2021-03-04T04:06:07Z",
+			),
+		},
+		{
+			name: "ReloadFailed",
+			opts: []HealthServerOption{WithReloadStatus(staticReloadStatus{Generation: 2, LastReload: reloaded, LastError: errors.New("invalid\nconfig")})},
+			header: metadata.Pairs(
+				ReloadGenerationHeader, "2",
+				ReloadTimeHeader, "2021-03-04T04:06:07Z",
+				ReloadErrorHeader, "invalid config",
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &headerStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			resp, err := NewHealthServer(tt.opts...).Check(ctx, &health.HealthCheckRequest{})
+			require.NoError(t, err)
+			// A failed reload still serves the previous catalog.
+			require.Equal(t, health.HealthCheckResponse_SERVING, resp.Status)
+			require.Equal(t, tt.header, stream.header)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+const metricsNamespace = "operator_registry"
+
+// Metrics collects Prometheus metrics about the requests served by a registry
+// server and the catalog it serves.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	catalog  *catalogCollector
+}
+
+type MetricsOption func(*Metrics)
+
+// WithLoadDuration reports how long the catalog took to load.
+func WithLoadDuration(d time.Duration) MetricsOption {
+	return func(m *Metrics) {
+		m.catalog.loadDuration = func() time.Duration { return d }
+	}
+}
+
+// WithMetricsReloadStatus reports the load duration of the catalog from a
+// reloading querier, so that the duration of the latest reload is exported.
+func WithMetricsReloadStatus(r ReloadStatusReporter) MetricsOption {
+	return func(m *Metrics) {
+		m.catalog.loadDuration = func() time.Duration { return r.Status().LoadDuration }
+	}
+}
+
+// WithMigrationVersion reports the migration version of the database the
+// catalog was loaded from, as returned by sqlite.Migrator.Version.
+func WithMigrationVersion(version int) MetricsOption {
+	return func(m *Metrics) {
+		m.catalog.migrationVersion = &version
+	}
+}
+
+// NewMetrics returns metrics for a server of the catalog in store. Catalog
+// gauges are computed when metrics are scraped, and recomputed only when the
+// generation of the store changes.
+func NewMetrics(store registry.GRPCQuery, logger logrus.FieldLogger, opts ...MetricsOption) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "Number of gRPC requests handled, by method and status code.",
+		}, []string{"method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to handle gRPC requests, by method. Streaming requests are timed until the stream ends.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 10),
+		}, []string{"method"}),
+		catalog: newCatalogCollector(store, logger),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.latency,
+		m.catalog,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// ServerOptions returns the gRPC server options that record the requests
+// handled by the server.
+func (m *Metrics) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(m.unaryInterceptor),
+		grpc.ChainStreamInterceptor(m.streamInterceptor),
+	}
+}
+
+func (m *Metrics) observe(method string, start time.Time, err error) {
+	m.requests.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func (m *Metrics) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	m.observe(info.FullMethod, start, err)
+	return res, err
+}
+
+func (m *Metrics) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.observe(info.FullMethod, start, err)
+	return err
+}
+
+// Handler returns an HTTP handler that serves the metrics in the Prometheus
+// exposition format. Metrics that can't be collected, such as the catalog
+// gauges of a store that fails to list its contents, are left out rather
+// than failing the whole scrape.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// Serve serves the metrics at /metrics on address until ctx is done. It
+// returns once the address is being listened on.
+func (m *Metrics) Serve(ctx context.Context, address string, logger logrus.FieldLogger) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	s := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	go func() {
+		if err := s.Serve(lis); err != nil && err != http.ErrServerClosed {
+			logger.WithError(err).Warn("metrics server stopped")
+		}
+	}()
+	return nil
+}
+
+// catalogCounts are the sizes of a catalog.
+type catalogCounts struct {
+	packages, channels, bundles int
+}
+
+// catalogCollector exports gauges describing the catalog in a store.
+type catalogCollector struct {
+	store            registry.GRPCQuery
+	logger           logrus.FieldLogger
+	loadDuration     func() time.Duration
+	migrationVersion *int
+
+	packagesDesc, channelsDesc, bundlesDesc *prometheus.Desc
+	loadDurationDesc, migrationVersionDesc  *prometheus.Desc
+
+	mu         sync.Mutex
+	counts     *catalogCounts
+	generation int64
+}
+
+func newCatalogCollector(store registry.GRPCQuery, logger logrus.FieldLogger) *catalogCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "catalog", name), help, nil, nil)
+	}
+	return &catalogCollector{
+		store:                store,
+		logger:               logger,
+		packagesDesc:         desc("packages", "Number of packages in the catalog."),
+		channelsDesc:         desc("channels", "Number of channels in the catalog, across all packages."),
+		bundlesDesc:          desc("bundles", "Number of bundles in the catalog, counting bundles in several channels once."),
+		loadDurationDesc:     desc("load_duration_seconds", "Time taken by the latest successful load of the catalog."),
+		migrationVersionDesc: desc("migration_version", "Migration version of the database the catalog was loaded from."),
+	}
+}
+
+func (c *catalogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.packagesDesc
+	ch <- c.channelsDesc
+	ch <- c.bundlesDesc
+	if c.loadDuration != nil {
+		ch <- c.loadDurationDesc
+	}
+	if c.migrationVersion != nil {
+		ch <- c.migrationVersionDesc
+	}
+}
+
+func (c *catalogCollector) Collect(ch chan<- prometheus.Metric) {
+	if counts, err := c.count(context.Background()); err != nil {
+		c.logger.WithError(err).Warn("unable to count catalog contents for metrics")
+		ch <- prometheus.NewInvalidMetric(c.packagesDesc, err)
+		ch <- prometheus.NewInvalidMetric(c.channelsDesc, err)
+		ch <- prometheus.NewInvalidMetric(c.bundlesDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.packagesDesc, prometheus.GaugeValue, float64(counts.packages))
+		ch <- prometheus.MustNewConstMetric(c.channelsDesc, prometheus.GaugeValue, float64(counts.channels))
+		ch <- prometheus.MustNewConstMetric(c.bundlesDesc, prometheus.GaugeValue, float64(counts.bundles))
+	}
+	if c.loadDuration != nil {
+		ch <- prometheus.MustNewConstMetric(c.loadDurationDesc, prometheus.GaugeValue, c.loadDuration().Seconds())
+	}
+	if c.migrationVersion != nil {
+		ch <- prometheus.MustNewConstMetric(c.migrationVersionDesc, prometheus.GaugeValue, float64(*c.migrationVersion))
+	}
+}
+
+// count returns the sizes of the catalog, counting its contents again only if
+// the generation of the store has changed since they were last counted.
+func (c *catalogCollector) count(ctx context.Context) (*catalogCounts, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var generation int64
+	if g, ok := c.store.(generational); ok {
+		generation = g.Generation()
+	}
+	if c.counts != nil && generation == c.generation {
+		return c.counts, nil
+	}
+
+	names, err := c.store.ListPackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := &catalogCounts{packages: len(names)}
+	for _, name := range names {
+		pkg, err := c.store.GetPackage(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		counts.channels += len(pkg.Channels)
+	}
+	bundles, err := registry.ListFilteredBundles(ctx, c.store, registry.BundleFilter{FieldMask: []string{"packageName", "csvName"}})
+	if err != nil {
+		return nil, err
+	}
+	type key struct{ pkg, name string }
+	seen := map[key]struct{}{}
+	for _, b := range bundles {
+		seen[key{b.PackageName, b.CsvName}] = struct{}{}
+	}
+	counts.bundles = len(seen)
+
+	c.counts = counts
+	c.generation = generation
+	return counts, nil
+}
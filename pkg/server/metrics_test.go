@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// gatherMetrics returns the value of each gauge and counter gathered by m,
+// keyed by metric name and label values.
+func gatherMetrics(t *testing.T, m *Metrics) map[string]float64 {
+	families, err := m.registry.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			key := f.GetName()
+			for _, l := range metric.GetLabel() {
+				key += "," + l.GetValue()
+			}
+			switch {
+			case metric.GetGauge() != nil:
+				values[key] = metric.GetGauge().GetValue()
+			case metric.GetCounter() != nil:
+				values[key] = metric.GetCounter().GetValue()
+			case metric.GetHistogram() != nil:
+				values[key] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return values
+}
+
+func TestMetricsCatalog(t *testing.T) {
+	store := registry.NewSwappableQuerier(fixtureQuerier(t, 2, 3, 0))
+	m := NewMetrics(store, logrus.New(), WithLoadDuration(1500*time.Millisecond), WithMigrationVersion(12))
+
+	values := gatherMetrics(t, m)
+	require.Equal(t, 2.0, values["operator_registry_catalog_packages"])
+	require.Equal(t, 2.0, values["operator_registry_catalog_channels"])
+	require.Equal(t, 6.0, values["operator_registry_catalog_bundles"])
+	require.Equal(t, 1.5, values["operator_registry_catalog_load_duration_seconds"])
+	require.Equal(t, 12.0, values["operator_registry_catalog_migration_version"])
+
+	// Catalog gauges follow the contents of the store.
+	store.Swap(fixtureQuerier(t, 3, 1, 0))
+	values = gatherMetrics(t, m)
+	require.Equal(t, 3.0, values["operator_registry_catalog_packages"])
+	require.Equal(t, 3.0, values["operator_registry_catalog_bundles"])
+
+	// Gauges that aren't configured aren't exported.
+	values = gatherMetrics(t, NewMetrics(store, logrus.New()))
+	require.NotContains(t, values, "operator_registry_catalog_load_duration_seconds")
+	require.NotContains(t, values, "operator_registry_catalog_migration_version")
+}
+
+func TestMetricsReloadStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeReloadConfig(t, dir, "foo.v0.1.0")
+	reloader, err := NewConfigReloader(dir, logrus.New())
+	require.NoError(t, err)
+
+	m := NewMetrics(reloader.Querier(), logrus.New(), WithMetricsReloadStatus(reloader))
+	values := gatherMetrics(t, m)
+	require.Equal(t, reloader.Status().LoadDuration.Seconds(), values["operator_registry_catalog_load_duration_seconds"])
+	require.Equal(t, 1.0, values["operator_registry_catalog_bundles"])
+}
+
+func TestMetricsInterceptors(t *testing.T) {
+	m := NewMetrics(fixtureQuerier(t, 1, 1, 0), logrus.New())
+
+	unary := &grpc.UnaryServerInfo{FullMethod: "/api.Registry/GetPackage"}
+	_, err := m.unaryInterceptor(context.TODO(), nil, unary, func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	_, err = m.unaryInterceptor(context.TODO(), nil, unary, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "not found")
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	stream := &grpc.StreamServerInfo{FullMethod: "/api.Registry/ListBundles", IsServerStream: true}
+	require.NoError(t, m.streamInterceptor(nil, nil, stream, func(interface{}, grpc.ServerStream) error {
+		return nil
+	}))
+
+	values := gatherMetrics(t, m)
+	require.Equal(t, 1.0, values["operator_registry_grpc_requests_total,OK,/api.Registry/GetPackage"])
+	require.Equal(t, 1.0, values["operator_registry_grpc_requests_total,NotFound,/api.Registry/GetPackage"])
+	require.Equal(t, 1.0, values["operator_registry_grpc_requests_total,OK,/api.Registry/ListBundles"])
+	require.Equal(t, 2.0, values["operator_registry_grpc_request_duration_seconds,/api.Registry/GetPackage"])
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, rec.Code)
+	require.True(t, strings.Contains(rec.Body.String(), `operator_registry_grpc_requests_total{code="NotFound",method="/api.Registry/GetPackage"} 1`))
+}
+
+func TestMetricsHandlerWithFailingStore(t *testing.T) {
+	m := NewMetrics(registry.NewEmptyQuerier(), logrus.New(), WithMigrationVersion(12))
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, rec.Code)
+	require.True(t, strings.Contains(rec.Body.String(), "operator_registry_catalog_migration_version 12"))
+	require.False(t, strings.Contains(rec.Body.String(), "operator_registry_catalog_packages"))
+}
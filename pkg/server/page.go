@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+const (
+	// defaultPageSize is the number of bundles in a page if the client does
+	// not choose one.
+	defaultPageSize = 100
+
+	// maxPageSize limits the number of bundles in a page to keep responses
+	// under the default maximum gRPC message size.
+	maxPageSize = 500
+
+	// maxCachedPageFilters limits the number of filters whose bundles are
+	// kept by a pageCache.
+	maxCachedPageFilters = 16
+)
+
+// pageToken identifies the last bundle of a page. Pages are ordered by
+// bundleLess, so the next page starts with the first bundle after it even if
+// the catalog changes between pages.
+type pageToken struct {
+	Package string `json:"p"`
+	Channel string `json:"c"`
+	Name    string `json:"n"`
+}
+
+func decodePageToken(token string) (*pageToken, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token: %v", err)
+	}
+	var t pageToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid page token: %v", err)
+	}
+	return &t, nil
+}
+
+func (t pageToken) encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// pageFilter returns a copy of filter whose field mask keeps the fields
+// needed to order bundles into pages.
+func pageFilter(filter registry.BundleFilter) registry.BundleFilter {
+	if len(filter.FieldMask) == 0 {
+		return filter
+	}
+	mask := append([]string{"packageName", "channelName", "csvName"}, filter.FieldMask...)
+	filter.FieldMask = mask
+	return filter
+}
+
+// pageCache holds the sorted bundles that pages are cut from, for the
+// filters that were last paged through, so that the store is read and the
+// bundles are sorted once per filter instead of once per page.
+//
+// Like bundleCache, the bundles are read once per generation of the store,
+// and stores that do not report a generation are assumed to never change.
+type pageCache struct {
+	store registry.GRPCQuery
+
+	mu         sync.Mutex
+	bundles    map[string][]*api.Bundle
+	generation int64
+}
+
+func newPageCache(store registry.GRPCQuery) *pageCache {
+	return &pageCache{store: store}
+}
+
+// list returns the bundles of the store that match filter, sorted by
+// bundleLess. The bundles are shared, and must not be modified.
+func (c *pageCache) list(ctx context.Context, filter registry.BundleFilter) ([]*api.Bundle, error) {
+	var generation int64
+	if g, ok := c.store.(generational); ok {
+		generation = g.Generation()
+	}
+	key, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.bundles == nil || c.generation != generation || len(c.bundles) >= maxCachedPageFilters {
+		c.bundles, c.generation = map[string][]*api.Bundle{}, generation
+	}
+	if bundles, ok := c.bundles[string(key)]; ok {
+		return bundles, nil
+	}
+
+	bundles, err := listPageBundles(ctx, c.store, filter)
+	if err != nil {
+		return nil, err
+	}
+	c.bundles[string(key)] = bundles
+	return bundles, nil
+}
+
+// listPageBundles returns the bundles of store that match filter, sorted by
+// bundleLess.
+func listPageBundles(ctx context.Context, store registry.GRPCQuery, filter registry.BundleFilter) ([]*api.Bundle, error) {
+	bundles, err := registry.ListFilteredBundles(ctx, store, filter)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundleLess(bundles[i], bundles[j])
+	})
+	return bundles, nil
+}
+
+// newBundlePage returns the bundles that follow the bundle identified by
+// after, masked by filter. The bundles must be sorted by bundleLess, and are
+// not modified.
+func newBundlePage(bundles []*api.Bundle, filter registry.BundleFilter, after *pageToken, size int) *api.ListBundlesPageResponse {
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+	start := 0
+	if after != nil {
+		last := &api.Bundle{PackageName: after.Package, ChannelName: after.Channel, CsvName: after.Name}
+		start = sort.Search(len(bundles), func(i int) bool {
+			return bundleLess(last, bundles[i])
+		})
+	}
+
+	res := &api.ListBundlesPageResponse{}
+	end := start + size
+	if end < len(bundles) {
+		last := bundles[end-1]
+		res.NextPageToken = pageToken{Package: last.PackageName, Channel: last.ChannelName, Name: last.CsvName}.encode()
+	} else {
+		end = len(bundles)
+	}
+	for _, b := range bundles[start:end] {
+		if len(filter.FieldMask) > 0 {
+			b = proto.Clone(b).(*api.Bundle)
+			filter.Mask(b)
+		}
+		res.Bundles = append(res.Bundles, b)
+	}
+	return res
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestPageCache(t *testing.T) {
+	ctx := context.TODO()
+	first, second := fixtureQuerier(t, 2, 2, 16), fixtureQuerier(t, 3, 1, 16)
+	store := &failingQuerier{SwappableQuerier: registry.NewSwappableQuerier(first)}
+	c := newPageCache(store)
+
+	filter := pageFilter(registry.BundleFilter{FieldMask: []string{"version"}})
+	bundles, err := c.list(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, bundles, 4)
+	for i := 1; i < len(bundles); i++ {
+		require.True(t, bundleLess(bundles[i-1], bundles[i]), "bundles are not sorted")
+	}
+
+	// Masking a page leaves the cached bundles as they are.
+	page := newBundlePage(bundles, registry.BundleFilter{FieldMask: []string{"version"}}, nil, 2)
+	require.Len(t, page.Bundles, 2)
+	require.Empty(t, page.Bundles[0].CsvName)
+	require.NotEmpty(t, bundles[0].CsvName)
+
+	// The store is not read again while its generation is unchanged.
+	store.err = errors.New("unexpected read")
+	cached, err := c.list(ctx, filter)
+	require.NoError(t, err)
+	require.Equal(t, bundles, cached)
+
+	// A swapped store is read again, and errors are not cached.
+	store.Swap(second)
+	_, err = c.list(ctx, filter)
+	require.EqualError(t, err, "unexpected read")
+	store.err = nil
+	bundles, err = c.list(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, bundles, 3)
+}
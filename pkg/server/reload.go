@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// ReloadStatus describes the state of a ConfigReloader.
+type ReloadStatus struct {
+	// Generation is the generation of the querier currently being served.
+	Generation int64
+	// LastReload is the time of the last successful load.
+	LastReload time.Time
+	// LoadDuration is how long the last successful load took.
+	LoadDuration time.Duration
+	// LastError is the error from the most recent reload attempt, if it failed.
+	LastError error
+}
+
+// ReloadStatusReporter reports the status of a reloading querier.
+type ReloadStatusReporter interface {
+	Status() ReloadStatus
+}
+
+// ConfigReloader serves a declarative config directory and rebuilds its
+// querier whenever the contents of the directory change. A new querier is
+// only swapped in once the updated configs have been successfully converted
+// to a valid model; otherwise the previous querier keeps being served.
+type ConfigReloader struct {
+	configDir string
+	store     *registry.SwappableQuerier
+	logger    logrus.FieldLogger
+
+	// reloadMu serializes reloads, and guards fingerprint. It is held while
+	// configs are loaded, so status is guarded separately by mu, which is
+	// only held briefly and never blocks health checks for a whole load.
+	reloadMu    sync.Mutex
+	fingerprint string
+
+	mu           sync.RWMutex
+	lastReload   time.Time
+	loadDuration time.Duration
+	lastErr      error
+}
+
+var _ ReloadStatusReporter = &ConfigReloader{}
+
+// NewConfigReloader loads the declarative configs in configDir and returns a
+// reloader serving them. It fails if the initial load fails.
+func NewConfigReloader(configDir string, logger logrus.FieldLogger) (*ConfigReloader, error) {
+	fingerprint, err := fingerprintDir(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("read declarative config directory: %v", err)
+	}
+	start := time.Now()
+	store, err := loadQuerier(configDir)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigReloader{
+		configDir:    configDir,
+		store:        registry.NewSwappableQuerier(store),
+		logger:       logger,
+		fingerprint:  fingerprint,
+		lastReload:   time.Now(),
+		loadDuration: time.Since(start),
+	}, nil
+}
+
+// Querier returns the querier that should be served. It always answers
+// from the latest successfully loaded configs.
+func (r *ConfigReloader) Querier() *registry.SwappableQuerier {
+	return r.store
+}
+
+// Status returns the current reload status.
+func (r *ConfigReloader) Status() ReloadStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return ReloadStatus{
+		Generation:   r.store.Generation(),
+		LastReload:   r.lastReload,
+		LoadDuration: r.loadDuration,
+		LastError:    r.lastErr,
+	}
+}
+
+// Reload rebuilds the querier if the contents of the config directory have
+// changed since the last attempt. It returns true if a new querier was swapped in.
+func (r *ConfigReloader) Reload() (bool, error) {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	fingerprint, err := fingerprintDir(r.configDir)
+	if err != nil {
+		return false, r.setError(fmt.Errorf("read declarative config directory: %v", err))
+	}
+	if fingerprint == r.fingerprint {
+		return false, nil
+	}
+	// Record the fingerprint even if loading fails so that an invalid
+	// config is not repeatedly reloaded until it changes again.
+	r.fingerprint = fingerprint
+
+	start := time.Now()
+	store, err := loadQuerier(r.configDir)
+	if err != nil {
+		return false, r.setError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store.Swap(store)
+	r.lastReload = time.Now()
+	r.loadDuration = r.lastReload.Sub(start)
+	r.lastErr = nil
+	return true, nil
+}
+
+func (r *ConfigReloader) setError(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = err
+	return err
+}
+
+// Watch polls the config directory for changes every interval until ctx is done.
+func (r *ConfigReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				r.logger.WithError(err).Warn("failed to reload declarative configs, continuing to serve previous configs")
+				continue
+			}
+			if reloaded {
+				r.logger.WithField("generation", r.store.Generation()).Info("reloaded declarative configs")
+			}
+		}
+	}
+}
+
+func loadQuerier(configDir string) (*registry.Querier, error) {
+	cfg, err := declcfg.LoadDir(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("load declarative config directory: %v", err)
+	}
+	m, err := declcfg.ConvertToModel(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not build index model from declarative config: %v", err)
+	}
+	return registry.NewQuerier(m), nil
+}
+
+// fingerprintDir summarizes the paths, sizes and modification times of the
+// files in a directory. Files are stat'ed rather than lstat'ed so that
+// updates to symlinked files (e.g. mounted ConfigMaps) are detected.
+func fingerprintDir(dir string) (string, error) {
+	h := sha256.New()
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		target, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if target.IsDir() {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", path, target.Size(), target.ModTime().UnixNano())
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func writeReloadConfig(t *testing.T, dir string, bundles ...string) {
+	t.Helper()
+	cfg := `{"schema": "olm.package", "name": "foo", "defaultChannel": "stable"}`
+	replaces := ""
+	for _, b := range bundles {
+		cfg += fmt.Sprintf(`
+{"schema": "olm.bundle", "name": %[1]q, "package": "foo", "image": "foo:%[1]s", "properties": [
+	{"type": "olm.package", "value": {"packageName": "foo", "version": "0.0.0"}},
+	{"type": "olm.channel", "value": {"name": "stable", "replaces": %[2]q}}
+]}`, b, replaces)
+		replaces = b
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.json"), []byte(cfg), 0666))
+}
+
+func TestConfigReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeReloadConfig(t, dir, "foo.v0.1.0")
+	r, err := NewConfigReloader(dir, logrus.New())
+	require.NoError(t, err)
+
+	q := r.Querier()
+	head, err := q.GetBundleForChannel(context.TODO(), "foo", "stable")
+	require.NoError(t, err)
+	require.Equal(t, "foo.v0.1.0", head.CsvName)
+	require.Equal(t, int64(1), r.Status().Generation)
+
+	// No changes: nothing is reloaded.
+	reloaded, err := r.Reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+	require.Equal(t, int64(1), r.Status().Generation)
+
+	// A valid change is swapped in.
+	writeReloadConfig(t, dir, "foo.v0.1.0", "foo.v0.2.0")
+	reloaded, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+	head, err = q.GetBundleForChannel(context.TODO(), "foo", "stable")
+	require.NoError(t, err)
+	require.Equal(t, "foo.v0.2.0", head.CsvName)
+	status := r.Status()
+	require.Equal(t, int64(2), status.Generation)
+	require.NoError(t, status.LastError)
+
+	// An invalid change is reported, and the previous configs are still served.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.json"), []byte(`{"schema": "olm.bundle", "name": "foo.v0.3.0", "package": "bar"}`), 0666))
+	reloaded, err = r.Reload()
+	require.Error(t, err)
+	require.False(t, reloaded)
+	head, err = q.GetBundleForChannel(context.TODO(), "foo", "stable")
+	require.NoError(t, err)
+	require.Equal(t, "foo.v0.2.0", head.CsvName)
+	status = r.Status()
+	require.Equal(t, int64(2), status.Generation)
+	require.Error(t, status.LastError)
+
+	// Fixing the configs clears the error.
+	writeReloadConfig(t, dir, "foo.v0.1.0", "foo.v0.2.0", "foo.v0.3.0")
+	reloaded, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+	status = r.Status()
+	require.Equal(t, int64(3), status.Generation)
+	require.NoError(t, status.LastError)
+
+	// The status is reported while a reload is in progress.
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	done := make(chan ReloadStatus)
+	go func() { done <- r.Status() }()
+	select {
+	case status = <-done:
+		require.Equal(t, int64(3), status.Generation)
+	case <-time.After(5 * time.Second):
+		t.Fatal("status blocked by reload")
+	}
+}
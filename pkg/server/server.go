@@ -1,7 +1,12 @@
 package server
 
 import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
@@ -9,13 +14,51 @@ import (
 
 type RegistryServer struct {
 	api.UnimplementedRegistryServer
-	store registry.GRPCQuery
+	store   registry.GRPCQuery
+	watcher *CatalogWatcher
+	bundles *bundleCache
+	pages   *pageCache
 }
 
 var _ api.RegistryServer = &RegistryServer{}
 
-func NewRegistryServer(store registry.GRPCQuery) *RegistryServer {
-	return &RegistryServer{UnimplementedRegistryServer: api.UnimplementedRegistryServer{}, store: store}
+type RegistryServerOption func(*RegistryServer)
+
+// WithCatalogWatcher configures the watcher used to serve WatchCatalog.
+// By default, a watcher that reads the store once is used, which suits
+// stores whose contents never change.
+func WithCatalogWatcher(w *CatalogWatcher) RegistryServerOption {
+	return func(s *RegistryServer) {
+		s.watcher = w
+	}
+}
+
+// WithListBundlesCache configures whether the responses of ListBundles and
+// ListBundlesPage are cached. When enabled, which is the default, each bundle
+// is serialized once per generation of the store instead of on every call,
+// and the bundles that pages are cut from are read and sorted once per
+// generation for each of the last filters paged through. Stores that do not
+// report a generation, as registry.SwappableQuerier does, are assumed to never
+// change; the cache should be disabled for such stores if they can.
+func WithListBundlesCache(enabled bool) RegistryServerOption {
+	return func(s *RegistryServer) {
+		if enabled {
+			s.bundles, s.pages = newBundleCache(s.store), newPageCache(s.store)
+		} else {
+			s.bundles, s.pages = nil, nil
+		}
+	}
+}
+
+func NewRegistryServer(store registry.GRPCQuery, opts ...RegistryServerOption) *RegistryServer {
+	s := &RegistryServer{UnimplementedRegistryServer: api.UnimplementedRegistryServer{}, store: store, bundles: newBundleCache(store), pages: newPageCache(store)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.watcher == nil {
+		s.watcher = NewCatalogWatcher(store, logrus.NewEntry(logrus.StandardLogger()))
+	}
+	return s
 }
 
 func (s *RegistryServer) ListPackages(req *api.ListPackageRequest, stream api.Registry_ListPackagesServer) error {
@@ -33,7 +76,24 @@ func (s *RegistryServer) ListPackages(req *api.ListPackageRequest, stream api.Re
 }
 
 func (s *RegistryServer) ListBundles(req *api.ListBundlesRequest, stream api.Registry_ListBundlesServer) error {
-	bundles, err := s.store.ListBundles(stream.Context())
+	filter, err := registry.NewBundleFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if s.bundles != nil && filter.IsEmpty() {
+		bundles, err := s.bundles.list(stream.Context())
+		if err != nil {
+			return err
+		}
+		for _, b := range bundles {
+			if err := stream.SendMsg(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	bundles, err := registry.ListFilteredBundles(stream.Context(), s.store, filter)
 	if err != nil {
 		return err
 	}
@@ -46,6 +106,27 @@ func (s *RegistryServer) ListBundles(req *api.ListBundlesRequest, stream api.Reg
 	return nil
 }
 
+func (s *RegistryServer) ListBundlesPage(ctx context.Context, req *api.ListBundlesPageRequest) (*api.ListBundlesPageResponse, error) {
+	filter, err := registry.NewBundleFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	after, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var bundles []*api.Bundle
+	if s.pages != nil {
+		bundles, err = s.pages.list(ctx, pageFilter(filter))
+	} else {
+		bundles, err = listPageBundles(ctx, s.store, pageFilter(filter))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newBundlePage(bundles, filter, after, int(req.GetPageSize())), nil
+}
+
 func (s *RegistryServer) GetPackage(ctx context.Context, req *api.GetPackageRequest) (*api.Package, error) {
 	packageManifest, err := s.store.GetPackage(ctx, req.GetName())
 	if err != nil {
@@ -108,3 +189,72 @@ func (s *RegistryServer) GetLatestChannelEntriesThatProvide(req *api.GetLatestPr
 func (s *RegistryServer) GetDefaultBundleThatProvides(ctx context.Context, req *api.GetDefaultProviderRequest) (*api.Bundle, error) {
 	return s.store.GetBundleThatProvides(ctx, req.GetGroup(), req.GetVersion(), req.GetKind())
 }
+
+func (s *RegistryServer) WatchCatalog(req *api.WatchCatalogRequest, stream api.Registry_WatchCatalogServer) error {
+	revision, snapshot, sub, err := s.watcher.Subscribe(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	if err := stream.Send(&api.CatalogEvent{Type: api.CatalogEvent_SNAPSHOT, Revision: revision}); err != nil {
+		return err
+	}
+	if req.GetIncludeSnapshot() {
+		for _, e := range snapshot {
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case events, ok := <-sub.Events():
+			if !ok {
+				// Events were lost, so the client must watch again and
+				// re-list from the new snapshot.
+				return status.Errorf(codes.Aborted, "%v, watch again to resync", sub.Err())
+			}
+			for _, e := range events {
+				if err := stream.Send(e); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (s *RegistryServer) GetPackageGraph(ctx context.Context, req *api.GetPackageGraphRequest) (*api.PackageGraph, error) {
+	graph, err := registry.GetPackageGraph(ctx, s.store, req.GetPkgName())
+	var notFound registry.PackageNotFoundErr
+	if errors.As(err, &notFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return graph, err
+}
+
+func (s *RegistryServer) ListBundlesWithProperty(req *api.ListBundlesWithPropertyRequest, stream api.Registry_ListBundlesWithPropertyServer) error {
+	if req.GetType() == "" {
+		return status.Error(codes.InvalidArgument, "property type must be set")
+	}
+	return s.ListBundles(&api.ListBundlesRequest{
+		Properties: []*api.PropertyFilter{{Type: req.GetType(), Value: req.GetValue()}},
+		FieldMask:  req.GetFieldMask(),
+	}, stream)
+}
+
+func (s *RegistryServer) GetDeprecations(req *api.GetDeprecationsRequest, stream api.Registry_GetDeprecationsServer) error {
+	deprecations, err := registry.GetDeprecations(stream.Context(), s.store, req.GetPkgName())
+	if err != nil {
+		return err
+	}
+	for _, d := range deprecations {
+		if err := stream.Send(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
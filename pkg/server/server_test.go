@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -16,7 +17,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
@@ -640,6 +644,245 @@ func testListBundles(addr string, etcdAlpha *api.Bundle, etcdStable *api.Bundle)
 	}
 }
 
+func TestListFilteredBundles(t *testing.T) {
+	t.Run("Sqlite", testListFilteredBundles(dbAddress))
+	t.Run("DeclarativeConfig", testListFilteredBundles(cfgAddress))
+}
+
+func listBundles(t *testing.T, c api.RegistryClient, req *api.ListBundlesRequest) ([]*api.Bundle, error) {
+	stream, err := c.ListBundles(context.TODO(), req)
+	require.NoError(t, err)
+	var bundles []*api.Bundle
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			return bundles, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, b)
+	}
+}
+
+func testListFilteredBundles(addr string) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		all, err := listBundles(t, c, &api.ListBundlesRequest{})
+		require.NoError(t, err)
+
+		mask := []string{"csvName", "packageName", "channelName", "version"}
+		for name, tt := range map[string]struct {
+			req      *api.ListBundlesRequest
+			expected []string
+		}{
+			"Channel": {
+				req:      &api.ListBundlesRequest{PkgName: "etcd", ChannelName: "alpha", FieldMask: mask},
+				expected: []string{"etcdoperator.v0.6.1", "etcdoperator.v0.9.0", "etcdoperator.v0.9.2"},
+			},
+			"Property": {
+				req: &api.ListBundlesRequest{Properties: []*api.PropertyFilter{
+					{Type: "olm.package", Value: `{"version": "0.9.2", "packageName": "etcd"}`},
+				}},
+				expected: []string{"etcdoperator.v0.9.2", "etcdoperator.v0.9.2"},
+			},
+			"ProvidedAPI": {
+				req: &api.ListBundlesRequest{PkgName: "etcd", ProvidedApi: &api.GroupVersionKind{
+					Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdRestore",
+				}},
+				expected: []string{"etcdoperator.v0.9.0", "etcdoperator.v0.9.0", "etcdoperator.v0.9.0", "etcdoperator.v0.9.2", "etcdoperator.v0.9.2"},
+			},
+			"None": {
+				req: &api.ListBundlesRequest{PkgName: "missing"},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				bundles, err := listBundles(t, c, tt.req)
+				require.NoError(t, err)
+
+				var names []string
+				for _, b := range bundles {
+					names = append(names, b.CsvName)
+				}
+				require.ElementsMatch(t, tt.expected, names)
+
+				// Bundles are the same as the ones listed without a filter, masked.
+				filter, err := registry.NewBundleFilter(tt.req)
+				require.NoError(t, err)
+				for _, b := range bundles {
+					var found bool
+					for _, a := range all {
+						if !bundleLess(a, b) && !bundleLess(b, a) {
+							a = proto.Clone(a).(*api.Bundle)
+							filter.Mask(a)
+							require.True(t, proto.Equal(a, b), "expected %v, got %v", a, b)
+							found = true
+						}
+					}
+					require.True(t, found, "unexpected bundle %v", b)
+				}
+			})
+		}
+
+		_, err = listBundles(t, c, &api.ListBundlesRequest{FieldMask: []string{"unknown"}})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestListBundlesPage(t *testing.T) {
+	t.Run("Sqlite", testListBundlesPage(dbAddress))
+	t.Run("DeclarativeConfig", testListBundlesPage(cfgAddress))
+}
+
+func testListBundlesPage(addr string) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		all, err := listBundles(t, c, &api.ListBundlesRequest{})
+		require.NoError(t, err)
+		sort.Slice(all, func(i, j int) bool { return bundleLess(all[i], all[j]) })
+
+		// Pages of 3 bundles, with only the version of each.
+		req := &api.ListBundlesPageRequest{
+			Filter:   &api.ListBundlesRequest{FieldMask: []string{"version"}},
+			PageSize: 3,
+		}
+		var pages int
+		var versions []string
+		for {
+			res, err := c.ListBundlesPage(context.TODO(), req)
+			require.NoError(t, err)
+			pages++
+			require.LessOrEqual(t, len(res.Bundles), 3)
+			for _, b := range res.Bundles {
+				require.Empty(t, b.CsvName)
+				versions = append(versions, b.Version)
+			}
+			if res.NextPageToken == "" {
+				break
+			}
+			req.PageToken = res.NextPageToken
+		}
+		require.Equal(t, 7, pages)
+
+		var expected []string
+		for _, b := range all {
+			expected = append(expected, b.Version)
+		}
+		require.Equal(t, expected, versions)
+
+		// A filtered listing in a single page.
+		res, err := c.ListBundlesPage(context.TODO(), &api.ListBundlesPageRequest{
+			Filter: &api.ListBundlesRequest{PkgName: "etcd", ChannelName: "beta"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, res.NextPageToken)
+		require.Len(t, res.Bundles, 2)
+		require.Equal(t, "etcdoperator.v0.6.1", res.Bundles[0].CsvName)
+		require.Equal(t, "etcdoperator.v0.9.0", res.Bundles[1].CsvName)
+		require.NotEmpty(t, res.Bundles[0].CsvJson)
+
+		_, err = c.ListBundlesPage(context.TODO(), &api.ListBundlesPageRequest{PageToken: "invalid"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestGetPackageGraph(t *testing.T) {
+	t.Run("Sqlite", testGetPackageGraph(dbAddress))
+	t.Run("DeclarativeConfig", testGetPackageGraph(cfgAddress))
+}
+
+func testGetPackageGraph(addr string) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		graph, err := c.GetPackageGraph(context.TODO(), &api.GetPackageGraphRequest{PkgName: "etcd"})
+		require.NoError(t, err)
+		require.Equal(t, "etcd", graph.Name)
+		require.Equal(t, "alpha", graph.DefaultChannelName)
+
+		heads := map[string]string{}
+		nodes := map[string][]string{}
+		for _, ch := range graph.Channels {
+			heads[ch.Name] = ch.Head
+			for _, n := range ch.Nodes {
+				nodes[ch.Name] = append(nodes[ch.Name], n.CsvName)
+				require.False(t, n.Deprecated)
+			}
+		}
+		require.Equal(t, map[string]string{
+			"alpha":  "etcdoperator.v0.9.2",
+			"beta":   "etcdoperator.v0.9.0",
+			"stable": "etcdoperator.v0.9.2",
+		}, heads)
+		require.Equal(t, map[string][]string{
+			"alpha":  {"etcdoperator.v0.6.1", "etcdoperator.v0.9.0", "etcdoperator.v0.9.2"},
+			"beta":   {"etcdoperator.v0.6.1", "etcdoperator.v0.9.0"},
+			"stable": {"etcdoperator.v0.6.1", "etcdoperator.v0.9.0", "etcdoperator.v0.9.2"},
+		}, nodes)
+
+		_, err = c.GetPackageGraph(context.TODO(), &api.GetPackageGraphRequest{PkgName: "missing"})
+		require.Equal(t, codes.NotFound, status.Code(err), "unexpected error: %v", err)
+	}
+}
+
+func TestListBundlesWithProperty(t *testing.T) {
+	t.Run("Sqlite", testListBundlesWithProperty(dbAddress))
+	t.Run("DeclarativeConfig", testListBundlesWithProperty(cfgAddress))
+}
+
+func testListBundlesWithProperty(addr string) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		stream, err := c.ListBundlesWithProperty(context.TODO(), &api.ListBundlesWithPropertyRequest{
+			Type:      "olm.package",
+			Value:     `{"packageName":"prometheus","version":"0.22.2"}`,
+			FieldMask: []string{"csvName", "channelName"},
+		})
+		require.NoError(t, err)
+		var bundles []*api.Bundle
+		for {
+			b, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			bundles = append(bundles, b)
+		}
+		require.Len(t, bundles, 1)
+		require.True(t, proto.Equal(&api.Bundle{CsvName: "prometheusoperator.0.22.2", ChannelName: "preview"}, bundles[0]), "got %v", bundles[0])
+
+		stream, err = c.ListBundlesWithProperty(context.TODO(), &api.ListBundlesWithPropertyRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestGetDeprecations(t *testing.T) {
+	t.Run("Sqlite", testGetDeprecations(dbAddress))
+	t.Run("DeclarativeConfig", testGetDeprecations(cfgAddress))
+}
+
+func testGetDeprecations(addr string) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		// The served catalog has no deprecated bundles.
+		stream, err := c.GetDeprecations(context.TODO(), &api.GetDeprecationsRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, io.EOF, err)
+	}
+}
+
 func EqualBundles(t *testing.T, expected, actual api.Bundle) {
 	stripPlural(actual.ProvidedApis)
 	stripPlural(actual.RequiredApis)
@@ -744,3 +987,43 @@ func etcdoperator_v0_9_2(channel string, addSkipsReplaces, addExtraProperties bo
 	}
 	return b
 }
+
+func TestWatchCatalog(t *testing.T) {
+	t.Run("Sqlite", testWatchCatalog(dbAddress))
+	t.Run("DeclarativeConfig", testWatchCatalog(cfgAddress))
+}
+
+func testWatchCatalog(addr string) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		stream, err := c.WatchCatalog(ctx, &api.WatchCatalogRequest{IncludeSnapshot: true}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+
+		snapshot, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, api.CatalogEvent_SNAPSHOT, snapshot.Type)
+		require.NotEmpty(t, snapshot.Revision)
+
+		// The snapshot contents are the 3 packages followed by the 20 bundles
+		// returned by ListBundles.
+		var packages, bundles []string
+		for i := 0; i < 23; i++ {
+			e, err := stream.Recv()
+			require.NoError(t, err)
+			require.Equal(t, api.CatalogEvent_ADDED, e.Type)
+			require.Equal(t, snapshot.Revision, e.Revision)
+			if e.Package != nil {
+				packages = append(packages, e.Package.Name)
+			}
+			if e.Bundle != nil {
+				bundles = append(bundles, e.Bundle.CsvName)
+			}
+		}
+		require.Equal(t, []string{"etcd", "prometheus", "strimzi-kafka-operator"}, packages)
+		require.Len(t, bundles, 20)
+	}
+}
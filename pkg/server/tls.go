@@ -0,0 +1,33 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+)
+
+// TLSServerOptions returns the gRPC server options that serve TLS with the
+// certificate and key in certFile and keyFile, requiring client certificates
+// signed by a certificate in clientCAFile if it is set. The files are
+// reloaded when they change. No options are returned if no files are set, in
+// which case the server is insecure.
+func TLSServerOptions(certFile, keyFile, clientCAFile string, logger logrus.FieldLogger) ([]grpc.ServerOption, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, fmt.Errorf("a client CA requires a TLS certificate and key")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key must be set")
+	}
+	config, err := certs.ServerTLSConfig(certFile, keyFile, clientCAFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(config))}, nil
+}
@@ -0,0 +1,344 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// subscriberBuffer is the number of refreshes a subscriber can fall behind
+// before it is dropped.
+const subscriberBuffer = 16
+
+// ErrSubscriptionBehind is the error of a subscription that was dropped
+// because it fell too far behind. Events were lost, so its subscriber must
+// subscribe again and re-list the catalog.
+var ErrSubscriptionBehind = errors.New("catalog subscription fell behind")
+
+// generational is implemented by queriers whose contents can change, such as
+// registry.SwappableQuerier. The generation changes whenever the contents may
+// have changed.
+type generational interface {
+	Generation() int64
+}
+
+// CatalogWatcher tracks the contents of a querier and notifies subscribers
+// of the packages and bundles that are added, removed or updated.
+//
+// Snapshots are computed through the GRPCQuery interface, so any querier
+// (e.g. registry.Querier or sqlite.SQLQuerier) can be watched. Queriers that
+// report a generation are only re-read when their generation changes, and
+// no querier is re-read while there are no subscribers.
+type CatalogWatcher struct {
+	store  registry.GRPCQuery
+	logger logrus.FieldLogger
+
+	mu          sync.Mutex
+	snapshot    *catalogSnapshot
+	generation  int64
+	subscribers map[*CatalogSubscription]struct{}
+}
+
+func NewCatalogWatcher(store registry.GRPCQuery, logger logrus.FieldLogger) *CatalogWatcher {
+	return &CatalogWatcher{
+		store:       store,
+		logger:      logger,
+		subscribers: map[*CatalogSubscription]struct{}{},
+	}
+}
+
+// CatalogSubscription receives the events for each change to a watched catalog.
+type CatalogSubscription struct {
+	w      *CatalogWatcher
+	events chan []*api.CatalogEvent
+	closed bool
+	err    error
+}
+
+// Events returns the channel on which batches of events are delivered. The
+// channel is closed if the subscription is closed or falls too far behind,
+// see Err.
+func (s *CatalogSubscription) Events() <-chan []*api.CatalogEvent {
+	return s.events
+}
+
+// Err returns ErrSubscriptionBehind once the events channel was closed
+// because the subscription fell too far behind, and nil otherwise.
+func (s *CatalogSubscription) Err() error {
+	s.w.mu.Lock()
+	defer s.w.mu.Unlock()
+	return s.err
+}
+
+// Close unregisters the subscription from its watcher.
+func (s *CatalogSubscription) Close() {
+	s.w.mu.Lock()
+	defer s.w.mu.Unlock()
+	s.w.unsubscribe(s)
+}
+
+// Subscribe returns the current revision of the catalog, the events that
+// describe its full contents at that revision, and a subscription that
+// receives every change made after it.
+func (w *CatalogWatcher) Subscribe(ctx context.Context) (string, []*api.CatalogEvent, *CatalogSubscription, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.subscribers) == 0 {
+		// The snapshot isn't kept up to date without subscribers.
+		if err := w.load(ctx); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	sub := &CatalogSubscription{w: w, events: make(chan []*api.CatalogEvent, subscriberBuffer)}
+	w.subscribers[sub] = struct{}{}
+	return w.snapshot.revision, w.snapshot.events(), sub, nil
+}
+
+// Refresh re-reads the catalog and notifies subscribers of any changes. It
+// returns the number of events that were sent. Without subscribers, the
+// catalog isn't read.
+func (w *CatalogWatcher) Refresh(ctx context.Context) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.refresh(ctx)
+}
+
+// Run refreshes the catalog every interval until ctx is done.
+func (w *CatalogWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := w.Refresh(ctx)
+			if err != nil {
+				w.logger.WithError(err).Warn("failed to refresh catalog for watchers")
+				continue
+			}
+			if n > 0 {
+				w.logger.WithField("events", n).Debug("notified catalog watchers")
+			}
+		}
+	}
+}
+
+func (w *CatalogWatcher) refresh(ctx context.Context) (int, error) {
+	if len(w.subscribers) == 0 {
+		return 0, nil
+	}
+	previous := w.snapshot
+	if err := w.load(ctx); err != nil {
+		return 0, err
+	}
+	if previous == nil {
+		return 0, nil
+	}
+	events := previous.diff(w.snapshot)
+	if len(events) == 0 {
+		return 0, nil
+	}
+	for sub := range w.subscribers {
+		select {
+		case sub.events <- events:
+		default:
+			w.logger.Warn("dropping catalog watcher that fell behind")
+			sub.err = ErrSubscriptionBehind
+			w.unsubscribe(sub)
+		}
+	}
+	return len(events), nil
+}
+
+// load reads the catalog into the snapshot, unless the store reports it
+// hasn't changed since the snapshot was read.
+func (w *CatalogWatcher) load(ctx context.Context) error {
+	var generation int64
+	if g, ok := w.store.(generational); ok {
+		generation = g.Generation()
+		if w.snapshot != nil && generation == w.generation {
+			return nil
+		}
+	}
+	snap, err := newCatalogSnapshot(ctx, w.store)
+	if err != nil {
+		return err
+	}
+	w.snapshot = snap
+	w.generation = generation
+	return nil
+}
+
+func (w *CatalogWatcher) unsubscribe(sub *CatalogSubscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(w.subscribers, sub)
+	close(sub.events)
+	if len(w.subscribers) == 0 {
+		// Release the snapshot, which would otherwise go stale.
+		w.snapshot = nil
+	}
+}
+
+type bundleKey struct {
+	pkg, channel, name string
+}
+
+// catalogSnapshot is an indexed, point-in-time view of the packages and
+// bundles served by a querier.
+type catalogSnapshot struct {
+	revision string
+	packages map[string]*api.Package
+	bundles  map[bundleKey]*api.Bundle
+}
+
+func newCatalogSnapshot(ctx context.Context, store registry.GRPCQuery) (*catalogSnapshot, error) {
+	snap := &catalogSnapshot{
+		packages: map[string]*api.Package{},
+		bundles:  map[bundleKey]*api.Bundle{},
+	}
+
+	pkgNames, err := store.ListPackages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list packages: %v", err)
+	}
+	for _, name := range pkgNames {
+		pkg, err := store.GetPackage(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("get package %q: %v", name, err)
+		}
+		apiPkg := registry.PackageManifestToAPIPackage(pkg)
+		sort.Slice(apiPkg.Channels, func(i, j int) bool {
+			return apiPkg.Channels[i].Name < apiPkg.Channels[j].Name
+		})
+		snap.packages[name] = apiPkg
+	}
+
+	bundles, err := store.ListBundles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list bundles: %v", err)
+	}
+	for _, b := range bundles {
+		snap.bundles[bundleKey{b.PackageName, b.ChannelName, b.CsvName}] = b
+	}
+
+	h := sha256.New()
+	marshal := proto.MarshalOptions{Deterministic: true}
+	for _, p := range snap.sortedPackages() {
+		d, err := marshal.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("marshal package %q: %v", p.Name, err)
+		}
+		h.Write(d)
+	}
+	for _, b := range snap.sortedBundles() {
+		d, err := marshal.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("marshal bundle %q: %v", b.CsvName, err)
+		}
+		h.Write(d)
+	}
+	snap.revision = hex.EncodeToString(h.Sum(nil))
+	return snap, nil
+}
+
+func (s *catalogSnapshot) sortedPackages() []*api.Package {
+	var out []*api.Package
+	for _, p := range s.packages {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (s *catalogSnapshot) sortedBundles() []*api.Bundle {
+	var out []*api.Bundle
+	for _, b := range s.bundles {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bundleLess(out[i], out[j])
+	})
+	return out
+}
+
+func bundleLess(a, b *api.Bundle) bool {
+	if a.PackageName != b.PackageName {
+		return a.PackageName < b.PackageName
+	}
+	if a.ChannelName != b.ChannelName {
+		return a.ChannelName < b.ChannelName
+	}
+	return a.CsvName < b.CsvName
+}
+
+// events returns the events that describe the full contents of the snapshot.
+func (s *catalogSnapshot) events() []*api.CatalogEvent {
+	var events []*api.CatalogEvent
+	for _, p := range s.sortedPackages() {
+		events = append(events, &api.CatalogEvent{Type: api.CatalogEvent_ADDED, Revision: s.revision, Package: p})
+	}
+	for _, b := range s.sortedBundles() {
+		events = append(events, &api.CatalogEvent{Type: api.CatalogEvent_ADDED, Revision: s.revision, Bundle: b})
+	}
+	return events
+}
+
+// diff returns the events that transform s into next. Packages are added
+// before their bundles and removed after them.
+func (s *catalogSnapshot) diff(next *catalogSnapshot) []*api.CatalogEvent {
+	if s.revision == next.revision {
+		return nil
+	}
+	var addedPkgs, updatedPkgs, removedPkgs []*api.CatalogEvent
+	for _, p := range next.sortedPackages() {
+		old, ok := s.packages[p.Name]
+		switch {
+		case !ok:
+			addedPkgs = append(addedPkgs, &api.CatalogEvent{Type: api.CatalogEvent_ADDED, Revision: next.revision, Package: p})
+		case !proto.Equal(old, p):
+			updatedPkgs = append(updatedPkgs, &api.CatalogEvent{Type: api.CatalogEvent_UPDATED, Revision: next.revision, Package: p})
+		}
+	}
+	for _, p := range s.sortedPackages() {
+		if _, ok := next.packages[p.Name]; !ok {
+			removedPkgs = append(removedPkgs, &api.CatalogEvent{Type: api.CatalogEvent_REMOVED, Revision: next.revision, Package: p})
+		}
+	}
+
+	var bundleEvents []*api.CatalogEvent
+	for _, b := range s.sortedBundles() {
+		if _, ok := next.bundles[bundleKey{b.PackageName, b.ChannelName, b.CsvName}]; !ok {
+			bundleEvents = append(bundleEvents, &api.CatalogEvent{Type: api.CatalogEvent_REMOVED, Revision: next.revision, Bundle: b})
+		}
+	}
+	for _, b := range next.sortedBundles() {
+		old, ok := s.bundles[bundleKey{b.PackageName, b.ChannelName, b.CsvName}]
+		switch {
+		case !ok:
+			bundleEvents = append(bundleEvents, &api.CatalogEvent{Type: api.CatalogEvent_ADDED, Revision: next.revision, Bundle: b})
+		case !proto.Equal(old, b):
+			bundleEvents = append(bundleEvents, &api.CatalogEvent{Type: api.CatalogEvent_UPDATED, Revision: next.revision, Bundle: b})
+		}
+	}
+
+	events := append(addedPkgs, bundleEvents...)
+	events = append(events, updatedPkgs...)
+	return append(events, removedPkgs...)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestCatalogWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	load := func(bundles ...string) *registry.Querier {
+		writeReloadConfig(t, dir, bundles...)
+		q, err := loadQuerier(dir)
+		require.NoError(t, err)
+		return q
+	}
+
+	store := registry.NewSwappableQuerier(load("foo.v0.1.0", "foo.v0.2.0"))
+	w := NewCatalogWatcher(store, logrus.New())
+
+	revision, snapshot, sub, err := w.Subscribe(context.TODO())
+	require.NoError(t, err)
+	defer sub.Close()
+	require.NotEmpty(t, revision)
+	require.Len(t, snapshot, 3)
+
+	// Nothing changed, so no events are sent.
+	n, err := w.Refresh(context.TODO())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// Replace foo.v0.1.0 with foo.v0.3.0, which also changes the channel
+	// head and the replaces field of foo.v0.2.0.
+	store.Swap(load("foo.v0.2.0", "foo.v0.3.0"))
+	n, err = w.Refresh(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	events := <-sub.Events()
+	type event struct {
+		typ  api.CatalogEvent_Type
+		name string
+	}
+	var actual []event
+	for _, e := range events {
+		require.NotEqual(t, revision, e.Revision)
+		if e.Bundle != nil {
+			actual = append(actual, event{e.Type, e.Bundle.CsvName})
+		}
+		if e.Package != nil {
+			actual = append(actual, event{e.Type, e.Package.Name})
+		}
+	}
+	require.Equal(t, []event{
+		{api.CatalogEvent_REMOVED, "foo.v0.1.0"},
+		{api.CatalogEvent_UPDATED, "foo.v0.2.0"},
+		{api.CatalogEvent_ADDED, "foo.v0.3.0"},
+		{api.CatalogEvent_UPDATED, "foo"},
+	}, actual)
+}
+
+func TestCatalogWatcherSubscribers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bundles := []string{"foo.v0.1.0"}
+	load := func() *registry.Querier {
+		writeReloadConfig(t, dir, bundles...)
+		q, err := loadQuerier(dir)
+		require.NoError(t, err)
+		return q
+	}
+	store := registry.NewSwappableQuerier(load())
+	w := NewCatalogWatcher(store, logrus.New())
+
+	// Without subscribers, no snapshot is read or kept.
+	store.Swap(load())
+	n, err := w.Refresh(context.TODO())
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.Nil(t, w.snapshot)
+
+	_, snapshot, sub, err := w.Subscribe(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, snapshot, 2)
+
+	// A subscriber that falls behind is dropped with an error.
+	for i := 0; i <= subscriberBuffer; i++ {
+		bundles = append(bundles, fmt.Sprintf("foo.v1.%d.0", i))
+		store.Swap(load())
+		n, err := w.Refresh(context.TODO())
+		require.NoError(t, err)
+		require.NotZero(t, n)
+	}
+	for range sub.Events() {
+	}
+	require.Equal(t, ErrSubscriptionBehind, sub.Err())
+	require.Nil(t, w.snapshot)
+
+	// A closed subscription has no error.
+	_, _, sub, err = w.Subscribe(context.TODO())
+	require.NoError(t, err)
+	sub.Close()
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+	require.NoError(t, sub.Err())
+}
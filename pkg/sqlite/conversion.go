@@ -16,21 +16,42 @@ import (
 )
 
 func ToModel(ctx context.Context, q *SQLQuerier) (model.Model, error) {
-	pkgs, err := initializeModelPackages(ctx, q)
+	pkgs, _, err := toModel(ctx, q)
+	return pkgs, err
+}
+
+// NewInMemoryQuerier loads the database into memory, returning a querier that
+// answers queries the same way the database does without querying it again.
+func NewInMemoryQuerier(ctx context.Context, q *SQLQuerier) (*registry.Querier, error) {
+	pkgs, bundles, err := toModel(ctx, q)
 	if err != nil {
 		return nil, err
 	}
-	if err := populateModelChannels(ctx, pkgs, q); err != nil {
-		return nil, fmt.Errorf("populate channels: %v", err)
+	return registry.NewQuerier(pkgs, registry.WithAPIBundles(bundles)), nil
+}
+
+// toModel converts the database to a model, also returning the bundles the
+// model was converted from.
+func toModel(ctx context.Context, q *SQLQuerier) (model.Model, []*api.Bundle, error) {
+	pkgs, err := initializeModelPackages(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	bundles, err := q.ListBundles(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list bundles: %v", err)
+	}
+	if err := populateModelChannels(pkgs, bundles); err != nil {
+		return nil, nil, fmt.Errorf("populate channels: %v", err)
 	}
 	if err := populatePackageIcons(ctx, pkgs, q); err != nil {
-		return nil, fmt.Errorf("populate package icons: %v", err)
+		return nil, nil, fmt.Errorf("populate package icons: %v", err)
 	}
 	if err := pkgs.Validate(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	pkgs.Normalize()
-	return pkgs, nil
+	return pkgs, bundles, nil
 }
 
 func initializeModelPackages(ctx context.Context, q *SQLQuerier) (model.Model, error) {
@@ -71,11 +92,7 @@ func initializeModelPackages(ctx context.Context, q *SQLQuerier) (model.Model, e
 	return pkgs, nil
 }
 
-func populateModelChannels(ctx context.Context, pkgs model.Model, q *SQLQuerier) error {
-	bundles, err := q.ListBundles(ctx)
-	if err != nil {
-		return err
-	}
+func populateModelChannels(pkgs model.Model, bundles []*api.Bundle) error {
 	for _, bundle := range bundles {
 		pkg, ok := pkgs[bundle.PackageName]
 		if !ok {
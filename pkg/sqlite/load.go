@@ -12,6 +12,7 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	libsemver "github.com/operator-framework/operator-registry/pkg/lib/semver"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
 
@@ -19,6 +20,9 @@ type sqlLoader struct {
 	db          *sql.DB
 	migrator    Migrator
 	enableAlpha bool
+
+	// ctx is the context operations are traced under, see WithContext.
+	ctx context.Context
 }
 
 type MigratableLoader interface {
@@ -27,6 +31,16 @@ type MigratableLoader interface {
 }
 
 var _ MigratableLoader = &sqlLoader{}
+var _ registry.ContextLoad = &sqlLoader{}
+
+// bundleAttributes describes the bundle being loaded by a span.
+func bundleAttributes(bundle *registry.Bundle) []tracing.Attribute {
+	return []tracing.Attribute{
+		tracing.String("bundle", bundle.Name),
+		tracing.String("package", bundle.Package),
+		tracing.String("image", bundle.BundleImage),
+	}
+}
 
 func NewSQLLiteLoader(db *sql.DB, opts ...DbOption) (MigratableLoader, error) {
 	options := defaultDBOptions()
@@ -53,7 +67,26 @@ func (s *sqlLoader) Migrate(ctx context.Context) error {
 	return s.migrator.Migrate(ctx)
 }
 
-func (s *sqlLoader) AddOperatorBundle(bundle *registry.Bundle) error {
+// WithContext returns a loader of the same database whose operations are
+// traced under ctx.
+func (s *sqlLoader) WithContext(ctx context.Context) registry.Load {
+	l := *s
+	l.ctx = ctx
+	return &l
+}
+
+// context returns the context operations are traced under.
+func (s *sqlLoader) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s *sqlLoader) AddOperatorBundle(bundle *registry.Bundle) (err error) {
+	_, span := tracing.Start(s.context(), "sqlite.AddOperatorBundle", bundleAttributes(bundle)...)
+	defer func() { span.EndWithError(err) }()
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -391,7 +424,10 @@ func (s *sqlLoader) appendSkips(tx *sql.Tx, skips []string, csvName string) erro
 	return err
 }
 
-func (s *sqlLoader) AddPackageChannelsFromGraph(graph *registry.Package) error {
+func (s *sqlLoader) AddPackageChannelsFromGraph(graph *registry.Package) (err error) {
+	_, span := tracing.Start(s.context(), "sqlite.AddPackageChannelsFromGraph", tracing.String("package", graph.Name))
+	defer func() { span.EndWithError(err) }()
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -512,7 +548,10 @@ func (s *sqlLoader) AddPackageChannelsFromGraph(graph *registry.Package) error {
 	return utilerrors.NewAggregate(errs)
 }
 
-func (s *sqlLoader) AddPackageChannels(manifest registry.PackageManifest) error {
+func (s *sqlLoader) AddPackageChannels(manifest registry.PackageManifest) (err error) {
+	_, span := tracing.Start(s.context(), "sqlite.AddPackageChannels", tracing.String("package", manifest.PackageName))
+	defer func() { span.EndWithError(err) }()
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -725,7 +764,10 @@ func (s *sqlLoader) addPackageChannels(tx *sql.Tx, manifest registry.PackageMani
 	return utilerrors.NewAggregate(errs)
 }
 
-func (s *sqlLoader) ClearNonHeadBundles() error {
+func (s *sqlLoader) ClearNonHeadBundles() (err error) {
+	_, span := tracing.Start(s.context(), "sqlite.ClearNonHeadBundles")
+	defer func() { span.EndWithError(err) }()
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -1030,7 +1072,10 @@ func (s *sqlLoader) AddBundleSemver(graph *registry.Package, bundle *registry.Bu
 	return nil
 }
 
-func (s *sqlLoader) AddBundlePackageChannels(manifest registry.PackageManifest, bundle *registry.Bundle) error {
+func (s *sqlLoader) AddBundlePackageChannels(manifest registry.PackageManifest, bundle *registry.Bundle) (err error) {
+	_, span := tracing.Start(s.context(), "sqlite.AddBundlePackageChannels", bundleAttributes(bundle)...)
+	defer func() { span.EndWithError(err) }()
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+const PropertiesTextMigrationKey = 13
+
+// Register this migration
+func init() {
+	registerMigration(PropertiesTextMigrationKey, propertiesTextMigration)
+}
+
+// propertiesTextMigration rewrites the property values that the properties migration stored as blobs to text, so
+// that they compare equal to the text values inserted by the loader.
+var propertiesTextMigration = &Migration{
+	Id: PropertiesTextMigrationKey,
+	Up: func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE properties SET value = CAST(value AS TEXT) WHERE typeof(value) = 'blob'`)
+		return err
+	},
+	Down: func(ctx context.Context, tx *sql.Tx) error {
+		// Text values are read the same way as blobs, so they are left as they are.
+		return nil
+	},
+}
@@ -0,0 +1,43 @@
+package migrations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite/migrations"
+)
+
+func TestPropertiesText(t *testing.T) {
+	db, migrator, cleanup := CreateTestDbAt(t, migrations.PropertiesTextMigrationKey-1)
+	defer cleanup()
+
+	_, err := db.Exec("INSERT INTO operatorbundle(name, version, bundlepath, csv) VALUES (?, ?, ?, ?)", "operator.v1.0.0", "1.0.0", "quay.io/operator:v1.0.0", "operator.v1.0.0's csv")
+	require.NoError(t, err)
+	insertProperty := "INSERT INTO properties(type, value, operatorbundle_name, operatorbundle_version, operatorbundle_path) VALUES (?, ?, ?, ?, ?)"
+	// Values are stored as blobs when inserted as byte slices, as the properties migration did.
+	_, err = db.Exec(insertProperty, "olm.package", []byte(`{"packageName":"operator","version":"1.0.0"}`), "operator.v1.0.0", "1.0.0", "quay.io/operator:v1.0.0")
+	require.NoError(t, err)
+	_, err = db.Exec(insertProperty, "olm.gvk", `{"group":"test.io","kind":"Test","version":"v1"}`, "operator.v1.0.0", "1.0.0", "quay.io/operator:v1.0.0")
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up(context.Background(), migrations.Only(migrations.PropertiesTextMigrationKey)))
+
+	rows, err := db.Query("SELECT type, typeof(value), value FROM properties ORDER BY type")
+	require.NoError(t, err)
+	defer rows.Close()
+	var got [][3]string
+	for rows.Next() {
+		var typ, valueType, value string
+		require.NoError(t, rows.Scan(&typ, &valueType, &value))
+		got = append(got, [3]string{typ, valueType, value})
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, [][3]string{
+		{"olm.gvk", "text", `{"group":"test.io","kind":"Test","version":"v1"}`},
+		{"olm.package", "text", `{"packageName":"operator","version":"1.0.0"}`},
+	}, got)
+
+	require.NoError(t, migrator.Down(context.Background(), migrations.Only(migrations.PropertiesTextMigrationKey)))
+}
@@ -18,6 +18,12 @@ type Migrator interface {
 	Down(ctx context.Context, migrations migrations.Migrations) error
 }
 
+// MigrationVersioner is implemented by the migrators that can report the
+// migration version of their database.
+type MigrationVersioner interface {
+	Version(ctx context.Context) (int, error)
+}
+
 type SQLLiteMigrator struct {
 	db              *sql.DB
 	migrationsTable string
@@ -148,6 +154,17 @@ func (m *SQLLiteMigrator) Down(ctx context.Context, migrations migrations.Migrat
 	return commitErr
 }
 
+// Version returns the id of the last migration applied to the database, or
+// NilVersion if none have been applied.
+func (m *SQLLiteMigrator) Version(ctx context.Context) (int, error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return NilVersion, err
+	}
+	defer tx.Rollback()
+	return m.version(ctx, tx)
+}
+
 func (m *SQLLiteMigrator) ensureMigrationTable(ctx context.Context, tx *sql.Tx) error {
 	sql := fmt.Sprintf(`
 	CREATE TABLE IF NOT EXISTS %s (
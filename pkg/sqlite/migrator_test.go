@@ -431,3 +431,23 @@ func TestSQLLiteMigrator_Migrate(t *testing.T) {
 		})
 	}
 }
+
+func TestSQLLiteMigrator_Version(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	m := &SQLLiteMigrator{
+		db:              db,
+		migrationsTable: DefaultMigrationsTable,
+		migrations:      migrations.All(),
+	}
+
+	version, err := m.Version(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, NilVersion, version)
+
+	require.NoError(t, m.Migrate(context.TODO()))
+	version, err = m.Version(context.TODO())
+	require.NoError(t, err)
+	latest := migrations.All().From(0)
+	require.Equal(t, latest[len(latest)-1].Id, version)
+}
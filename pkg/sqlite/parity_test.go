@@ -0,0 +1,336 @@
+package sqlite_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/lib/tmp"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+// TestInMemoryQuerierParity checks that serving a database from memory returns
+// the same results as querying the database for every GRPCQuery method. Lists
+// of packages and channel entries are compared regardless of order, which
+// neither backend defines.
+func TestInMemoryQuerierParity(t *testing.T) {
+	for name, newDB := range map[string]func(t *testing.T) string{
+		"IndexDB":        indexDB,
+		"DeprecatedDB":   deprecatedDB,
+		"ManifestsDir":   manifestsDB("../../manifests"),
+		"LoaderTestData": manifestsDB("./testdata/loader_data"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.TODO()
+			db, err := sqlite.Open(newDB(t))
+			require.NoError(t, err)
+			defer db.Close()
+			migrator, err := sqlite.NewSQLLiteMigrator(db)
+			require.NoError(t, err)
+			require.NoError(t, migrator.Migrate(ctx))
+
+			store := sqlite.NewSQLLiteQuerierFromDb(db)
+			inMemory, err := sqlite.NewInMemoryQuerier(ctx, store)
+			require.NoError(t, err)
+			testParity(t, store, inMemory)
+
+			deprecations, err := registry.GetDeprecations(ctx, store, "")
+			require.NoError(t, err)
+			if name != "DeprecatedDB" {
+				require.Empty(t, deprecations)
+				return
+			}
+			require.Len(t, deprecations, 1)
+			require.Equal(t, "etcdoperator.v0.9.0", deprecations[0].CsvName)
+			require.Equal(t, []string{"alpha", "stable"}, deprecations[0].Channels)
+			graph, err := registry.GetPackageGraph(ctx, store, "etcd")
+			require.NoError(t, err)
+			var channels []string
+			for _, ch := range graph.Channels {
+				channels = append(channels, ch.Name)
+				for _, node := range ch.Nodes {
+					require.Equal(t, node.CsvName == "etcdoperator.v0.9.0", node.Deprecated, "%s in %s", node.CsvName, ch.Name)
+				}
+			}
+			require.Equal(t, []string{"alpha", "stable"}, channels)
+		})
+	}
+}
+
+func indexDB(t *testing.T) string {
+	dbFile, err := tmp.CopyTmpDB("../lib/indexer/testdata/bundles.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Remove(dbFile) })
+	return dbFile
+}
+
+// deprecatedDB returns the index database with etcdoperator.v0.9.0
+// deprecated. The beta channel, which it is the head of, is removed.
+func deprecatedDB(t *testing.T) string {
+	dbFile := indexDB(t)
+	db, err := sqlite.Open(dbFile)
+	require.NoError(t, err)
+	defer db.Close()
+	load, err := sqlite.NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+	require.NoError(t, sqlite.NewSQLDeprecatorForBundles(load, []string{"quay.io/olmtest/example-bundle:etcdoperator.v0.9.0"}).Deprecate())
+	return dbFile
+}
+
+func manifestsDB(dir string) func(t *testing.T) string {
+	return func(t *testing.T) string {
+		tmpDir, err := ioutil.TempDir("", "parity-")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(tmpDir) })
+		dbFile := filepath.Join(tmpDir, "index.db")
+		db, err := sqlite.Open(dbFile)
+		require.NoError(t, err)
+		defer db.Close()
+		load, err := sqlite.NewSQLLiteLoader(db)
+		require.NoError(t, err)
+		require.NoError(t, load.Migrate(context.TODO()))
+		require.NoError(t, sqlite.NewSQLLoaderForDirectory(load, dir).Populate())
+		return dbFile
+	}
+}
+
+func testParity(t *testing.T, expected, actual registry.GRPCQuery) {
+	ctx := context.TODO()
+	call := func(name string, f func(q registry.GRPCQuery) (interface{}, error)) {
+		t.Helper()
+		e, eErr := f(expected)
+		a, aErr := f(actual)
+		if eErr != nil {
+			require.Error(t, aErr, "%s: expected an error like %v, got %s", name, eErr, toJSON(t, a))
+			return
+		}
+		require.NoError(t, aErr, name)
+		require.Empty(t, jsonDiff("", toJSON(t, e), toJSON(t, a)), name)
+	}
+
+	pkgNames, err := expected.ListPackages(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, pkgNames)
+	call("ListPackages", func(q registry.GRPCQuery) (interface{}, error) {
+		pkgs, err := q.ListPackages(ctx)
+		sort.Strings(pkgs)
+		return pkgs, err
+	})
+	call("ListBundles", func(q registry.GRPCQuery) (interface{}, error) {
+		bundles, err := q.ListBundles(ctx)
+		sortBundles(bundles)
+		return bundles, err
+	})
+
+	type gvk struct{ group, version, kind string }
+	gvks := map[gvk]struct{}{{"missing.example.com", "v1", "Missing"}: {}}
+	bundles, err := expected.ListBundles(ctx)
+	require.NoError(t, err)
+	for _, b := range bundles {
+		for _, a := range append(b.ProvidedApis, b.RequiredApis...) {
+			gvks[gvk{a.Group, a.Version, a.Kind}] = struct{}{}
+		}
+		call("GetBundle", func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetBundle(ctx, b.PackageName, b.ChannelName, b.CsvName)
+		})
+		call("GetChannelEntriesThatReplace", func(q registry.GRPCQuery) (interface{}, error) {
+			entries, err := q.GetChannelEntriesThatReplace(ctx, b.CsvName)
+			sortEntries(entries)
+			return entries, err
+		})
+		call("GetBundleThatReplaces", func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetBundleThatReplaces(ctx, b.CsvName, b.PackageName, b.ChannelName)
+		})
+	}
+
+	for _, name := range append(pkgNames, "missing") {
+		call("GetPackage", func(q registry.GRPCQuery) (interface{}, error) {
+			pkg, err := q.GetPackage(ctx, name)
+			if pkg != nil {
+				sort.Slice(pkg.Channels, func(i, j int) bool { return pkg.Channels[i].Name < pkg.Channels[j].Name })
+			}
+			return pkg, err
+		})
+		pkg, err := expected.GetPackage(ctx, name)
+		if err != nil {
+			continue
+		}
+		for _, ch := range append(pkg.Channels, registry.PackageChannel{Name: "missing"}) {
+			call("GetBundleForChannel", func(q registry.GRPCQuery) (interface{}, error) {
+				return q.GetBundleForChannel(ctx, name, ch.Name)
+			})
+		}
+	}
+
+	for _, name := range append(pkgNames, "", "missing") {
+		call("GetPackageGraph", func(q registry.GRPCQuery) (interface{}, error) {
+			return registry.GetPackageGraph(ctx, q, name)
+		})
+		call("GetDeprecations", func(q registry.GRPCQuery) (interface{}, error) {
+			return registry.GetDeprecations(ctx, q, name)
+		})
+	}
+
+	filters := []registry.BundleFilter{
+		{PackageName: "missing"},
+		{FieldMask: []string{"csvName", "packageName", "channelName", "version", "properties"}},
+		{Properties: []registry.PropertyFilter{{Type: registry.DeprecatedType}}},
+	}
+	for _, b := range bundles {
+		filters = append(filters,
+			registry.BundleFilter{PackageName: b.PackageName},
+			registry.BundleFilter{PackageName: b.PackageName, ChannelName: b.ChannelName},
+		)
+		for _, p := range b.Properties {
+			filters = append(filters, registry.BundleFilter{Properties: []registry.PropertyFilter{{Type: p.Type, Value: p.Value}}})
+		}
+	}
+	for g := range gvks {
+		filters = append(filters, registry.BundleFilter{ProvidedAPI: &registry.APIKey{Group: g.group, Version: g.version, Kind: g.kind}})
+	}
+	for _, filter := range filters {
+		call("ListFilteredBundles", func(q registry.GRPCQuery) (interface{}, error) {
+			bundles, err := registry.ListFilteredBundles(ctx, q, filter)
+			sortBundles(bundles)
+			return bundles, err
+		})
+	}
+
+	for g := range gvks {
+		call("GetChannelEntriesThatProvide", func(q registry.GRPCQuery) (interface{}, error) {
+			entries, err := q.GetChannelEntriesThatProvide(ctx, g.group, g.version, g.kind)
+			sortEntries(entries)
+			return entries, err
+		})
+		call("GetLatestChannelEntriesThatProvide", func(q registry.GRPCQuery) (interface{}, error) {
+			entries, err := q.GetLatestChannelEntriesThatProvide(ctx, g.group, g.version, g.kind)
+			sortEntries(entries)
+			return entries, err
+		})
+		call("GetBundleThatProvides", func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetBundleThatProvides(ctx, g.group, g.version, g.kind)
+		})
+	}
+}
+
+func sortBundles(bundles []*api.Bundle) {
+	sort.Slice(bundles, func(i, j int) bool {
+		if bundles[i].PackageName != bundles[j].PackageName {
+			return bundles[i].PackageName < bundles[j].PackageName
+		}
+		if bundles[i].ChannelName != bundles[j].ChannelName {
+			return bundles[i].ChannelName < bundles[j].ChannelName
+		}
+		return bundles[i].CsvName < bundles[j].CsvName
+	})
+}
+
+func sortEntries(entries []*registry.ChannelEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.PackageName != b.PackageName {
+			return a.PackageName < b.PackageName
+		}
+		if a.ChannelName != b.ChannelName {
+			return a.ChannelName < b.ChannelName
+		}
+		if a.BundleName != b.BundleName {
+			return a.BundleName < b.BundleName
+		}
+		return a.Replaces < b.Replaces
+	})
+}
+
+// toJSON converts v to its generic JSON representation. Bundle properties are
+// sorted, since the database returns them in a different order depending on
+// the query.
+func toJSON(t *testing.T, v interface{}) interface{} {
+	switch b := v.(type) {
+	case *api.Bundle:
+		v = sortedProperties(b)
+	case []*api.Bundle:
+		var bundles []*api.Bundle
+		for _, bundle := range b {
+			bundles = append(bundles, sortedProperties(bundle))
+		}
+		v = bundles
+	}
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	var out interface{}
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func sortedProperties(b *api.Bundle) *api.Bundle {
+	if b == nil {
+		return nil
+	}
+	b = proto.Clone(b).(*api.Bundle)
+	sort.Slice(b.Properties, func(i, j int) bool {
+		if b.Properties[i].Type != b.Properties[j].Type {
+			return b.Properties[i].Type < b.Properties[j].Type
+		}
+		return b.Properties[i].Value < b.Properties[j].Value
+	})
+	return b
+}
+
+// jsonDiff returns the paths at which the generic JSON values e and a differ.
+func jsonDiff(path string, e, a interface{}) []string {
+	switch e := e.(type) {
+	case map[string]interface{}:
+		a, ok := a.(map[string]interface{})
+		if !ok {
+			break
+		}
+		var diffs []string
+		keys := map[string]struct{}{}
+		for k := range e {
+			keys[k] = struct{}{}
+		}
+		for k := range a {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			diffs = append(diffs, jsonDiff(path+"."+k, e[k], a[k])...)
+		}
+		sort.Strings(diffs)
+		return diffs
+	case []interface{}:
+		a, ok := a.([]interface{})
+		if !ok || len(a) != len(e) {
+			break
+		}
+		var diffs []string
+		for i := range e {
+			diffs = append(diffs, jsonDiff(fmt.Sprintf("%s[%d]", path, i), e[i], a[i])...)
+		}
+		return diffs
+	default:
+		if reflect.DeepEqual(e, a) {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s: expected %s, got %s", path, truncate(e), truncate(a))}
+}
+
+func truncate(v interface{}) string {
+	s := fmt.Sprintf("%v", v)
+	if len(s) > 200 {
+		return s[:200] + "..."
+	}
+	return s
+}
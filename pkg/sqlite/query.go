@@ -38,6 +38,7 @@ type SQLQuerier struct {
 }
 
 var _ registry.Query = &SQLQuerier{}
+var _ registry.FilteredBundleLister = &SQLQuerier{}
 
 func NewSQLLiteQuerier(dbFilename string) (*SQLQuerier, error) {
 	db, err := OpenReadOnly(dbFilename)
@@ -114,7 +115,7 @@ func (s *SQLQuerier) GetPackage(ctx context.Context, name string) (*registry.Pac
 	var channelName sql.NullString
 	var bundleName sql.NullString
 	if !rows.Next() {
-		return nil, fmt.Errorf("package %s not found", name)
+		return nil, registry.PackageNotFoundErr{ErrorString: fmt.Sprintf("package %s not found", name)}
 	}
 	if err := rows.Scan(&pkgName, &defaultChannel, &channelName, &bundleName); err != nil {
 		return nil, err
@@ -430,7 +431,7 @@ func (s *SQLQuerier) GetChannelEntriesThatProvide(ctx context.Context, group, ve
           FROM channel_entry
           INNER JOIN properties ON channel_entry.operatorbundle_name = properties.operatorbundle_name
           LEFT OUTER JOIN channel_entry replaces ON channel_entry.replaces = replaces.entry_id
-		  WHERE properties.type=? AND properties.value=?`
+		  WHERE properties.type=? AND CAST(properties.value AS TEXT)=?`
 
 	value, err := json.Marshal(map[string]string{
 		"group":   group,
@@ -477,7 +478,7 @@ func (s *SQLQuerier) GetLatestChannelEntriesThatProvide(ctx context.Context, gro
           FROM channel_entry
           INNER JOIN properties ON channel_entry.operatorbundle_name = properties.operatorbundle_name
 		  LEFT OUTER JOIN channel_entry replaces ON channel_entry.replaces = replaces.entry_id
-		  WHERE properties.type = ? AND properties.value = ?
+		  WHERE properties.type = ? AND CAST(properties.value AS TEXT) = ?
 		  GROUP BY channel_entry.package_name, channel_entry.channel_name`
 
 	value, err := json.Marshal(map[string]string{
@@ -528,7 +529,7 @@ func (s *SQLQuerier) GetBundleThatProvides(ctx context.Context, group, apiVersio
 		  INNER JOIN operatorbundle ON operatorbundle.name = channel_entry.operatorbundle_name
 		  INNER JOIN properties ON channel_entry.operatorbundle_name = properties.operatorbundle_name
 		  INNER JOIN package ON package.name = channel_entry.package_name
-		  WHERE properties.type = ? AND properties.value = ? AND package.default_channel = channel_entry.channel_name
+		  WHERE properties.type = ? AND CAST(properties.value AS TEXT) = ? AND package.default_channel = channel_entry.channel_name
 		  GROUP BY channel_entry.package_name, channel_entry.channel_name`
 
 	value, err := json.Marshal(map[string]string{
@@ -933,7 +934,7 @@ func (s *SQLQuerier) GetCurrentCSVNameForChannel(ctx context.Context, pkgName, c
 // represent channel heads. All other edges are merged into an
 // aggregate "skips" column. The result contains one row per bundle
 // for each channel in which the bundle appears.
-const listBundlesQuery = `
+const listBundlesWith = `
 WITH RECURSIVE
 tip (depth) AS (
   SELECT min(depth)
@@ -970,22 +971,9 @@ tip (depth) AS (
       INNER JOIN channel_entry AS skipped_entry
         ON skips_entry.skips = skipped_entry.entry_id
     GROUP BY all_entry.operatorbundle_name, all_entry.package_name, all_entry.channel_name
-)
-SELECT
-    replaces_bundle.entry_id,
-    operatorbundle.bundle,
-    operatorbundle.bundlepath,
-    operatorbundle.name,
-    replaces_bundle.package_name,
-    replaces_bundle.channel_name,
-    replaces_bundle.replaces,
-    skips_bundle.skips,
-    operatorbundle.version,
-    operatorbundle.skiprange,
-    dependencies.type,
-    dependencies.value,
-    properties.type,
-    properties.value
+)`
+
+const listBundlesFrom = `
   FROM replaces_bundle
     INNER JOIN operatorbundle
       ON replaces_bundle.operatorbundle_name = operatorbundle.name
@@ -998,8 +986,62 @@ SELECT
     LEFT OUTER JOIN properties
       ON operatorbundle.name = properties.operatorbundle_name`
 
+var listBundlesQuery = listBundlesSelect(true)
+
+// listBundlesSelect returns the ListBundles query. The bundle column, which
+// holds the bundle manifests, is NULL unless manifests is set.
+func listBundlesSelect(manifests bool) string {
+	bundle := "NULL"
+	if manifests {
+		bundle = "operatorbundle.bundle"
+	}
+	columns := []string{
+		"replaces_bundle.entry_id",
+		bundle,
+		"operatorbundle.bundlepath",
+		"operatorbundle.name",
+		"replaces_bundle.package_name",
+		"replaces_bundle.channel_name",
+		"replaces_bundle.replaces",
+		"skips_bundle.skips",
+		"operatorbundle.version",
+		"operatorbundle.skiprange",
+		"dependencies.type",
+		"dependencies.value",
+		"properties.type",
+		"properties.value",
+	}
+	return listBundlesWith + "\nSELECT\n    " + strings.Join(columns, ",\n    ") + listBundlesFrom
+}
+
 func (s *SQLQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
-	rows, err := s.db.QueryContext(ctx, listBundlesQuery)
+	return s.ListFilteredBundles(ctx, registry.BundleFilter{})
+}
+
+// ListFilteredBundles narrows the bundles it reads by package, channel and
+// property type in SQL, and matches the rest of the filter against the bundles
+// it builds. Bundle manifests are not read if the field mask omits them.
+func (s *SQLQuerier) ListFilteredBundles(ctx context.Context, filter registry.BundleFilter) ([]*api.Bundle, error) {
+	query := listBundlesSelect(filter.Includes("csvJson") || filter.Includes("object"))
+	var conditions []string
+	var args []interface{}
+	if filter.PackageName != "" {
+		conditions = append(conditions, "replaces_bundle.package_name = ?")
+		args = append(args, filter.PackageName)
+	}
+	if filter.ChannelName != "" {
+		conditions = append(conditions, "replaces_bundle.channel_name = ?")
+		args = append(args, filter.ChannelName)
+	}
+	for _, p := range filter.Properties {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM properties AS filter_properties WHERE filter_properties.operatorbundle_name = operatorbundle.name AND filter_properties.type = ?)")
+		args = append(args, p.Type)
+	}
+	if len(conditions) > 0 {
+		query += "\n  WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -1107,6 +1149,10 @@ func (s *SQLQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
 			newProps := uniqueProps(v.Properties)
 			v.Properties = newProps
 		}
+		if !filter.Matches(v) {
+			continue
+		}
+		filter.Mask(v)
 		bundles = append(bundles, v)
 	}
 
@@ -108,7 +108,9 @@ func buildIndexWith(containerTool, fromIndexImage, toIndexImage string, bundleIm
 		Bundles:           bundleImages,
 		Permissive:        false,
 		Overwrite:         overwriteLatest,
-		SkipTLS:           *skipTLSForRegistry,
+		PullOptions: lregistry.PullOptions{
+			SkipTLS: *skipTLSForRegistry,
+		},
 	}
 
 	return indexAdder.AddToIndex(request)
@@ -169,7 +171,9 @@ func exportPackageWith(containerTool string) error {
 		Packages:      packages,
 		DownloadPath:  "downloaded",
 		ContainerTool: containertools.NewContainerTool(containerTool, containertools.NoneTool),
-		SkipTLS:       *skipTLSForRegistry,
+		PullOptions: lregistry.PullOptions{
+			SkipTLS: *skipTLSForRegistry,
+		},
 	}
 
 	return indexExporter.ExportFromIndex(request)
@@ -185,7 +189,9 @@ func exportIndexImageWith(containerTool string) error {
 		Packages:      []string{},
 		DownloadPath:  "downloaded",
 		ContainerTool: containertools.NewContainerTool(containerTool, containertools.NoneTool),
-		SkipTLS:       *skipTLSForRegistry,
+		PullOptions: lregistry.PullOptions{
+			SkipTLS: *skipTLSForRegistry,
+		},
 	}
 
 	return indexExporter.ExportFromIndex(request)
@@ -460,8 +466,10 @@ var _ = Describe("opm", func() {
 					}
 
 					request := lregistry.AddToRegistryRequest{
-						Permissive:    false,
-						SkipTLS:       *skipTLSForRegistry,
+						Permissive: false,
+						PullOptions: lregistry.PullOptions{
+							SkipTLS: *skipTLSForRegistry,
+						},
 						InputDatabase: dbFile,
 						Bundles:       []string{ch.Head.BundlePath},
 						Mode:          registry.ReplacesMode,